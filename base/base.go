@@ -2,13 +2,42 @@ package base
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/antlr4-go/antlr/v4"
 )
 
+// SegmentKind is the kind of a SingleSQL.
+type SegmentKind int
+
+const (
+	// SegmentStatement is a sql statement.
+	SegmentStatement SegmentKind = iota
+	// SegmentCopyData is the data of a PostgreSQL `COPY ... FROM STDIN` statement, without the `\.` end marker.
+	SegmentCopyData
+	// SegmentMetaCommand is a psql meta-command such as `\connect db`, it ends at the end of the line.
+	SegmentMetaCommand
+)
+
+func (k SegmentKind) String() string {
+	switch k {
+	case SegmentStatement:
+		return "Statement"
+	case SegmentCopyData:
+		return "CopyData"
+	case SegmentMetaCommand:
+		return "MetaCommand"
+	}
+	return fmt.Sprintf("SegmentKind(%d)", int(k))
+}
+
 // SingleSQL is a separate SQL split from multi-SQL.
 type SingleSQL struct {
 	Text string
+	// Kind is the kind of the segment, only PostgreSQL scripts may contain segments other than statements.
+	Kind SegmentKind
 	// BaseLine is the line number of the first line of the SQL in the original SQL.
 	// HINT: ZERO based.
 	BaseLine int
@@ -39,9 +68,22 @@ type SingleSQL struct {
 
 // SyntaxError is a syntax error.
 type SyntaxError struct {
-	Line    int
-	Column  int
-	Message string
+	Line   int
+	Column int
+	// ByteOffset is the byte offset of the offending token in the original SQL.
+	ByteOffset int
+	// Token is the text of the offending token, empty at the end of the input.
+	Token string
+	// TokenType is the token type of the offending token, antlr.TokenEOF at the end of the input,
+	// antlr.TokenInvalidType for the text not recognized by the lexer.
+	TokenType int
+	// Expected is the display names of the tokens which the parser expected, empty for lexer errors.
+	Expected []string
+	// StatementIndex is the index of the statement containing the error among the non-empty sqls of the original SQL.
+	StatementIndex int
+	// SourceLine is the line of the original SQL containing the error, used by Format.
+	SourceLine string
+	Message    string
 }
 
 // Error returns the error message.
@@ -49,34 +91,237 @@ func (e *SyntaxError) Error() string {
 	return e.Message
 }
 
+// maxFormatExpected is the max number of expected tokens printed by Format.
+const maxFormatExpected = 10
+
+// Format returns the error with the source line and a caret under the error column, such as
+//
+//	Syntax error at line 1:9 near "fro"
+//	select * fro t
+//	         ^
+//	expecting one of: FROM, WHERE, ...
+func (e *SyntaxError) Format() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Syntax error at line %d:%d %s\n", e.Line, e.Column, e.near())
+	sb.WriteString(e.SourceLine)
+	sb.WriteByte('\n')
+	// Keep the tabs so that the caret lines up with the source line.
+	column := 0
+	for _, r := range e.SourceLine {
+		if column == e.Column {
+			break
+		}
+		if r == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+		column++
+	}
+	sb.WriteString(strings.Repeat(" ", e.Column-column))
+	sb.WriteByte('^')
+	if len(e.Expected) > 0 {
+		sb.WriteString("\nexpecting one of: ")
+		if len(e.Expected) > maxFormatExpected {
+			sb.WriteString(strings.Join(e.Expected[:maxFormatExpected], ", "))
+			sb.WriteString(", ...")
+		} else {
+			sb.WriteString(strings.Join(e.Expected, ", "))
+		}
+	}
+	return sb.String()
+}
+
+// near describes the offending token.
+func (e *SyntaxError) near() string {
+	if e.TokenType == antlr.TokenEOF {
+		return "at end of input"
+	}
+	return fmt.Sprintf("near %q", e.Token)
+}
+
+// SyntaxErrors is the list of all syntax errors in the SQL, ordered by position.
+type SyntaxErrors []*SyntaxError
+
+// Error returns the error messages, one per line.
+func (e SyntaxErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap returns the syntax errors, so that errors.As finds the first *SyntaxError.
+func (e SyntaxErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
 // ParseErrorListener is a custom error listener for PLSQL parser.
 type ParseErrorListener struct {
 	BaseLine int
-	Err      *SyntaxError
+	// BaseColumn is the column of the parsed SQL in the original SQL, it applies to the errors on the first line.
+	BaseColumn int
+	// BaseOffset is the byte offset of the parsed SQL in the original SQL.
+	BaseOffset int
+	// CollectAll records every error into Errs, relying on the error recovery of the parser.
+	// Otherwise only the first error is recorded.
+	CollectAll bool
+	// StatementIndex is the index of the parsed SQL among the non-empty sqls split from the original SQL.
+	StatementIndex int
+	Err            *SyntaxError
+	Errs           SyntaxErrors
+
+	// linePrefix is the text before the parsed SQL on its first line in the original SQL.
+	linePrefix string
 }
 
 // SyntaxError returns the errors.
-func (l *ParseErrorListener) SyntaxError(_ antlr.Recognizer, token any, line, column int, msg string, _ antlr.RecognitionException) {
+func (l *ParseErrorListener) SyntaxError(recognizer antlr.Recognizer, token any, line, column int, msg string, _ antlr.RecognitionException) {
+	if l.Err != nil && !l.CollectAll {
+		return
+	}
+
+	if line == 1 {
+		column += l.BaseColumn
+	}
+	err := &SyntaxError{
+		Line:           line + l.BaseLine,
+		Column:         column,
+		StatementIndex: l.StatementIndex,
+	}
+	errMessage := ""
+	var stream antlr.CharStream
+	if token, ok := token.(*antlr.CommonToken); ok {
+		stream = token.GetInputStream()
+		start := token.GetStart() - 40
+		if start < 0 {
+			start = 0
+		}
+		stop := token.GetStop()
+		if stop >= stream.Size() {
+			stop = stream.Size() - 1
+		}
+		errMessage = fmt.Sprintf("related text: %s", stream.GetTextFromInterval(antlr.NewInterval(start, stop)))
+		err.ByteOffset = byteOffset(stream, token.GetStart())
+		err.TokenType = token.GetTokenType()
+		if token.GetTokenType() != antlr.TokenEOF {
+			err.Token = token.GetText()
+		}
+		if parser, ok := recognizer.(antlr.Parser); ok {
+			err.Expected = expectedTokens(parser)
+		}
+	} else if lexer, ok := recognizer.(*antlr.BaseLexer); ok {
+		// The lexer reports the error without a token, the offending text starts at the token start.
+		stream = lexer.GetInputStream()
+		err.ByteOffset = byteOffset(stream, lexer.TokenStartCharIndex)
+		err.Token = stream.GetTextFromInterval(antlr.NewInterval(lexer.TokenStartCharIndex, stream.Index()))
+		err.TokenType = antlr.TokenInvalidType
+	}
+	if stream != nil {
+		err.SourceLine = sourceLine(stream, line)
+		if line == 1 {
+			err.SourceLine = l.linePrefix + err.SourceLine
+		}
+	}
+	err.ByteOffset += l.BaseOffset
+	err.Message = fmt.Sprintf("Syntax error at line %d:%d %s \n%s", err.Line, column, err.near(), errMessage)
+
 	if l.Err == nil {
-		errMessage := ""
-		if token, ok := token.(*antlr.CommonToken); ok {
-			stream := token.GetInputStream()
-			start := token.GetStart() - 40
-			if start < 0 {
-				start = 0
-			}
-			stop := token.GetStop()
-			if stop >= stream.Size() {
-				stop = stream.Size() - 1
+		l.Err = err
+	}
+	if l.CollectAll {
+		// The lexer may report an error after the parser errors of the previous tokens because of the lookahead.
+		i := sort.Search(len(l.Errs), func(i int) bool { return l.Errs[i].ByteOffset > err.ByteOffset })
+		l.Errs = append(l.Errs, nil)
+		copy(l.Errs[i+1:], l.Errs[i:])
+		l.Errs[i] = err
+	}
+}
+
+// NewSplitErrorListener returns the listener for parsing the sql split from the text,
+// so that the errors are positioned in the text rather than in the sql.
+// The index is the index of the sql among the non-empty sqls.
+func NewSplitErrorListener(text string, sql SingleSQL, index int) *ParseErrorListener {
+	lineStart := strings.LastIndexByte(text[:sql.ByteOffsetStart], '\n') + 1
+	return &ParseErrorListener{
+		BaseLine:       sql.BaseLine,
+		BaseColumn:     utf8.RuneCountInString(text[lineStart:sql.ByteOffsetStart]),
+		BaseOffset:     sql.ByteOffsetStart,
+		StatementIndex: index,
+		linePrefix:     text[lineStart:sql.ByteOffsetStart],
+	}
+}
+
+// SetStatementIndex sets the StatementIndex of the syntax errors in err by their byte offsets,
+// for the errors of parsing the whole text. The sqls are split from the same text.
+func SetStatementIndex(err error, sqls []SingleSQL) {
+	sqls = FilterEmptySQL(sqls)
+	if len(sqls) == 0 {
+		return
+	}
+	locate := func(e *SyntaxError) {
+		e.StatementIndex = sort.Search(len(sqls), func(i int) bool { return sqls[i].ByteOffsetEnd > e.ByteOffset })
+		if e.StatementIndex == len(sqls) {
+			// The error at the end of the input belongs to the last sql.
+			e.StatementIndex--
+		}
+	}
+	switch e := err.(type) {
+	case *SyntaxError:
+		locate(e)
+	case SyntaxErrors:
+		for _, syntaxErr := range e {
+			locate(syntaxErr)
+		}
+	}
+}
+
+// expectedTokens returns the display names of the tokens expected by the parser at the current state.
+func expectedTokens(parser antlr.Parser) []string {
+	literalNames, symbolicNames := parser.GetLiteralNames(), parser.GetSymbolicNames()
+	var names []string
+	for _, interval := range parser.GetExpectedTokens().GetIntervals() {
+		for t := interval.Start; t < interval.Stop; t++ {
+			switch {
+			case t == antlr.TokenEOF:
+				names = append(names, "<EOF>")
+			case t >= 0 && t < len(literalNames) && literalNames[t] != "":
+				names = append(names, literalNames[t])
+			case t >= 0 && t < len(symbolicNames) && symbolicNames[t] != "":
+				names = append(names, symbolicNames[t])
 			}
-			errMessage = fmt.Sprintf("related text: %s", stream.GetTextFromInterval(antlr.NewInterval(start, stop)))
 		}
-		l.Err = &SyntaxError{
-			Line:    line + l.BaseLine,
-			Column:  column,
-			Message: fmt.Sprintf("Syntax error at line %d:%d \n%s", line+l.BaseLine, column, errMessage),
+	}
+	return names
+}
+
+// sourceLine returns the line of the stream, line is ONE based.
+func sourceLine(stream antlr.CharStream, line int) string {
+	text := stream.GetText(0, stream.Size()-1)
+	for ; line > 1; line-- {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			return ""
 		}
+		text = text[i+1:]
+	}
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSuffix(text, "\r")
+}
+
+// byteOffset converts the rune index of the stream to the byte offset.
+func byteOffset(stream antlr.CharStream, runeIndex int) int {
+	if runeIndex <= 0 {
+		return 0
 	}
+	return len(stream.GetTextFromInterval(antlr.NewInterval(0, runeIndex-1)))
 }
 
 // ReportAmbiguity reports an ambiguity.
@@ -126,3 +371,24 @@ func GetOffsetLength(total int) int {
 		length++
 	}
 }
+
+// UTF16Offset returns the offset in UTF-16 code units of the byte offset in text,
+// the editors in the browser such as Monaco and CodeMirror use UTF-16 offsets.
+func UTF16Offset(text string, byteOffset int) int {
+	n := 0
+	for _, r := range text[:min(byteOffset, len(text))] {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// UTF16Column returns the column in UTF-16 code units of the byte offset in text, zero based.
+func UTF16Column(text string, byteOffset int) int {
+	byteOffset = min(byteOffset, len(text))
+	lineStart := strings.LastIndexByte(text[:byteOffset], '\n') + 1
+	return UTF16Offset(text[lineStart:], byteOffset-lineStart)
+}
@@ -0,0 +1,224 @@
+package base
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// Fingerprint is the normalized shape of sql, statements differing only in literals, formatting and comments
+// share the same fingerprint.
+type Fingerprint struct {
+	// Text is the normalized sql, e.g. "select * from t where id in ( ? ) and name = ?".
+	Text string
+	// Digest is the hex encoded SHA-256 of Text.
+	Digest string
+	// Hash is the first 8 bytes of the SHA-256 of Text as a big endian integer.
+	Hash uint64
+}
+
+// FingerprintTokens holds the dialect specific token types used to normalize sql.
+type FingerprintTokens struct {
+	// Numbers are the numeric literals, a sign in front of them is part of the literal.
+	Numbers []int
+	// Literals are the other literals replaced by ?, such as strings and bit or hex literals.
+	Literals []int
+	// LiteralBegin and LiteralEnd enclose a literal made of several tokens, e.g. dollar quoted strings, 0 when none.
+	LiteralBegin, LiteralEnd int
+	// IdentifierQuote quotes identifiers lexed as Literals, e.g. the backtick of mysql, empty when none.
+	IdentifierQuote string
+	// Executables are the hidden comments whose content is executed, e.g. /*!40001 SQL_NO_CACHE */ of mysql,
+	// they are kept with their whitespace collapsed.
+	Executables []int
+	// Identifiers are kept as is.
+	Identifiers []int
+	// LowerIdentifiers are case insensitive identifiers, lowered as keywords.
+	LowerIdentifiers []int
+	// Signs are the + and - operators.
+	Signs []int
+	// In is the IN keyword followed by a list.
+	In int
+	// Values are the keywords followed by rows, e.g. VALUES.
+	Values []int
+	// LeftParen, RightParen, Comma and Semicolon are the punctuations, trailing semicolons are dropped.
+	LeftParen, RightParen, Comma, Semicolon int
+}
+
+type fingerprintKind int
+
+const (
+	fingerprintOther fingerprintKind = iota
+	fingerprintLiteral
+	fingerprintIdentifier
+	fingerprintKeyword
+)
+
+type fingerprintToken struct {
+	tokenType int
+	kind      fingerprintKind
+	text      string
+}
+
+// NewFingerprint normalizes the default channel tokens of stream and digests the result.
+// Literals are replaced by ?, lists of IN and rows of VALUES made only of literals by ( ? ),
+// repeated rows are kept once, keywords are lowered, comments other than Executables are stripped
+// and tokens are separated by a single space.
+// The stream must be filled, e.g. by parsing.
+func NewFingerprint(stream antlr.TokenStream, tokens *FingerprintTokens) *Fingerprint {
+	f := &fingerprinter{types: tokens}
+	f.scan(stream)
+	text := f.render(0, len(f.tokens))
+	sum := sha256.Sum256([]byte(text))
+	return &Fingerprint{Text: text, Digest: hex.EncodeToString(sum[:]), Hash: binary.BigEndian.Uint64(sum[:8])}
+}
+
+type fingerprinter struct {
+	types  *FingerprintTokens
+	tokens []fingerprintToken
+}
+
+// scan collects the default channel tokens, merging each literal with its sign and adjacent literals into a single ?.
+func (f *fingerprinter) scan(stream antlr.TokenStream) {
+	types := f.types
+	inLiteral := false
+	for i := 0; i < stream.Size(); i++ {
+		token := stream.Get(i)
+		tokenType := token.GetTokenType()
+		if tokenType == antlr.TokenEOF {
+			break
+		}
+		if token.GetChannel() != antlr.TokenDefaultChannel {
+			if slices.Contains(types.Executables, tokenType) {
+				f.tokens = append(f.tokens, fingerprintToken{tokenType: tokenType, text: strings.Join(strings.Fields(token.GetText()), " ")})
+			}
+			continue
+		}
+		if inLiteral {
+			inLiteral = tokenType != types.LiteralEnd
+			continue
+		}
+
+		ft := fingerprintToken{tokenType: tokenType, text: token.GetText()}
+		switch {
+		case types.IdentifierQuote != "" && strings.HasPrefix(ft.text, types.IdentifierQuote):
+			ft.kind = fingerprintIdentifier
+		case slices.Contains(types.Numbers, tokenType) || slices.Contains(types.Literals, tokenType):
+			ft.kind, ft.text = fingerprintLiteral, "?"
+		case types.LiteralBegin != 0 && tokenType == types.LiteralBegin:
+			ft.kind, ft.text = fingerprintLiteral, "?"
+			inLiteral = true
+		case slices.Contains(types.Identifiers, tokenType):
+			ft.kind = fingerprintIdentifier
+		case slices.Contains(types.LowerIdentifiers, tokenType):
+			ft.kind, ft.text = fingerprintIdentifier, strings.ToLower(ft.text)
+		case isWord(ft.text):
+			ft.kind, ft.text = fingerprintKeyword, strings.ToLower(ft.text)
+		}
+
+		if ft.kind == fingerprintLiteral {
+			n := len(f.tokens)
+			// 'a' 'b' is a single string, as is _utf8mb4'a'
+			if n > 0 && f.tokens[n-1].kind == fingerprintLiteral {
+				continue
+			}
+			// unary sign, the previous token does not end an operand
+			if slices.Contains(types.Numbers, tokenType) && n > 0 && slices.Contains(types.Signs, f.tokens[n-1].tokenType) &&
+				(n == 1 || !f.endsOperand(f.tokens[n-2])) {
+				f.tokens = f.tokens[:n-1]
+			}
+		}
+		f.tokens = append(f.tokens, ft)
+	}
+
+	for n := len(f.tokens); n > 0 && f.tokens[n-1].tokenType == types.Semicolon; n-- {
+		f.tokens = f.tokens[:n-1]
+	}
+}
+
+func (f *fingerprinter) endsOperand(token fingerprintToken) bool {
+	return token.kind == fingerprintLiteral || token.kind == fingerprintIdentifier || token.tokenType == f.types.RightParen
+}
+
+// render renders the tokens [from, to).
+func (f *fingerprinter) render(from, to int) string {
+	var parts []string
+	for i := from; i < to; i++ {
+		token := f.tokens[i]
+		parts = append(parts, token.text)
+		switch {
+		case token.tokenType == f.types.In && i+1 < to && f.tokens[i+1].tokenType == f.types.LeftParen:
+			if end := f.closing(i+1, to); end > 0 && f.onlyLiterals(i+2, end) {
+				parts = append(parts, "( ? )")
+				i = end
+			}
+		case slices.Contains(f.types.Values, token.tokenType):
+			var rows []string
+			for i+1 < to && f.tokens[i+1].tokenType == f.types.LeftParen {
+				end := f.closing(i+1, to)
+				if end < 0 {
+					break
+				}
+				row := "( ? )"
+				if !f.onlyLiterals(i+2, end) {
+					row = f.render(i+1, end+1)
+				}
+				if len(rows) == 0 || rows[len(rows)-1] != row {
+					rows = append(rows, row)
+				}
+				i = end
+				if i+2 >= to || f.tokens[i+1].tokenType != f.types.Comma || f.tokens[i+2].tokenType != f.types.LeftParen {
+					break
+				}
+				i++
+			}
+			if len(rows) > 0 {
+				parts = append(parts, strings.Join(rows, " , "))
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// closing returns the index of the parenthesis closing the one at open, -1 when it is not closed before to.
+func (f *fingerprinter) closing(open, to int) int {
+	depth := 0
+	for i := open; i < to; i++ {
+		switch f.tokens[i].tokenType {
+		case f.types.LeftParen:
+			depth++
+		case f.types.RightParen:
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// onlyLiterals reports whether the tokens [from, to) are literals separated by commas.
+func (f *fingerprinter) onlyLiterals(from, to int) bool {
+	if from >= to {
+		return false
+	}
+	for i := from; i < to; i++ {
+		if (i-from)%2 == 0 && f.tokens[i].kind != fingerprintLiteral ||
+			(i-from)%2 == 1 && f.tokens[i].tokenType != f.types.Comma {
+			return false
+		}
+	}
+	return (to-from)%2 == 1
+}
+
+func isWord(text string) bool {
+	for i, r := range text {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return text != ""
+}
@@ -0,0 +1,764 @@
+package base
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// KeywordCase is the case of the keywords in formatted sql.
+type KeywordCase int
+
+const (
+	KeywordUpper KeywordCase = iota
+	KeywordLower
+	KeywordPreserve
+)
+
+// CommaPlacement is the position of the commas when a list is laid out one item per line.
+type CommaPlacement int
+
+const (
+	// CommaTrailing puts the comma at the end of the line of the previous item.
+	CommaTrailing CommaPlacement = iota
+	// CommaLeading puts the comma at the start of the line of the next item.
+	CommaLeading
+)
+
+// FormatOptions controls the layout of formatted sql.
+// The zero value formats with upper case keywords, an indent of 2 spaces, trailing commas and a line width of 80.
+type FormatOptions struct {
+	KeywordCase    KeywordCase
+	IndentWidth    int
+	CommaPlacement CommaPlacement
+	// LineWidth is the width a clause, list or subquery is kept on a single line within, it is exceeded by long expressions.
+	LineWidth int
+}
+
+// ClauseKind is the layout of the body of a clause.
+type ClauseKind int
+
+const (
+	// ClausePlain keeps the body on a line.
+	ClausePlain ClauseKind = iota
+	// ClauseList puts the items separated by commas one per line.
+	ClauseList
+	// ClauseCondition puts the conditions separated by AND and OR one per line.
+	ClauseCondition
+	// ClauseTables puts the joins one per line.
+	ClauseTables
+)
+
+// FormatClause is a clause starting on a new line.
+type FormatClause struct {
+	// Tokens are the token types of the clause keywords, e.g. GROUP BY.
+	Tokens []int
+	Kind   ClauseKind
+	// Leading clauses only start a statement, they start a clause at the beginning of a statement or a subquery,
+	// after a parenthesis or a semicolon, e.g. UPDATE but not FOR UPDATE.
+	Leading bool
+}
+
+// FormatTokens holds the dialect specific token types used to format sql.
+type FormatTokens struct {
+	// Clauses are the clauses of the statements starting with a token of Statements, longer clauses are matched first.
+	Clauses []FormatClause
+	// Statements are the first tokens of the statements laid out clause per line, e.g. SELECT, INSERT, CREATE.
+	Statements []int
+	// Joins are the join keywords, JoinModifiers are the keywords before them such as LEFT OUTER.
+	Joins, JoinModifiers []int
+	// Conditions are the logical operators separating the conditions, e.g. AND, OR; the AND of BETWEEN does not separate.
+	Conditions []int
+	Between    int
+	// Create, Alter and Table lay out the column definitions of CREATE TABLE and the specifications of ALTER one per line.
+	Create, Alter, Table int
+	// Identifiers are the token types never keywords, e.g. quoted identifiers.
+	Identifiers []int
+	// IsIdentifier reports whether the tokens of a parse tree node are identifiers, e.g. keywords used as column names.
+	IsIdentifier func(tree antlr.Tree) bool
+	// LeftParen and RightParen enclose blocks, a semicolon inside a statement, e.g. in a procedure body, ends a line.
+	LeftParen, RightParen, Comma, Semicolon int
+}
+
+// Format formats a parsed statement by its tokens, including the comments on hidden channels.
+// Only the whitespace between tokens and the case of keywords change, so the formatted sql has the same meaning;
+// tokens not separated by whitespace, such as a function name and its parenthesis, stay adjacent.
+func Format(tree antlr.ParseTree, stream antlr.TokenStream, tokens *FormatTokens, options FormatOptions) string {
+	if options.IndentWidth <= 0 {
+		options.IndentWidth = 2
+	}
+	if options.LineWidth <= 0 {
+		options.LineWidth = 80
+	}
+	f := &formatter{types: tokens, options: options}
+	identifiers := make(map[int]bool)
+	markIdentifiers(tree, tokens.IsIdentifier, false, identifiers)
+	f.scan(stream, identifiers)
+	if len(f.tokens) == 0 {
+		return strings.Join(f.comments, "\n")
+	}
+
+	var nodes []*formatNode
+	for i := 0; i < len(f.tokens); {
+		var node *formatNode
+		node, i = f.node(i)
+		nodes = append(nodes, node)
+	}
+	// comments before the first token and a line comment after the last token do not break the statement
+	first, last := f.tokens[0], f.tokens[len(f.tokens)-1]
+	leading := f.leading(first)
+	first.leading = nil
+	trailing := f.trailing(last)
+	last.trailing = nil
+	statement := docConcat{leading, f.statement(nodes, false), trailing}
+	for _, c := range f.comments {
+		statement = append(statement, docHardline, docText(c))
+	}
+	return printDoc(statement, options.LineWidth)
+}
+
+// FormatScript formats the statements of text split into sqls by format, which receives the index of the sql
+// among the non-empty sqls. Empty sqls, segments other than statements and the text between the sqls,
+// e.g. DELIMITER commands, are kept as is. The formatted sqls are separated by new lines.
+func FormatScript(text string, sqls []SingleSQL, format func(sql SingleSQL, index int) (string, error)) (string, error) {
+	var parts []string
+	end, index := 0, 0
+	for _, sql := range sqls {
+		// the rest of the line goes with the previous sql, e.g. a custom delimiter
+		for i, line := range strings.Split(text[end:sql.ByteOffsetStart], "\n") {
+			switch line = strings.TrimRight(line, " \t\r"); {
+			case i == 0 && len(parts) > 0 && end > 0 && text[end-1] != '\n':
+				parts[len(parts)-1] += line
+			case strings.TrimSpace(line) != "":
+				parts = append(parts, strings.TrimSpace(line))
+			}
+		}
+		end = sql.ByteOffsetEnd
+
+		switch {
+		case sql.Empty:
+			if trimmed := strings.TrimSpace(sql.Text); trimmed != "" {
+				parts = append(parts, trimmed)
+			}
+			continue
+		case sql.Kind != SegmentStatement:
+			parts = append(parts, strings.TrimRight(sql.Text, "\r\n"))
+		default:
+			formatted, err := format(sql, index)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, formatted)
+		}
+		index++
+	}
+	for _, line := range strings.Split(text[end:], "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+func markIdentifiers(tree antlr.Tree, isIdentifier func(antlr.Tree) bool, inside bool, identifiers map[int]bool) {
+	if terminal, ok := tree.(antlr.TerminalNode); ok {
+		if inside {
+			identifiers[terminal.GetSymbol().GetTokenIndex()] = true
+		}
+		return
+	}
+	inside = inside || isIdentifier != nil && isIdentifier(tree)
+	for _, child := range tree.GetChildren() {
+		markIdentifiers(child, isIdentifier, inside, identifiers)
+	}
+}
+
+type formatComment struct {
+	text string
+	// line is true for line comments, which must be followed by a new line
+	line bool
+	// newline and space are true when a new line or whitespace follows a leading comment
+	newline, space bool
+}
+
+type formatToken struct {
+	tokenType int
+	text      string
+	// space is true when whitespace or comments precede the token
+	space    bool
+	leading  []formatComment
+	trailing []formatComment
+}
+
+// formatNode is a token, or a parenthesized block when children is not nil.
+type formatNode struct {
+	token    *formatToken
+	children []*formatNode
+	close    *formatToken
+}
+
+type formatter struct {
+	types   *FormatTokens
+	options FormatOptions
+	tokens  []*formatToken
+	// comments on the lines after the last token
+	comments []string
+}
+
+// scan collects the default channel tokens with the comments before and after them.
+func (f *formatter) scan(stream antlr.TokenStream, identifiers map[int]bool) {
+	var pending []formatComment
+	space, newline := false, false
+	for i := 0; i < stream.Size(); i++ {
+		token := stream.Get(i)
+		if token.GetTokenType() == antlr.TokenEOF {
+			break
+		}
+		text := token.GetText()
+		if token.GetChannel() != antlr.TokenDefaultChannel {
+			space = true
+			comment := strings.TrimRight(text, " \t\r\n")
+			if comment == "" {
+				if n := len(pending); n > 0 {
+					pending[n-1].space = true
+				}
+				if strings.Contains(text, "\n") {
+					newline = true
+					if n := len(pending); n > 0 {
+						pending[n-1].newline = true
+					}
+				}
+				continue
+			}
+			c := formatComment{text: comment, line: strings.HasPrefix(comment, "--") || strings.HasPrefix(comment, "#")}
+			c.newline = c.line || len(comment) < len(text)
+			// a comment on the line of the previous token follows it
+			if n := len(f.tokens); n > 0 && !newline && len(pending) == 0 {
+				f.tokens[n-1].trailing = append(f.tokens[n-1].trailing, c)
+			} else {
+				pending = append(pending, c)
+			}
+			newline = newline || c.newline
+			continue
+		}
+
+		ft := &formatToken{tokenType: token.GetTokenType(), text: text, space: space, leading: pending}
+		// join keywords are never identifiers, though a lax grammar may parse LEFT of LEFT JOIN as an alias
+		keyword := slices.Contains(f.types.Joins, ft.tokenType) || slices.Contains(f.types.JoinModifiers, ft.tokenType)
+		if isWord(text) && !slices.Contains(f.types.Identifiers, ft.tokenType) && (keyword || !identifiers[token.GetTokenIndex()]) {
+			switch f.options.KeywordCase {
+			case KeywordUpper:
+				ft.text = strings.ToUpper(text)
+			case KeywordLower:
+				ft.text = strings.ToLower(text)
+			}
+		}
+		f.tokens = append(f.tokens, ft)
+		pending, space, newline = nil, false, false
+	}
+
+	for _, c := range pending {
+		f.comments = append(f.comments, c.text)
+	}
+}
+
+// node returns the node starting at the token i and the index of the token after it.
+func (f *formatter) node(i int) (*formatNode, int) {
+	node := &formatNode{token: f.tokens[i]}
+	if node.token.tokenType != f.types.LeftParen {
+		return node, i + 1
+	}
+	node.children = []*formatNode{}
+	for i++; i < len(f.tokens); {
+		if f.tokens[i].tokenType == f.types.RightParen {
+			node.close = f.tokens[i]
+			return node, i + 1
+		}
+		var child *formatNode
+		child, i = f.node(i)
+		node.children = append(node.children, child)
+	}
+	return node, i
+}
+
+func (n *formatNode) is(tokenType int) bool {
+	return n.children == nil && n.token.tokenType == tokenType
+}
+
+// statement lays out a statement or a subquery, clause per line for the statements starting with Statements.
+func (f *formatter) statement(nodes []*formatNode, nested bool) doc {
+	first := nodes[0]
+	switch {
+	case first.children != nil || slices.Contains(f.types.Statements, first.token.tokenType):
+		return f.clauses(nodes, nested)
+	case first.token.tokenType == f.types.Alter:
+		return f.list(nodes, docHardline, f.options.IndentWidth)
+	}
+	return f.sequence(nodes)
+}
+
+// clauses lays out the clauses of a statement, clauses of a top level statement are always on their own lines.
+func (f *formatter) clauses(nodes []*formatNode, nested bool) doc {
+	// the statements of a compound block, e.g. a procedure body, are laid out one by one
+	for i, node := range nodes[:len(nodes)-1] {
+		if node.is(f.types.Semicolon) {
+			return docConcat{f.clauses(nodes[:i+1], nested), docHardline, f.clauses(nodes[i+1:], nested)}
+		}
+	}
+	var starts []int
+	var kinds []FormatClause
+	for i := 0; i < len(nodes); i++ {
+		if clause, ok := f.clauseAt(nodes, i); ok {
+			starts = append(starts, i)
+			kinds = append(kinds, clause)
+			i += len(clause.Tokens) - 1
+		}
+	}
+
+	separator := docHardline
+	if nested {
+		separator = docLine
+	}
+	var parts docConcat
+	if len(starts) == 0 || starts[0] > 0 {
+		end := len(nodes)
+		if len(starts) > 0 {
+			end = starts[0]
+		}
+		parts = append(parts, f.prefix(nodes[:end]))
+	}
+	for i, start := range starts {
+		end := len(nodes)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		if len(parts) > 0 {
+			parts = append(parts, separator)
+		}
+		parts = append(parts, f.clause(nodes[start:end], kinds[i]))
+	}
+	if nested {
+		return docGroup{doc: parts}
+	}
+	return parts
+}
+
+// prefix lays out the nodes before the first clause, the column definitions of CREATE TABLE are one per line.
+func (f *formatter) prefix(nodes []*formatNode) doc {
+	if !nodes[0].is(f.types.Create) {
+		return f.sequence(nodes)
+	}
+	for i, node := range nodes {
+		if node.children != nil {
+			break
+		}
+		if node.is(f.types.Table) {
+			for j := i + 1; j < len(nodes); j++ {
+				if nodes[j].children != nil {
+					return f.sequenceBroken(nodes, j)
+				}
+			}
+		}
+	}
+	return f.sequence(nodes)
+}
+
+func (f *formatter) clauseAt(nodes []*formatNode, i int) (FormatClause, bool) {
+	var found FormatClause
+	for _, clause := range f.types.Clauses {
+		if len(clause.Tokens) <= len(found.Tokens) || i+len(clause.Tokens) > len(nodes) {
+			continue
+		}
+		matched := true
+		for j, tokenType := range clause.Tokens {
+			if !nodes[i+j].is(tokenType) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			found = clause
+		}
+	}
+	if len(found.Tokens) == 0 || i == 0 {
+		return found, len(found.Tokens) > 0
+	}
+	prev := nodes[i-1]
+	// the keywords in expressions, e.g. a = VALUES(b)
+	if prev.is(f.types.Comma) || prev.children == nil && (prev.token.text == "=" || prev.token.text == ":=") {
+		return found, false
+	}
+	if found.Leading {
+		return found, prev.children != nil || prev.is(f.types.Semicolon)
+	}
+	return found, true
+}
+
+// clause lays out the keywords of a clause and its body indented on the next lines when it does not fit on one line.
+func (f *formatter) clause(nodes []*formatNode, clause FormatClause) doc {
+	n := len(clause.Tokens)
+	keywords := f.sequence(nodes[:n])
+	body := nodes[n:]
+	if len(body) == 0 {
+		return keywords
+	}
+	// a comment after the clause does not break it
+	var trailing doc = docText("")
+	if last := body[len(body)-1]; last.children == nil {
+		trailing = f.trailing(last.token)
+		last.token.trailing = nil
+	}
+
+	var bodyDoc doc
+	switch clause.Kind {
+	case ClauseList:
+		bodyDoc = f.list(body, docLine, 0)
+	case ClauseCondition:
+		bodyDoc = f.conditions(body)
+	case ClauseTables:
+		bodyDoc = f.tables(body)
+	default:
+		bodyDoc = f.sequence(body)
+	}
+	return docConcat{docGroup{doc: docConcat{keywords, docNest{indent: f.options.IndentWidth, doc: docConcat{docLine, bodyDoc}}}}, trailing}
+}
+
+// split splits nodes before the nodes accepted by at, the accepted nodes start the parts.
+func split(nodes []*formatNode, at func(i int) bool) [][]*formatNode {
+	var parts [][]*formatNode
+	start := 0
+	for i := 1; i < len(nodes); i++ {
+		if at(i) {
+			parts = append(parts, nodes[start:i])
+			start = i
+		}
+	}
+	return append(parts, nodes[start:])
+}
+
+// list lays out the items separated by commas, separated by line, the items after the first are indented by indent.
+func (f *formatter) list(nodes []*formatNode, line doc, indent int) doc {
+	parts := split(nodes, func(i int) bool { return nodes[i-1].is(f.types.Comma) })
+	var items docConcat
+	for i, part := range parts {
+		if i == len(parts)-1 || !part[len(part)-1].is(f.types.Comma) {
+			items = append(items, f.sequence(part))
+			continue
+		}
+		item, comma := f.sequence(part[:len(part)-1]), f.token(part[len(part)-1].token)
+		if f.options.CommaPlacement == CommaLeading {
+			// the comma follows the item directly when the list is flat
+			lead := docSoftline
+			if hasHardline(line) {
+				lead = docHardline
+			}
+			items = append(items, item, lead, comma, docText(" "))
+		} else {
+			items = append(items, item, comma, line)
+		}
+	}
+	if indent > 0 {
+		return docConcat{items[0], docNest{indent: indent, doc: items[1:]}}
+	}
+	return items
+}
+
+// conditions lays out the conditions separated by logical operators one per line.
+func (f *formatter) conditions(nodes []*formatNode) doc {
+	between := false
+	parts := split(nodes, func(i int) bool {
+		node := nodes[i]
+		if node.children != nil {
+			return false
+		}
+		if node.token.tokenType == f.types.Between {
+			between = true
+		}
+		if !slices.Contains(f.types.Conditions, node.token.tokenType) {
+			return false
+		}
+		// AND of BETWEEN ... AND
+		if between {
+			between = false
+			return false
+		}
+		return true
+	})
+	var conditions docConcat
+	for i, part := range parts {
+		if i > 0 {
+			conditions = append(conditions, docLine)
+		}
+		conditions = append(conditions, f.sequence(part))
+	}
+	return conditions
+}
+
+// tables lays out the tables of FROM, each join starts a line.
+func (f *formatter) tables(nodes []*formatNode) doc {
+	joins := split(nodes, func(i int) bool {
+		// the first keyword before JOIN, e.g. LEFT in LEFT OUTER JOIN
+		j := i
+		for j < len(nodes) && nodes[j].children == nil && slices.Contains(f.types.JoinModifiers, nodes[j].token.tokenType) {
+			j++
+		}
+		if j >= len(nodes) || nodes[j].children != nil || !slices.Contains(f.types.Joins, nodes[j].token.tokenType) {
+			return false
+		}
+		prev := nodes[i-1]
+		return prev.children != nil || !slices.Contains(f.types.JoinModifiers, prev.token.tokenType)
+	})
+	var tables docConcat
+	for i, join := range joins {
+		if i > 0 {
+			tables = append(tables, docHardline)
+		}
+		tables = append(tables, f.list(join, docLine, 0))
+	}
+	return tables
+}
+
+// block lays out a parenthesized block, a subquery as a statement and other blocks as lists.
+func (f *formatter) block(node *formatNode, broken bool) doc {
+	open := f.token(node.token)
+	// a comment after the block does not break it
+	var close, trailing doc = docText(""), docText("")
+	if node.close != nil {
+		close, trailing = docConcat{f.leading(node.close), docText(node.close.text)}, f.trailing(node.close)
+	}
+	if len(node.children) == 0 {
+		return docConcat{open, close, trailing}
+	}
+
+	if f.short(node) && !broken {
+		return docConcat{open, f.sequence(node.children), close, trailing}
+	}
+
+	var inner doc
+	first := node.children[0]
+	if first.children != nil || slices.Contains(f.types.Statements, first.token.tokenType) {
+		inner = f.statement(node.children, true)
+	} else {
+		inner = f.list(node.children, docLine, 0)
+	}
+	return docConcat{docGroup{broken: broken, doc: docConcat{
+		open,
+		docNest{indent: f.options.IndentWidth, doc: docConcat{docSoftline, inner}},
+		docSoftline,
+		close,
+	}}, trailing}
+}
+
+// maxShortArgs is the most arguments a short argument list has.
+const maxShortArgs = 3
+
+// short reports whether a block is a short argument list kept on a line, e.g. VARCHAR(10) or ROUND(a, 2):
+// it directly follows a token and has at most maxShortArgs arguments of a single token.
+func (f *formatter) short(node *formatNode) bool {
+	if node.token.space || len(node.children) > 2*maxShortArgs-1 {
+		return false
+	}
+	for i, child := range node.children {
+		if child.children != nil || i%2 == 1 != child.is(f.types.Comma) {
+			return false
+		}
+	}
+	return true
+}
+
+// sequence lays out nodes on a line, keeping the adjacent tokens adjacent.
+func (f *formatter) sequence(nodes []*formatNode) doc {
+	return f.sequenceBroken(nodes, -1)
+}
+
+// sequenceBroken lays out nodes on a line except the block at broken, laid out one item per line.
+func (f *formatter) sequenceBroken(nodes []*formatNode, broken int) doc {
+	var parts docConcat
+	for i, node := range nodes {
+		if i > 0 {
+			parts = append(parts, f.separator(nodes[i-1], node))
+		}
+		if node.children != nil {
+			parts = append(parts, f.block(node, i == broken))
+		} else {
+			parts = append(parts, f.token(node.token))
+		}
+	}
+	return parts
+}
+
+// separator is the whitespace between two nodes, next is nil at the end of a sequence.
+func (f *formatter) separator(prev, next *formatNode) doc {
+	switch {
+	case next == nil:
+		return docText("")
+	case prev.is(f.types.Semicolon):
+		return docHardline
+	case next.is(f.types.Comma) || next.is(f.types.Semicolon):
+		return docText("")
+	case prev.is(f.types.Comma) || next.token.space:
+		return docText(" ")
+	}
+	return docText("")
+}
+
+// token lays out a token and its comments.
+func (f *formatter) token(t *formatToken) doc {
+	return docConcat{f.leading(t), docText(t.text), f.trailing(t)}
+}
+
+func (f *formatter) leading(t *formatToken) doc {
+	var parts docConcat
+	for _, c := range t.leading {
+		parts = append(parts, docText(c.text))
+		switch {
+		case c.newline:
+			parts = append(parts, docHardline)
+		case c.space:
+			parts = append(parts, docText(" "))
+		}
+	}
+	return parts
+}
+
+func (f *formatter) trailing(t *formatToken) doc {
+	var parts docConcat
+	for _, c := range t.trailing {
+		if c.line {
+			parts = append(parts, docLineSuffix(" "+c.text))
+		} else {
+			parts = append(parts, docText(" "+c.text))
+		}
+	}
+	return parts
+}
+
+// doc is a document of the Wadler style pretty printer.
+type doc any
+
+type (
+	docText string
+	// docLineSuffix is printed at the end of the line, e.g. a line comment
+	docLineSuffix string
+	docConcat     []doc
+	// docBreak is a new line when the enclosing group is broken, flat otherwise
+	docBreak struct {
+		flat string
+		hard bool
+	}
+	docNest struct {
+		indent int
+		doc    doc
+	}
+	// docGroup is printed flat when it fits in the line width and contains no hard line
+	docGroup struct {
+		doc    doc
+		broken bool
+	}
+)
+
+var (
+	docLine     = docBreak{flat: " "}
+	docSoftline = docBreak{}
+	docHardline = docBreak{hard: true}
+)
+
+// hasHardline reports whether d must break its enclosing groups.
+func hasHardline(d doc) bool {
+	switch d := d.(type) {
+	case docBreak:
+		return d.hard
+	case docLineSuffix:
+		return true
+	case docConcat:
+		return slices.ContainsFunc(d, hasHardline)
+	case docNest:
+		return hasHardline(d.doc)
+	case docGroup:
+		return d.broken || hasHardline(d.doc)
+	}
+	return false
+}
+
+// flatWidth returns the width of d printed flat, stopping once it exceeds limit.
+func flatWidth(d doc, limit int) int {
+	switch d := d.(type) {
+	case docText:
+		return utf8.RuneCountInString(string(d))
+	case docBreak:
+		return len(d.flat)
+	case docConcat:
+		width := 0
+		for _, part := range d {
+			if width += flatWidth(part, limit-width); width > limit {
+				break
+			}
+		}
+		return width
+	case docNest:
+		return flatWidth(d.doc, limit)
+	case docGroup:
+		return flatWidth(d.doc, limit)
+	}
+	return 0
+}
+
+type printCommand struct {
+	indent int
+	flat   bool
+	doc    doc
+}
+
+func printDoc(d doc, width int) string {
+	var sb strings.Builder
+	var suffixes []string
+	column := 0
+	newline := func(indent int) {
+		for _, suffix := range suffixes {
+			sb.WriteString(suffix)
+		}
+		suffixes = nil
+		// no trailing whitespace
+		text := strings.TrimRight(sb.String(), " ")
+		sb.Reset()
+		sb.WriteString(text)
+		sb.WriteString("\n")
+		sb.WriteString(strings.Repeat(" ", indent))
+		column = indent
+	}
+
+	stack := []printCommand{{doc: d}}
+	for len(stack) > 0 {
+		cmd := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		switch d := cmd.doc.(type) {
+		case docText:
+			sb.WriteString(string(d))
+			column += utf8.RuneCountInString(string(d))
+		case docLineSuffix:
+			suffixes = append(suffixes, string(d))
+		case docConcat:
+			for i := len(d) - 1; i >= 0; i-- {
+				stack = append(stack, printCommand{indent: cmd.indent, flat: cmd.flat, doc: d[i]})
+			}
+		case docNest:
+			stack = append(stack, printCommand{indent: cmd.indent + d.indent, flat: cmd.flat, doc: d.doc})
+		case docGroup:
+			flat := cmd.flat || !hasHardline(d) && column+flatWidth(d.doc, width-column) <= width
+			stack = append(stack, printCommand{indent: cmd.indent, flat: flat, doc: d.doc})
+		case docBreak:
+			if cmd.flat && !d.hard {
+				sb.WriteString(d.flat)
+				column += len(d.flat)
+			} else {
+				newline(cmd.indent)
+			}
+		}
+	}
+	for _, suffix := range suffixes {
+		sb.WriteString(suffix)
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
@@ -0,0 +1,66 @@
+package base
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// Parameters replaces literals with placeholders and collects their values in placeholder order.
+type Parameters struct {
+	rewriter    *Rewriter
+	placeholder func(n int) string
+	args        []any
+}
+
+// NewParameters returns Parameters on the token stream of parser, placeholder returns the n-th placeholder starting at 1.
+func NewParameters(parser antlr.Parser, placeholder func(n int) string) *Parameters {
+	return &Parameters{rewriter: NewRewriter(parser), placeholder: placeholder}
+}
+
+// Replace replaces the literal ctx with the next placeholder bound to value.
+// Literals must be replaced in the order they appear in the sql.
+func (p *Parameters) Replace(ctx antlr.ParserRuleContext, value any) {
+	p.args = append(p.args, value)
+	p.rewriter.Replace(ctx, p.placeholder(len(p.args)))
+}
+
+// Text returns the text of ctx with the literals replaced and the values of the placeholders.
+func (p *Parameters) Text(ctx antlr.ParserRuleContext) (string, []any) {
+	return p.rewriter.Text(ctx), p.args
+}
+
+// NumberValue converts a numeric literal with an optional sign to an int64, an uint64 for the integers beyond int64,
+// or a float64 for the literals with a fraction or an exponent. It reports false for the integers beyond uint64.
+func NumberValue(text string) (any, bool) {
+	if strings.ContainsAny(text, ".eE") {
+		value, err := strconv.ParseFloat(text, 64)
+		return value, err == nil
+	}
+	if value, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return value, true
+	}
+	if value, err := strconv.ParseUint(text, 10, 64); err == nil {
+		return value, true
+	}
+	return nil, false
+}
+
+// IsWholeChild reports whether ctx is all of a child of a parent accepted by match, e.g. an ORDER BY item made only of ctx.
+func IsWholeChild(ctx antlr.ParserRuleContext, match func(parent antlr.Tree) bool) bool {
+	text := ctx.GetText()
+	for node := ctx; ; {
+		parent, ok := node.GetParent().(antlr.ParserRuleContext)
+		if !ok {
+			return false
+		}
+		if match(parent) {
+			return true
+		}
+		if parent.GetText() != text {
+			return false
+		}
+		node = parent
+	}
+}
@@ -0,0 +1,97 @@
+package base
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// Rewriter collects edits on the tokens of a parse tree and renders the edited original text of a rule,
+// including hidden tokens such as comments.
+type Rewriter struct {
+	tokens antlr.TokenStream
+	edits  []tokenEdit
+}
+
+// tokenEdit replaces the tokens [start, stop] with text, stop is start-1 for an insertion before start.
+type tokenEdit struct {
+	start, stop int
+	text        string
+}
+
+// NewRewriter returns a Rewriter on the token stream of parser.
+func NewRewriter(parser antlr.Parser) *Rewriter {
+	return &Rewriter{tokens: parser.GetTokenStream()}
+}
+
+// Replace replaces the text of ctx with text.
+func (r *Rewriter) Replace(ctx antlr.ParserRuleContext, text string) {
+	r.edits = append(r.edits, tokenEdit{start: ctx.GetStart().GetTokenIndex(), stop: ctx.GetStop().GetTokenIndex(), text: text})
+}
+
+// InsertBefore inserts text before the first token of ctx.
+func (r *Rewriter) InsertBefore(ctx antlr.ParserRuleContext, text string) {
+	start := ctx.GetStart().GetTokenIndex()
+	r.edits = append(r.edits, tokenEdit{start: start, stop: start - 1, text: text})
+}
+
+// InsertAfter inserts text after the last token of ctx.
+func (r *Rewriter) InsertAfter(ctx antlr.ParserRuleContext, text string) {
+	start := ctx.GetStop().GetTokenIndex() + 1
+	r.edits = append(r.edits, tokenEdit{start: start, stop: start - 1, text: text})
+}
+
+// Delete deletes the text of ctx and the whitespace before it.
+func (r *Rewriter) Delete(ctx antlr.ParserRuleContext) {
+	start := ctx.GetStart().GetTokenIndex()
+	for start > 0 {
+		token := r.tokens.Get(start - 1)
+		if token.GetChannel() == antlr.TokenDefaultChannel || strings.TrimSpace(token.GetText()) != "" {
+			break
+		}
+		start--
+	}
+	r.edits = append(r.edits, tokenEdit{start: start, stop: ctx.GetStop().GetTokenIndex(), text: ""})
+}
+
+// Text returns the edited text of ctx. Insertions are rendered in the order they were made,
+// replacements must not overlap.
+func (r *Rewriter) Text(ctx antlr.ParserRuleContext) string {
+	var sb strings.Builder
+	start, stop := ctx.GetStart().GetTokenIndex(), ctx.GetStop().GetTokenIndex()
+	for i := start; i <= stop+1; {
+		next := i + 1
+		for _, edit := range r.edits {
+			if edit.start == i && edit.stop < edit.start {
+				sb.WriteString(edit.text)
+			}
+		}
+		if i > stop {
+			break
+		}
+
+		replaced := false
+		for _, edit := range r.edits {
+			if edit.start == i && edit.stop >= edit.start {
+				sb.WriteString(edit.text)
+				next, replaced = edit.stop+1, true
+				break
+			}
+		}
+		if token := r.tokens.Get(i); !replaced && token.GetTokenType() != antlr.TokenEOF {
+			sb.WriteString(token.GetText())
+		}
+		i = next
+	}
+	return sb.String()
+}
+
+// PageWindow intersects the page [offset, offset+count) with the rows kept by an existing LIMIT clause
+// and returns the offset and count that select the page from the unlimited rows.
+// limitCount < 0 means the existing clause does not bound the row count (no LIMIT, LIMIT ALL or only OFFSET).
+func PageWindow(limitOffset, limitCount, offset, count int) (int, int) {
+	if limitCount < 0 {
+		return limitOffset + offset, count
+	}
+	return limitOffset + offset, max(0, min(count, limitCount-offset))
+}
@@ -0,0 +1,196 @@
+package base
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// SplitTokens holds the dialect specific token types used to split sql by the lexer.
+type SplitTokens struct {
+	// Semicolon is the statement terminator.
+	Semicolon int
+	// Create starts the statements which may contain a compound BEGIN ... END block, e.g. CREATE PROCEDURE.
+	Create int
+	// Begin opens a compound block inside a CREATE statement.
+	Begin int
+	// End closes a compound block or a CASE expression.
+	End int
+	// Case opens a CASE expression or statement, closed by END or END CASE.
+	Case int
+	// EndSuffixes are the tokens following END which close a block that did not open one, e.g. END IF, END LOOP.
+	EndSuffixes []int
+}
+
+func (st *SplitTokens) isEndSuffix(tokenType int) bool {
+	for _, t := range st.EndSuffixes {
+		if t == tokenType {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitByLexer splits the text into single sqls by the semicolon tokens of the lexer.
+// Semicolons inside string literals, quoted identifiers and comments never split, because they are part of those tokens,
+// and neither do the semicolons inside a BEGIN ... END block of a CREATE statement.
+// The lexer must be built on the text. Hidden channel tokens are treated as whitespace or comments,
+// tokens on any other channel are part of the statement.
+func SplitByLexer(text string, lexer antlr.Lexer, tokens *SplitTokens) ([]SingleSQL, error) {
+	errorListener := &ParseErrorListener{}
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errorListener)
+
+	s := &lexerSplitter{text: text, tokens: tokens, cursor: textCursor{text: text}}
+	for {
+		token := lexer.NextToken()
+		if token.GetTokenType() == antlr.TokenEOF {
+			break
+		}
+		s.accept(token)
+	}
+	s.finish()
+
+	if errorListener.Err != nil {
+		return nil, errorListener.Err
+	}
+	return s.result, nil
+}
+
+// textCursor walks the text rune by rune and keeps the line, column and byte offset in step.
+type textCursor struct {
+	text string
+
+	runeIndex int
+	offset    int
+	// line and column are ZERO based, column counts runes.
+	line   int
+	column int
+}
+
+// advance moves the cursor to the rune index, reporting the position of the last rune passed over.
+func (c *textCursor) advance(runeIndex int) (lastLine, lastColumn int) {
+	lastLine, lastColumn = c.line, c.column-1
+	for c.runeIndex < runeIndex && c.offset < len(c.text) {
+		r, size := utf8.DecodeRuneInString(c.text[c.offset:])
+		lastLine, lastColumn = c.line, c.column
+		c.offset += size
+		c.runeIndex++
+		if r == '\n' {
+			c.line++
+			c.column = 0
+		} else {
+			c.column++
+		}
+	}
+	return lastLine, lastColumn
+}
+
+type lexerSplitter struct {
+	text   string
+	tokens *SplitTokens
+	cursor textCursor
+	result []SingleSQL
+
+	// state of the current sql
+	current    SingleSQL
+	started    bool
+	commented  bool
+	firstType  int
+	depth      int
+	pendingEnd bool
+}
+
+func (s *lexerSplitter) accept(token antlr.Token) {
+	if !s.started {
+		s.started = true
+		s.current = SingleSQL{
+			BaseLine:        s.cursor.line,
+			ByteOffsetStart: s.cursor.offset,
+		}
+	}
+
+	s.cursor.advance(token.GetStart())
+	startLine, startColumn := s.cursor.line, s.cursor.column
+	lastLine, lastColumn := s.cursor.advance(token.GetStop() + 1)
+
+	if token.GetChannel() == antlr.TokenHiddenChannel {
+		if strings.TrimSpace(token.GetText()) != "" {
+			s.commented = true
+			if s.firstType == 0 {
+				s.current.LastLine, s.current.LastColumn = lastLine, lastColumn
+			}
+		}
+		return
+	}
+
+	tokenType := token.GetTokenType()
+	if s.pendingEnd {
+		s.pendingEnd = false
+		if s.tokens.isEndSuffix(tokenType) {
+			s.current.LastLine, s.current.LastColumn = lastLine, lastColumn
+			return
+		}
+		if s.depth > 0 {
+			s.depth--
+		}
+		if tokenType == s.tokens.Case {
+			s.current.LastLine, s.current.LastColumn = lastLine, lastColumn
+			return
+		}
+	}
+
+	if tokenType == s.tokens.Semicolon && s.depth == 0 {
+		s.current.LastLine, s.current.LastColumn = lastLine, lastColumn
+		s.flush()
+		return
+	}
+
+	if s.firstType == 0 {
+		s.current.FirstStatementLine, s.current.FirstStatementColumn = startLine, startColumn
+		s.firstType = tokenType
+	}
+	s.current.LastLine, s.current.LastColumn = lastLine, lastColumn
+
+	switch tokenType {
+	case s.tokens.Case:
+		s.depth++
+	case s.tokens.Begin:
+		if s.firstType == s.tokens.Create {
+			s.depth++
+		}
+	case s.tokens.End:
+		s.pendingEnd = true
+	}
+}
+
+// flush appends the current sql to the result, the sql ends at the cursor.
+func (s *lexerSplitter) flush() {
+	if s.firstType == 0 {
+		// Empty sql, such as `;` or `/* comments */;`.
+		s.current.Empty = true
+		s.current.FirstStatementLine, s.current.FirstStatementColumn = s.current.LastLine, s.current.LastColumn
+	}
+	s.current.ByteOffsetEnd = s.cursor.offset
+	s.current.Text = s.text[s.current.ByteOffsetStart:s.current.ByteOffsetEnd]
+	s.result = append(s.result, s.current)
+
+	s.current = SingleSQL{}
+	s.started = false
+	s.commented = false
+	s.firstType = 0
+	s.depth = 0
+	s.pendingEnd = false
+}
+
+// finish flushes the trailing sql without terminator, trailing blanks are dropped.
+func (s *lexerSplitter) finish() {
+	if !s.started {
+		return
+	}
+	if s.firstType == 0 && !s.commented {
+		return
+	}
+	s.flush()
+}
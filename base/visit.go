@@ -0,0 +1,102 @@
+package base
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// ErrUnsupportedConstruct is returned instead of panicking when the parse tree has a shape the visitor does not support.
+type ErrUnsupportedConstruct struct {
+	// Rule is the grammar rule name of the unsupported construct.
+	Rule string
+	// Line is ONE based, Column is ZERO based and counts runes, they are positioned in the original SQL.
+	Line   int
+	Column int
+	// ByteOffset is the byte offset of the construct in the original SQL.
+	ByteOffset int
+	// Reason describes what the visitor did not expect.
+	Reason string
+}
+
+// Error returns the error message.
+func (e *ErrUnsupportedConstruct) Error() string {
+	return fmt.Sprintf("unsupported construct %s at line %d:%d: %s", e.Rule, e.Line, e.Column, e.Reason)
+}
+
+// Accept visits the ctx and returns the result as T, a nil ctx or a nil result returns the zero T.
+// It panics with *ErrUnsupportedConstruct if the result is not a T, and turns any other panic
+// raised while visiting the ctx into *ErrUnsupportedConstruct, VisitTree recovers it.
+func Accept[T any](visitor antlr.ParseTreeVisitor, ctx antlr.ParserRuleContext) T {
+	var zero T
+	if ctx == nil {
+		return zero
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*ErrUnsupportedConstruct); ok {
+				panic(r)
+			}
+			panic(newUnsupportedConstruct(ctx, fmt.Sprint(r)))
+		}
+	}()
+
+	result := ctx.Accept(visitor)
+	if result == nil {
+		return zero
+	}
+	t, ok := result.(T)
+	if !ok {
+		panic(newUnsupportedConstruct(ctx, fmt.Sprintf("unexpected %T, want %s", result, reflect.TypeOf((*T)(nil)).Elem())))
+	}
+	return t
+}
+
+// VisitTree visits the tree and returns the result as T, any panic raised while visiting is returned as *ErrUnsupportedConstruct.
+// The errorListener is the one used to parse the tree, its bases position the error in the original SQL.
+func VisitTree[T any](visitor antlr.ParseTreeVisitor, tree antlr.ParseTree, errorListener *ParseErrorListener) (result T, err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		unsupported, ok := r.(*ErrUnsupportedConstruct)
+		if !ok {
+			ctx, _ := tree.(antlr.ParserRuleContext)
+			unsupported = newUnsupportedConstruct(ctx, fmt.Sprint(r))
+		}
+		if unsupported.Line == 1 {
+			unsupported.Column += errorListener.BaseColumn
+		}
+		unsupported.Line += errorListener.BaseLine
+		unsupported.ByteOffset += errorListener.BaseOffset
+		err = unsupported
+	}()
+
+	if ctx, ok := tree.(antlr.ParserRuleContext); ok {
+		return Accept[T](visitor, ctx), nil
+	}
+	return tree.Accept(visitor).(T), nil
+}
+
+func newUnsupportedConstruct(ctx antlr.ParserRuleContext, reason string) *ErrUnsupportedConstruct {
+	err := &ErrUnsupportedConstruct{Reason: reason}
+	if ctx == nil {
+		return err
+	}
+	err.Rule = fmt.Sprintf("rule(%d)", ctx.GetRuleIndex())
+	if p, ok := ctx.(interface{ GetParser() antlr.Parser }); ok && p.GetParser() != nil {
+		if ruleNames := p.GetParser().GetRuleNames(); ctx.GetRuleIndex() < len(ruleNames) {
+			err.Rule = ruleNames[ctx.GetRuleIndex()]
+		}
+	}
+	if start := ctx.GetStart(); start != nil {
+		err.Line, err.Column = start.GetLine(), start.GetColumn()
+		if stream := start.GetInputStream(); stream != nil {
+			err.ByteOffset = byteOffset(stream, start.GetStart())
+		}
+	}
+	return err
+}
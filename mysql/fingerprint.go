@@ -0,0 +1,36 @@
+package mysql
+
+import (
+	"github.com/may-fly/go-sqlparser/base"
+	mysqlparser "github.com/may-fly/go-sqlparser/mysql/antlr4"
+)
+
+var mysqlFingerprintTokens = &base.FingerprintTokens{
+	Numbers: []int{mysqlparser.MySqlLexerDECIMAL_LITERAL, mysqlparser.MySqlLexerREAL_LITERAL,
+		mysqlparser.MySqlLexerZERO_DECIMAL, mysqlparser.MySqlLexerONE_DECIMAL, mysqlparser.MySqlLexerTWO_DECIMAL},
+	Literals: []int{mysqlparser.MySqlLexerSTRING_LITERAL, mysqlparser.MySqlLexerSTART_NATIONAL_STRING_LITERAL,
+		mysqlparser.MySqlLexerSTRING_CHARSET_NAME, mysqlparser.MySqlLexerHEXADECIMAL_LITERAL, mysqlparser.MySqlLexerBIT_STRING,
+		mysqlparser.MySqlLexerTRUE, mysqlparser.MySqlLexerFALSE},
+	Executables:     []int{mysqlparser.MySqlLexerSPEC_MYSQL_COMMENT},
+	IdentifierQuote: "`",
+	Identifiers: []int{mysqlparser.MySqlLexerID, mysqlparser.MySqlLexerDOT_ID, mysqlparser.MySqlLexerREVERSE_QUOTE_ID,
+		mysqlparser.MySqlLexerLOCAL_ID, mysqlparser.MySqlLexerGLOBAL_ID},
+	Signs:      []int{mysqlparser.MySqlLexerPLUS, mysqlparser.MySqlLexerMINUS},
+	In:         mysqlparser.MySqlLexerIN,
+	Values:     []int{mysqlparser.MySqlLexerVALUES, mysqlparser.MySqlLexerVALUE},
+	LeftParen:  mysqlparser.MySqlLexerLR_BRACKET,
+	RightParen: mysqlparser.MySqlLexerRR_BRACKET,
+	Comma:      mysqlparser.MySqlLexerCOMMA,
+	Semicolon:  mysqlparser.MySqlLexerSEMI,
+}
+
+// Fingerprint 计算sql的指纹，字面量(包括true、false)替换为?，仅含字面量的in列表及values行替换为( ? )，关键字小写
+// 去除注释，但/*! */中的内容会被mysql执行，保留原样(仅合并空白)
+// 标识符保持原样，sql存在语法错误时返回error
+func Fingerprint(sql string) (*base.Fingerprint, error) {
+	_, stream, err := getMysqlParserTree(&base.ParseErrorListener{}, sql)
+	if err != nil {
+		return nil, err
+	}
+	return base.NewFingerprint(stream, mysqlFingerprintTokens), nil
+}
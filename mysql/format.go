@@ -0,0 +1,75 @@
+package mysql
+
+import (
+	"github.com/may-fly/go-sqlparser/base"
+	mysqlparser "github.com/may-fly/go-sqlparser/mysql/antlr4"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+var mysqlFormatTokens = &base.FormatTokens{
+	Clauses: []base.FormatClause{
+		{Tokens: []int{mysqlparser.MySqlLexerWITH}, Kind: base.ClauseList, Leading: true},
+		{Tokens: []int{mysqlparser.MySqlLexerSELECT}, Kind: base.ClauseList},
+		{Tokens: []int{mysqlparser.MySqlLexerINSERT}, Leading: true},
+		{Tokens: []int{mysqlparser.MySqlLexerREPLACE}, Leading: true},
+		{Tokens: []int{mysqlparser.MySqlLexerUPDATE}, Kind: base.ClauseTables, Leading: true},
+		{Tokens: []int{mysqlparser.MySqlLexerDELETE}, Leading: true},
+		{Tokens: []int{mysqlparser.MySqlLexerDELETE, mysqlparser.MySqlLexerFROM}, Kind: base.ClauseTables, Leading: true},
+		{Tokens: []int{mysqlparser.MySqlLexerFROM}, Kind: base.ClauseTables},
+		{Tokens: []int{mysqlparser.MySqlLexerWHERE}, Kind: base.ClauseCondition},
+		{Tokens: []int{mysqlparser.MySqlLexerGROUP, mysqlparser.MySqlLexerBY}, Kind: base.ClauseList},
+		{Tokens: []int{mysqlparser.MySqlLexerHAVING}, Kind: base.ClauseCondition},
+		{Tokens: []int{mysqlparser.MySqlLexerWINDOW}, Kind: base.ClauseList},
+		{Tokens: []int{mysqlparser.MySqlLexerORDER, mysqlparser.MySqlLexerBY}, Kind: base.ClauseList},
+		{Tokens: []int{mysqlparser.MySqlLexerLIMIT}},
+		{Tokens: []int{mysqlparser.MySqlLexerFOR}},
+		{Tokens: []int{mysqlparser.MySqlLexerLOCK, mysqlparser.MySqlLexerIN}},
+		{Tokens: []int{mysqlparser.MySqlLexerVALUES}, Kind: base.ClauseList},
+		{Tokens: []int{mysqlparser.MySqlLexerVALUE}, Kind: base.ClauseList},
+		{Tokens: []int{mysqlparser.MySqlLexerSET}, Kind: base.ClauseList},
+		{Tokens: []int{mysqlparser.MySqlLexerON, mysqlparser.MySqlLexerDUPLICATE, mysqlparser.MySqlLexerKEY, mysqlparser.MySqlLexerUPDATE}, Kind: base.ClauseList},
+		{Tokens: []int{mysqlparser.MySqlLexerUNION}},
+		{Tokens: []int{mysqlparser.MySqlLexerEXCEPT}},
+	},
+	Statements: []int{mysqlparser.MySqlLexerSELECT, mysqlparser.MySqlLexerWITH, mysqlparser.MySqlLexerINSERT, mysqlparser.MySqlLexerREPLACE,
+		mysqlparser.MySqlLexerUPDATE, mysqlparser.MySqlLexerDELETE, mysqlparser.MySqlLexerCREATE},
+	Joins: []int{mysqlparser.MySqlLexerJOIN, mysqlparser.MySqlLexerSTRAIGHT_JOIN},
+	JoinModifiers: []int{mysqlparser.MySqlLexerLEFT, mysqlparser.MySqlLexerRIGHT, mysqlparser.MySqlLexerINNER, mysqlparser.MySqlLexerCROSS,
+		mysqlparser.MySqlLexerOUTER, mysqlparser.MySqlLexerNATURAL},
+	Conditions: []int{mysqlparser.MySqlLexerAND, mysqlparser.MySqlLexerOR, mysqlparser.MySqlLexerXOR},
+	Between:    mysqlparser.MySqlLexerBETWEEN,
+	Create:     mysqlparser.MySqlLexerCREATE,
+	Alter:      mysqlparser.MySqlLexerALTER,
+	Table:      mysqlparser.MySqlLexerTABLE,
+	Identifiers: []int{mysqlparser.MySqlLexerID, mysqlparser.MySqlLexerREVERSE_QUOTE_ID, mysqlparser.MySqlLexerSTRING_CHARSET_NAME,
+		mysqlparser.MySqlLexerLOCAL_ID, mysqlparser.MySqlLexerGLOBAL_ID, mysqlparser.MySqlLexerDOT_ID},
+	IsIdentifier: func(tree antlr.Tree) bool {
+		switch tree.(type) {
+		case *mysqlparser.UidContext, *mysqlparser.SimpleIdContext, *mysqlparser.DottedIdContext,
+			*mysqlparser.EngineNameContext, *mysqlparser.CharsetNameContext, *mysqlparser.CollationNameContext:
+			return true
+		}
+		return false
+	},
+	LeftParen:  mysqlparser.MySqlLexerLR_BRACKET,
+	RightParen: mysqlparser.MySqlLexerRR_BRACKET,
+	Comma:      mysqlparser.MySqlLexerCOMMA,
+	Semicolon:  mysqlparser.MySqlLexerSEMI,
+}
+
+// Format 格式化sql，select、insert、update、delete及create等语句每个子句独占一行，超出行宽的列表每项一行，保留注释(包括/*! */)
+// 仅改变token之间的空白及关键字的大小写，不改变语义；多条语句逐条格式化，DELIMITER命令保持原样，存在语法错误时返回error
+func Format(sql string, options base.FormatOptions) (string, error) {
+	sqls, err := SplitSQL(sql)
+	if err != nil {
+		return "", err
+	}
+	return base.FormatScript(sql, sqls, func(s base.SingleSQL, index int) (string, error) {
+		tree, stream, err := getMysqlParserTree(base.NewSplitErrorListener(sql, s, index), s.Text)
+		if err != nil {
+			return "", err
+		}
+		return base.Format(tree, stream, mysqlFormatTokens, options), nil
+	})
+}
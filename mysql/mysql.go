@@ -1,6 +1,11 @@
 package mysql
 
 import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/may-fly/go-sqlparser"
 	"github.com/may-fly/go-sqlparser/sqlstmt"
 
 	"github.com/may-fly/go-sqlparser/base"
@@ -10,21 +15,22 @@ import (
 )
 
 func GetMysqlParserTree(baseLine int, statement string) (antlr.ParseTree, *antlr.CommonTokenStream, error) {
+	return getMysqlParserTree(&base.ParseErrorListener{BaseLine: baseLine}, statement)
+}
+
+// getMysqlParserTree 语法错误的行列及偏移按errorListener中的基准计算
+func getMysqlParserTree(errorListener *base.ParseErrorListener, statement string) (antlr.ParseTree, *antlr.CommonTokenStream, error) {
 	lexer := mysqlparser.NewMySqlLexer(antlr.NewInputStream(statement))
 	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
 	parser := mysqlparser.NewMySqlParser(stream)
 
-	lexerErrorListener := &base.ParseErrorListener{
-		BaseLine: baseLine,
-	}
+	lexerErrorListener := *errorListener
 	lexer.RemoveErrorListeners()
-	lexer.AddErrorListener(lexerErrorListener)
+	lexer.AddErrorListener(&lexerErrorListener)
 
-	parserErrorListener := &base.ParseErrorListener{
-		BaseLine: baseLine,
-	}
+	parserErrorListener := *errorListener
 	parser.RemoveErrorListeners()
-	parser.AddErrorListener(parserErrorListener)
+	parser.AddErrorListener(&parserErrorListener)
 	parser.BuildParseTrees = true
 
 	tree := parser.Root()
@@ -40,14 +46,241 @@ func GetMysqlParserTree(baseLine int, statement string) (antlr.ParseTree, *antlr
 	return tree, stream, nil
 }
 
+// CheckSyntax 检查sql语法，切割后逐条检查并借助antlr的错误恢复收集全部语法错误，存在错误时返回base.SyntaxErrors
+func CheckSyntax(statement string) error {
+	sqls, err := SplitSQL(statement)
+	var errs base.SyntaxErrors
+	if err != nil {
+		var syntaxErr *base.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			return err
+		}
+		// 存在词法错误无法切割时整体检查
+		errs = checkSyntax(&base.ParseErrorListener{}, statement)
+		if len(errs) == 0 {
+			return err
+		}
+	}
+	// 逐条检查，antlr的错误恢复不一定在下一条语句处恢复，整体检查会遗漏或误报其他语句的错误
+	for i, sql := range base.FilterEmptySQL(sqls) {
+		errs = append(errs, checkSyntax(base.NewSplitErrorListener(statement, sql, i), sql.Text)...)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// visitTree 将语法树转换为语句，不支持的语法树结构返回base.ErrUnsupportedConstruct而不是panic
+func visitTree(tree antlr.ParseTree, errorListener *base.ParseErrorListener) ([]sqlstmt.Stmt, error) {
+	stmts, err := base.VisitTree[[]sqlstmt.Stmt](new(MysqlVisitor), tree, errorListener)
+	var origin sqlstmt.Origin
+	if err == nil && errorListener.BaseOffset > 0 {
+		// 切割出的语句，节点的位置为在原sql中的位置
+		origin = sqlstmt.Origin{Line: errorListener.BaseLine, Column: errorListener.BaseColumn, Offset: errorListener.BaseOffset}
+	}
+	sqlstmt.SetParsed(stmts, origin)
+	return stmts, err
+}
+
+// setStatementIndex 整体解析出错时，按切割结果设置错误所在语句的序号
+func setStatementIndex(statement string, err error) {
+	if sqls, splitErr := SplitSQL(statement); splitErr == nil {
+		base.SetStatementIndex(err, sqls)
+	}
+}
+
+func checkSyntax(errorListener *base.ParseErrorListener, statement string) base.SyntaxErrors {
+	lexer := mysqlparser.NewMySqlLexer(antlr.NewInputStream(statement))
+	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+	parser := mysqlparser.NewMySqlParser(stream)
+
+	errorListener.CollectAll = true
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errorListener)
+	parser.RemoveErrorListeners()
+	parser.AddErrorListener(errorListener)
+	parser.BuildParseTrees = false
+
+	parser.Root()
+	return errorListener.Errs
+}
+
+var mysqlSplitTokens = &base.SplitTokens{
+	Semicolon:   mysqlparser.MySqlLexerSEMI,
+	Create:      mysqlparser.MySqlLexerCREATE,
+	Begin:       mysqlparser.MySqlLexerBEGIN,
+	End:         mysqlparser.MySqlLexerEND,
+	Case:        mysqlparser.MySqlLexerCASE,
+	EndSuffixes: []int{mysqlparser.MySqlLexerIF, mysqlparser.MySqlLexerLOOP, mysqlparser.MySqlLexerWHILE, mysqlparser.MySqlLexerREPEAT},
+}
+
+// delimiterCommandRegexp 匹配行首的DELIMITER，仅用于快速排除不含DELIMITER命令的sql
+var delimiterCommandRegexp = regexp.MustCompile(`(?im)^\s*delimiter\s+\S`)
+
+// hasDelimiterCommand 按词法判断sql中是否有mysql客户端的DELIMITER命令，即位于行首、以DELIMITER开始且带参数的语句，
+// 字符串、注释中及作为列名、别名的delimiter不是命令
+func hasDelimiterCommand(statement string) bool {
+	if !delimiterCommandRegexp.MatchString(statement) {
+		return false
+	}
+	lexer := mysqlparser.NewMySqlLexer(antlr.NewInputStream(statement))
+	lexer.RemoveErrorListeners()
+
+	// lineStart 当前token之前同一行只有空白，statementStart 当前token之前同一语句没有有效token
+	lineStart, statementStart := true, true
+	for {
+		token := lexer.NextToken()
+		tokenType := token.GetTokenType()
+		if tokenType == antlr.TokenEOF {
+			return false
+		}
+		if token.GetChannel() != antlr.TokenDefaultChannel {
+			text := token.GetText()
+			if tokenType == mysqlparser.MySqlLexerSPACE {
+				lineStart = lineStart || strings.Contains(text, "\n")
+			} else {
+				lineStart = strings.HasSuffix(text, "\n")
+			}
+			continue
+		}
+		if lineStart && statementStart && strings.EqualFold(token.GetText(), "delimiter") {
+			// 命令的参数在同一行
+			next := lexer.NextToken()
+			if next.GetTokenType() == mysqlparser.MySqlLexerSPACE && !strings.Contains(next.GetText(), "\n") {
+				next = lexer.NextToken()
+				return next.GetTokenType() != antlr.TokenEOF && next.GetLine() == token.GetLine()
+			}
+		}
+		lineStart, statementStart = false, tokenType == mysqlparser.MySqlLexerSEMI
+	}
+}
+
+// SplitSQL 根据词法切割多条sql，字符串、注释及存储过程等BEGIN ... END块中的分号不会切割
+// 支持mysql客户端的DELIMITER命令，命令本身不会返回，自定义结束符不包含在语句文本中
+func SplitSQL(statement string) ([]base.SingleSQL, error) {
+	if hasDelimiterCommand(statement) {
+		// 自定义结束符可能与其他字符相连(如END$$)，需逐字节识别
+		return splitByDelimiter(statement)
+	}
+	lexer := mysqlparser.NewMySqlLexer(antlr.NewInputStream(statement))
+	return base.SplitByLexer(statement, lexer, mysqlSplitTokens)
+}
+
+func splitByDelimiter(statement string) ([]base.SingleSQL, error) {
+	reader, err := sqlparser.NewStatementReader(sqlparser.Mysql, strings.NewReader(statement))
+	if err != nil {
+		return nil, err
+	}
+	reader.SetMaxStatementSize(len(statement))
+
+	var sqls []base.SingleSQL
+	for reader.Next() {
+		sqls = append(sqls, reader.Statement())
+	}
+	if err := reader.Err(); err != nil {
+		return nil, err
+	}
+	return sqls, nil
+}
+
 type MysqlParser struct {
 }
 
+func init() {
+	sqlparser.Register(sqlparser.Mysql, new(MysqlParser))
+}
+
 func (*MysqlParser) Parse(stmt string) ([]sqlstmt.Stmt, error) {
-	tree, _, err := GetMysqlParserTree(1, stmt)
+	if hasDelimiterCommand(stmt) {
+		return parseByDelimiter(stmt)
+	}
+
+	errorListener := &base.ParseErrorListener{}
+	tree, _, err := getMysqlParserTree(errorListener, stmt)
+	if err == nil {
+		var stmts []sqlstmt.Stmt
+		if stmts, err = visitTree(tree, errorListener); err == nil {
+			return stmts, nil
+		}
+	}
+	setStatementIndex(stmt, err)
+	return nil, err
+}
+
+// parseByDelimiter 按DELIMITER命令切割后逐条解析，错误行号为原sql中的行号
+func parseByDelimiter(stmt string) ([]sqlstmt.Stmt, error) {
+	sqls, err := splitByDelimiter(stmt)
 	if err != nil {
 		return nil, err
 	}
 
-	return tree.Accept(new(MysqlVisitor)).([]sqlstmt.Stmt), nil
+	stmts := make([]sqlstmt.Stmt, 0)
+	for i, sql := range base.FilterEmptySQL(sqls) {
+		errorListener := base.NewSplitErrorListener(stmt, sql, i)
+		tree, _, err := getMysqlParserTree(errorListener, sql.Text)
+		if err != nil {
+			return nil, err
+		}
+		sqlStmts, err := visitTree(tree, errorListener)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, sqlStmts...)
+	}
+	return stmts, nil
+}
+
+// ParseEach 切割后逐条解析，单条语句的语法错误不影响其他语句
+// 错误的行列为原sql中的位置
+func ParseEach(text string) ([]sqlparser.ParseResult, error) {
+	sqls, err := SplitSQL(text)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]sqlparser.ParseResult, 0, len(sqls))
+	for i, sql := range base.FilterEmptySQL(sqls) {
+		result := sqlparser.ParseResult{SQL: sql}
+		errorListener := base.NewSplitErrorListener(text, sql, i)
+		tree, _, err := getMysqlParserTree(errorListener, sql.Text)
+		if err == nil {
+			var stmts []sqlstmt.Stmt
+			if stmts, err = visitTree(tree, errorListener); err == nil && len(stmts) > 0 {
+				result.Stmt = stmts[0]
+			}
+		}
+		result.Err = err
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (*MysqlParser) ParseEach(text string) ([]sqlparser.ParseResult, error) {
+	return ParseEach(text)
+}
+
+func (*MysqlParser) Split(text string) ([]base.SingleSQL, error) {
+	return SplitSQL(text)
+}
+
+func (*MysqlParser) CheckSyntax(text string) error {
+	return CheckSyntax(text)
+}
+
+func (*MysqlParser) Paginate(stmt sqlstmt.Stmt, offset, count int) (string, error) {
+	return Paginate(stmt, offset, count)
+}
+
+func (*MysqlParser) Fingerprint(sql string) (*base.Fingerprint, error) {
+	return Fingerprint(sql)
+}
+
+func (*MysqlParser) Parameterize(stmt sqlstmt.Stmt) (string, []any, error) {
+	return Parameterize(stmt)
+}
+
+func (*MysqlParser) Format(sql string, options base.FormatOptions) (string, error) {
+	return Format(sql, options)
 }
@@ -1,9 +1,20 @@
 package mysql
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/may-fly/go-sqlparser"
+	"github.com/may-fly/go-sqlparser/base"
+	mysqlparser "github.com/may-fly/go-sqlparser/mysql/antlr4"
 	"github.com/may-fly/go-sqlparser/sqlstmt"
+
+	"github.com/antlr4-go/antlr/v4"
 )
 
 func TestParserSimpleSelect(t *testing.T) {
@@ -107,3 +118,1558 @@ func TestParserSql(t *testing.T) {
 	}
 	t.Log(stmts)
 }
+
+func TestRegistryParse(t *testing.T) {
+	stmts, err := sqlparser.Parse(sqlparser.Mysql, "select * from t_db; insert into t_db(id) values (1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 2 {
+		t.Fatalf("stmts len = %d, want 2", len(stmts))
+	}
+
+	_, err = sqlparser.Parse("oracle", "select 1")
+	var ude *sqlparser.UnknownDialectError
+	if !errors.As(err, &ude) || ude.Dialect != "oracle" {
+		t.Fatalf("err = %v, want UnknownDialectError", err)
+	}
+}
+
+func TestSplitSQL(t *testing.T) {
+	sql := `select 'a;b' from t_db; -- trailing; comment
+/* c1; */ update t_db set name = "x;y" where id = 1;
+CREATE PROCEDURE p_test()
+BEGIN
+  DECLARE i INT DEFAULT 0;
+  IF i = 0 THEN
+    SELECT CASE WHEN i > 0 THEN 1 ELSE 0 END;
+  END IF;
+END;
+;
+select 2`
+	sqls, err := SplitSQL(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqls) != 5 {
+		t.Fatalf("sqls len = %d, want 5", len(sqls))
+	}
+
+	if sqls[0].Text != "select 'a;b' from t_db;" || sqls[0].FirstStatementLine != 0 || sqls[0].LastColumn != 22 {
+		t.Fatalf("unexpected first sql: %+v", sqls[0])
+	}
+
+	update := sqls[1]
+	if update.BaseLine != 0 || update.FirstStatementLine != 1 || update.FirstStatementColumn != 10 || update.LastLine != 1 {
+		t.Fatalf("unexpected update sql: %+v", update)
+	}
+	if sql[update.ByteOffsetStart:update.ByteOffsetEnd] != update.Text {
+		t.Fatal("byte offsets should match the text")
+	}
+
+	proc := sqls[2]
+	if proc.FirstStatementLine != 2 || proc.LastLine != 8 || proc.LastColumn != 3 {
+		t.Fatalf("unexpected procedure sql: %+v", proc)
+	}
+
+	if !sqls[3].Empty {
+		t.Fatal("sqls[3] should be empty")
+	}
+	if sqls[4].Empty || sqls[4].FirstStatementLine != 10 || sqls[4].LastColumn != 7 {
+		t.Fatalf("unexpected last sql: %+v", sqls[4])
+	}
+}
+
+func TestSplitSQLDelimiter(t *testing.T) {
+	sql := `select 1;
+DELIMITER $$
+CREATE PROCEDURE p_test()
+BEGIN
+  SELECT 'a$$b';
+  SELECT 2;
+END$$
+DELIMITER ;
+select 3;`
+	sqls, err := SplitSQL(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqls) != 3 {
+		t.Fatalf("sqls len = %d, want 3", len(sqls))
+	}
+
+	proc := sqls[1]
+	if proc.Text != "CREATE PROCEDURE p_test()\nBEGIN\n  SELECT 'a$$b';\n  SELECT 2;\nEND" {
+		t.Fatalf("unexpected procedure text: %q", proc.Text)
+	}
+	if proc.BaseLine != 2 || proc.FirstStatementLine != 2 || proc.LastLine != 6 || proc.LastColumn != 2 {
+		t.Fatalf("unexpected procedure sql: %+v", proc)
+	}
+	if sql[proc.ByteOffsetStart:proc.ByteOffsetEnd] != proc.Text {
+		t.Fatal("byte offsets should match the text")
+	}
+
+	if sqls[2].Text != "select 3;" || sqls[2].FirstStatementLine != 8 {
+		t.Fatalf("unexpected last sql: %+v", sqls[2])
+	}
+
+	// 列名、别名、字符串及注释中的delimiter不是DELIMITER命令
+	for _, sql := range []string{
+		"select id,\ndelimiter d from t;\nselect 2;",
+		"select 'a\ndelimiter $$\nb';\nselect 2;",
+		"/*\ndelimiter $$\n*/ select 1;\nselect 2;",
+		"select 1 as\n  delimiter\nfrom t;\nselect 2;",
+	} {
+		sqls, err := SplitSQL(sql)
+		if err != nil {
+			t.Fatalf("%q: %v", sql, err)
+		}
+		if len(sqls) != 2 || strings.TrimSpace(sqls[1].Text) != "select 2;" {
+			t.Errorf("%q: unexpected sqls: %+v", sql, sqls)
+		}
+		if _, err := new(MysqlParser).Parse(sql); err != nil {
+			t.Errorf("%q: %v", sql, err)
+		}
+	}
+}
+
+func TestParserDelimiter(t *testing.T) {
+	parser := new(MysqlParser)
+
+	sql := `DELIMITER ;;
+CREATE TRIGGER tr_test BEFORE INSERT ON t_db FOR EACH ROW
+BEGIN
+  SET NEW.name = 'x';
+END ;;
+DELIMITER ;
+select * from t_db;`
+	stmts, err := parser.Parse(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 2 {
+		t.Fatalf("stmts len = %d, want 2", len(stmts))
+	}
+
+	_, err = parser.Parse("DELIMITER //\nselect 1//\nselect * fro t_db//")
+	var syntaxErr *base.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 3 {
+		t.Fatalf("err = %v, want syntax error at line 3", err)
+	}
+}
+
+func TestCheckSyntax(t *testing.T) {
+	sql := "select * from t_db;\nselect * fro t_db;\nupdate t_db set;\nselect 1;"
+	err := sqlparser.CheckSyntax(sqlparser.Mysql, sql)
+	var errs base.SyntaxErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("err = %v, want base.SyntaxErrors", err)
+	}
+	if len(errs) < 2 || errs[0].Line != 2 || errs[len(errs)-1].Line != 3 {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+	if sql[errs[0].ByteOffset:errs[0].ByteOffset+len(errs[0].Token)] != errs[0].Token {
+		t.Fatalf("byte offset %d does not match token %q", errs[0].ByteOffset, errs[0].Token)
+	}
+
+	var syntaxErr *base.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr != errs[0] {
+		t.Fatal("errors.As should find the first syntax error")
+	}
+	if err := CheckSyntax("select * from t_db;"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每条语句的错误都会报告，且不误报正确的语句
+	for _, c := range []struct {
+		sql  string
+		want []string // 行:列 语句序号 token
+	}{
+		{"select 1;\nselect from;\nselect 2;\ndelete t where;\nselect 3;", []string{"2:11 1 ;", "4:9 3 where"}},
+		{"select * fro t_db;\nselect 1;\nupdate t_db set;", []string{"1:0 0 select", "3:16 2 "}},
+	} {
+		var errs base.SyntaxErrors
+		if !errors.As(CheckSyntax(c.sql), &errs) {
+			t.Fatalf("%q: want base.SyntaxErrors", c.sql)
+		}
+		var got []string
+		for _, e := range errs {
+			got = append(got, fmt.Sprintf("%d:%d %d %s", e.Line, e.Column, e.StatementIndex, e.Token))
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%q: CheckSyntax() = %q, want %q", c.sql, got, c.want)
+		}
+	}
+}
+
+func TestParseEach(t *testing.T) {
+	sql := "select * from t_db;\n\nselect * from t_db1; delete fro t_db;\nselect * from t_db2;"
+	results, err := sqlparser.ParseEach(sqlparser.Mysql, sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("results len = %d, want 4", len(results))
+	}
+	for i, result := range results {
+		if (i == 2) != (result.Err != nil) || (result.Err == nil) == (result.Stmt == nil) {
+			t.Fatalf("results[%d] = %+v", i, result)
+		}
+	}
+
+	var syntaxErr *base.SyntaxError
+	if !errors.As(results[2].Err, &syntaxErr) {
+		t.Fatalf("err = %v, want *base.SyntaxError", results[2].Err)
+	}
+	if syntaxErr.Line != 3 || syntaxErr.Column != 32 || syntaxErr.Token != "t_db" || sql[syntaxErr.ByteOffset:syntaxErr.ByteOffset+4] != "t_db" {
+		t.Fatalf("unexpected syntax error: %+v", syntaxErr)
+	}
+}
+
+func TestSyntaxErrorFormat(t *testing.T) {
+	_, err := sqlparser.Parse(sqlparser.Mysql, "select * from t_db;\n\tselect * from t_db limit;")
+	var syntaxErr *base.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("err = %v, want *base.SyntaxError", err)
+	}
+	if syntaxErr.Token != ";" || syntaxErr.TokenType != mysqlparser.MySqlLexerSEMI || syntaxErr.StatementIndex != 1 || len(syntaxErr.Expected) == 0 {
+		t.Fatalf("unexpected syntax error: %+v", syntaxErr)
+	}
+	want := "Syntax error at line 2:25 near \";\"\n\tselect * from t_db limit;\n\t                        ^\nexpecting one of: "
+	if got := syntaxErr.Format(); !strings.HasPrefix(got, want) {
+		t.Fatalf("Format() = %q, want prefix %q", got, want)
+	}
+}
+
+func TestStmtKind(t *testing.T) {
+	for _, c := range []struct {
+		sql      string
+		kind     sqlstmt.StmtKind
+		readOnly bool
+	}{
+		{"select * from t_db", sqlstmt.KindSelect, true},
+		{"insert into t_db values (1)", sqlstmt.KindInsert, false},
+		{"replace into t_db values (1)", sqlstmt.KindReplace, false},
+		{"update t_db set a = 1", sqlstmt.KindUpdate, false},
+		{"delete from t_db", sqlstmt.KindDelete, false},
+		{"create table t (id int)", sqlstmt.KindDDL, false},
+		{"grant select on t_db to u", sqlstmt.KindDCL, false},
+		{"begin", sqlstmt.KindTCL, false},
+		{"show tables", sqlstmt.KindShow, true},
+		{"explain select * from t_db", sqlstmt.KindExplain, true},
+		{"explain format=json delete from t_db", sqlstmt.KindExplain, true},
+		{"set names utf8mb4", sqlstmt.KindSet, false},
+		{"use db", sqlstmt.KindUse, true},
+		{"call p()", sqlstmt.KindCall, false},
+		{"table t_db", sqlstmt.KindSelect, true},
+		{"values (1, 2)", sqlstmt.KindSelect, true},
+		{"load data infile '/tmp/t' into table t_db", sqlstmt.KindLoad, false},
+		{"handler t_db read first", sqlstmt.KindHandler, true},
+		{"do sleep(1)", sqlstmt.KindOther, false},
+	} {
+		stmts, err := new(MysqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if kind := stmts[0].Kind(); kind != c.kind {
+			t.Errorf("%s: Kind() = %v, want %v", c.sql, kind, c.kind)
+		}
+		if readOnly := sqlstmt.IsReadOnly(stmts[0]); readOnly != c.readOnly {
+			t.Errorf("%s: IsReadOnly() = %v, want %v", c.sql, readOnly, c.readOnly)
+		}
+	}
+}
+
+func TestTypedStmt(t *testing.T) {
+	for _, c := range []struct {
+		sql   string
+		check func(sqlstmt.Stmt) bool
+	}{
+		{"table t_db order by id limit 1", func(s sqlstmt.Stmt) bool {
+			ts, ok := s.(*sqlstmt.TableStmt)
+			return ok && ts.TableName.GetText() == "t_db" && len(ts.OrderBy) == 1 && ts.Limit.RowCount == 1
+		}},
+		{"values (1, 2), (3, 4)", func(s sqlstmt.Stmt) bool {
+			vs, ok := s.(*sqlstmt.ValuesStmt)
+			return ok && len(vs.Rows) == 2 && len(vs.Rows[1].Exprs) == 2
+		}},
+		{"load data infile '/tmp/t' replace into table t_db", func(s sqlstmt.Stmt) bool {
+			ld, ok := s.(*sqlstmt.LoadDataStmt)
+			return ok && !ld.Xml && ld.Replace && ld.TableName.GetText() == "t_db"
+		}},
+		{"load xml infile '/tmp/t' into table t_db", func(s sqlstmt.Stmt) bool {
+			ld, ok := s.(*sqlstmt.LoadDataStmt)
+			return ok && ld.Xml && ld.TableName.GetText() == "t_db"
+		}},
+		{"handler t_db open as h", func(s sqlstmt.Stmt) bool {
+			hs, ok := s.(*sqlstmt.HandlerStmt)
+			return ok && hs.TableName.GetText() == "t_db" && hs.Alias == "h"
+		}},
+		{"handler h read first where id > 1", func(s sqlstmt.Stmt) bool {
+			hs, ok := s.(*sqlstmt.HandlerStmt)
+			return ok && hs.TableName.GetText() == "h" && hs.Where != nil
+		}},
+	} {
+		stmts, err := new(MysqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if !c.check(stmts[0]) {
+			t.Errorf("%s: unexpected %T", c.sql, stmts[0])
+		}
+	}
+}
+
+func TestSelectSideEffects(t *testing.T) {
+	for _, c := range []struct {
+		sql     string
+		effects sqlstmt.SelectSideEffects
+	}{
+		{"select * from t_db", sqlstmt.SelectSideEffects{}},
+		{"select * from t_db into outfile '/tmp/t'", sqlstmt.SelectSideEffects{IntoFile: true}},
+		{"select * from t_db into dumpfile '/tmp/t'", sqlstmt.SelectSideEffects{IntoFile: true}},
+		{"select id into @id from t_db", sqlstmt.SelectSideEffects{IntoVariables: true}},
+		{"select * from t_db for update", sqlstmt.SelectSideEffects{Locking: true}},
+		{"select * from t_db lock in share mode", sqlstmt.SelectSideEffects{Locking: true}},
+		{"select * from t1 union select * from t2 for update", sqlstmt.SelectSideEffects{Locking: true}},
+		{"select * from t1 where id in (select id from t2 for update)", sqlstmt.SelectSideEffects{Locking: true}},
+	} {
+		stmts, err := new(MysqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		selectStmt, ok := stmts[0].(sqlstmt.ISelectStmt)
+		if !ok {
+			t.Fatalf("%s: %T is not a select", c.sql, stmts[0])
+		}
+		if effects := selectStmt.GetSideEffects(); effects != c.effects {
+			t.Errorf("%s: GetSideEffects() = %+v, want %+v", c.sql, effects, c.effects)
+		}
+		if readOnly := sqlstmt.IsReadOnly(stmts[0]); readOnly != c.effects.IsReadOnly() {
+			t.Errorf("%s: IsReadOnly() = %v", c.sql, readOnly)
+		}
+	}
+
+	// 子查询的副作用仅包含其自身及其中的子查询
+	stmts, err := new(MysqlParser).Parse("select * from t1 where a in (select a from t2 where b in (select b from t3 for update)) and c in (select c from t4)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []bool
+	sqlstmt.Inspect(stmts[0], func(node sqlstmt.INode) bool {
+		if s, ok := node.(sqlstmt.ISelectStmt); ok {
+			got = append(got, s.GetSideEffects().Locking)
+		}
+		return true
+	})
+	if want := []bool{true, true, true, false}; !slices.Equal(got, want) {
+		t.Errorf("Locking of the nested selects = %v, want %v", got, want)
+	}
+}
+
+func TestReferencedTables(t *testing.T) {
+	for _, c := range []struct {
+		sql  string
+		want string
+	}{
+		{"select * from t1 a join db.t2 b on a.id = b.id left join t3 on t3.id = (select max(id) from t4)", "t1 a Read,db.t2 b Read,t3 Read,t4 Read"},
+		{"select * from t1 where id in (select id from t2) and exists (select 1 from t3) or name like (select n from t4)", "t1 Read,t2 Read,t3 Read,t4 Read"},
+		{"select (select 1 from t2), count((select 1 from t3)) from (select * from t1) d", "t2 Read,t3 Read,t1 Read"},
+		{"select * from t1 union select * from t2 union all (select * from t3)", "t1 Read,t2 Read,t3 Read"},
+		{"(select * from t1) union (select * from t2) union (select * from t3)", "t1 Read,t2 Read,t3 Read"},
+		{"with c as (select * from t1), d as (select * from c) select * from d join t2", "t1 Read,t2 Read"},
+		{"with recursive c as (select 1 union all select n + 1 from c) select * from c", ""},
+		{"insert into t1 select * from t2", "t1 Write,t2 Read"},
+		{"replace into t1 values (1)", "t1 Write"},
+		{"insert into t1 values ((select max(id) from t2)), (default)", "t1 Write,t2 Read"},
+		{"insert into t1 set a = (select 1 from t2)", "t1 Write,t2 Read"},
+		{"insert into t1 (a) values (1) on duplicate key update a = (select 1 from t2)", "t1 Write,t2 Read"},
+		{"replace into t1 set a = (select 1 from t2)", "t1 Write,t2 Read"},
+		{"call p((select 1 from t1), 2)", "t1 Read"},
+		{"set @a = (select max(id) from t1), @b = 1", "t1 Read"},
+		{"do (select sleep(1) from t1)", "t1 Read"},
+		{"update t1 a join t2 b on a.id = b.id set a.name = b.name where b.id in (select id from t3)", "t1 a Write,t2 b Read,t3 Read"},
+		{"with c as (select id from t2) update t1 set name = 'x' where id in (select id from c)", "t2 Read,t1 Write"},
+		{"delete a from t1 a join t2 b on a.id = b.id", "t1 a Write,t2 b Read"},
+		{"delete from t1 where id = 1", "t1 Write"},
+		{"create table t1 as select * from t2", "t1 Create,t2 Read"},
+		{"create table t1 like t2", "t1 Create,t2 Read"},
+		{"create view v1 as select * from t1", "v1 Create,t1 Read"},
+		{"alter table t1 add column c int", "t1 Alter"},
+		{"create index idx on t1 (c)", "t1 Alter"},
+		{"drop table t1, t2", "t1 Drop,t2 Drop"},
+		{"truncate table t1", "t1 Drop"},
+		{"rename table t1 to t2", "t1 Alter,t2 Create"},
+		{"table t1", "t1 Read"},
+		{"values (1, (select max(id) from t1))", "t1 Read"},
+		{"select * from t1, lateral (select * from t2 where t2.id = t1.id) as d", "t1 Read,t2 Read"},
+		{"load data infile '/tmp/t' into table t1", "t1 Write"},
+		{"load xml infile '/tmp/t' into table t1", "t1 Write"},
+		{"lock tables t1 read, t2 as b write, t3 low_priority write", "t1 Read,t2 b Write,t3 Write"},
+		{"handler t1 open as h", "t1 h Read"},
+	} {
+		stmts, err := new(MysqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if len(stmts) != 1 {
+			t.Fatalf("%s: got %d statements", c.sql, len(stmts))
+		}
+		refs := make([]string, 0)
+		for _, ref := range sqlstmt.ReferencedTables(stmts[0]) {
+			name := ref.TableName.Identifier.Value
+			if ref.TableName.Owner != "" {
+				name = ref.TableName.Owner + "." + name
+			}
+			if ref.Alias != "" {
+				name += " " + ref.Alias
+			}
+			refs = append(refs, name+" "+ref.Access.String())
+		}
+		if got := strings.Join(refs, ","); got != c.want {
+			t.Errorf("%s: ReferencedTables() = %s, want %s", c.sql, got, c.want)
+		}
+	}
+}
+
+func TestReferencedColumns(t *testing.T) {
+	for _, c := range []struct {
+		sql  string
+		want string
+	}{
+		{"select a.id, b.name n, count(c.x) from t1 a join t2 b on a.id = b.aid where b.status = 1 group by a.id having count(*) > 1 order by n",
+			"a.id Select Resolved t1,b.name Select Resolved t2,c.x Select Unresolved,a.id Join Resolved t1,b.aid Join Resolved t2,b.status Where Resolved t2,a.id GroupBy Resolved t1,n OrderBy SelectAlias"},
+		{"select id from t1 where name = 'x' order by id", "id Select Resolved t1,name Where Resolved t1,id OrderBy Resolved t1"},
+		{"select id from t1, t2", "id Select Ambiguous"},
+		{"select * from t1 a where exists (select 1 from t2 b where b.aid = a.id) and a.x in (select y from t3)",
+			"b.aid Where Resolved t2,a.id Where Resolved t1,a.x Where Resolved t1,y Select Resolved t3"},
+		{"select d.id from (select id from t1) d", "d.id Select Derived,id Select Resolved t1"},
+		{"with c as (select id from t1) select c.id from c", "id Select Resolved t1,c.id Select Derived"},
+		{"select db.t1.id, t1.name from db.t1", "db.t1.id Select Resolved t1,t1.name Select Resolved t1"},
+		{"select t1.id from t1 a", "t1.id Select Unresolved"},
+		{"update t1 a join t2 b on a.id = b.id set a.name = b.name where b.x = 1",
+			"a.name Set Resolved t1,b.name Set Resolved t2,a.id Join Resolved t1,b.id Join Resolved t2,b.x Where Resolved t2"},
+		{"delete from t1 where id in (select aid from t2)", "id Where Resolved t1,aid Select Resolved t2"},
+		{"insert into t1 (a, b) values (1, (select x from t2)) on duplicate key update b = b + 1",
+			"a Insert Resolved t1,b Insert Resolved t1,x Select Resolved t2,b Set Resolved t1,b Set Resolved t1"},
+		{"insert into t1 set a = (select max(x) from t2)", "a Set Resolved t1,x Select Resolved t2"},
+		{"insert into t1 (a) select x from t2 where t2.y = 1", "a Insert Resolved t1,x Select Resolved t2,t2.y Where Resolved t2"},
+		{"insert into t1 (a, b) values (default, c) on duplicate key update b = default", "a Insert Resolved t1,b Insert Resolved t1,c Insert Resolved t1,b Set Resolved t1"},
+		{"set @a = (select x from t1)", "x Select Resolved t1"},
+		{"select id, t2.id, name from t1 join t2 using (id) where id > 1", "id Select Joined,t2.id Select Resolved t2,name Select Ambiguous,id Where Joined"},
+		{"select id from t1 left join t2 using (id) join t3 using (id)", "id Select Joined"},
+		{"select id from (t1 join t2 using (id)) join t3 on t3.x = 1", "id Select Ambiguous,t3.x Join Resolved t3"},
+		{"select id from t1 natural join t2", "id Select Ambiguous"},
+		{"select d.n from t1, lateral (select name n from t2 where t2.id = t1.id) as d",
+			"d.n Select Derived,name Select Resolved t2,t2.id Where Resolved t2,t1.id Where Resolved t1"},
+		{"table t1 order by id", "id OrderBy Resolved t1"},
+		{"handler t1 read first where id > 1", "id Where Resolved t1"},
+	} {
+		stmts, err := new(MysqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		refs := make([]string, 0)
+		for _, ref := range sqlstmt.ReferencedColumns(stmts[0]) {
+			name := ref.ColumnName.Identifier.Value
+			if ref.ColumnName.Owner != "" {
+				name = ref.ColumnName.Owner + "." + name
+			}
+			name += " " + ref.Clause.String() + " " + ref.Resolution.String()
+			if ref.Table != nil {
+				name += " " + ref.Table.TableName.Identifier.Value
+			}
+			refs = append(refs, name)
+		}
+		if got := strings.Join(refs, ","); got != c.want {
+			t.Errorf("%s: ReferencedColumns() = %s, want %s", c.sql, got, c.want)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	for _, c := range []struct {
+		sql           string
+		offset, count int
+		want          string
+	}{
+		{"select * from t1 where a = 1 order by id", 20, 10, "select * from t1 where a = 1 order by id LIMIT 20, 10"},
+		{"select * from t1 limit 100", 20, 10, "select * from t1 LIMIT 20, 10"},
+		{"select * from t1 limit 5, 25", 20, 10, "select * from t1 LIMIT 25, 5"},
+		{"select * from t1 limit 10 offset 5", 20, 10, "select * from t1 LIMIT 25, 0"},
+		{"select * from t1 where id = 1 for update", 0, 10, "select * from t1 where id = 1 LIMIT 0, 10 for update"},
+		{"select id into @x from t1", 0, 1, "select id into @x from t1 LIMIT 0, 1"},
+		{"select id from t1 into @x", 0, 1, "select id from t1 LIMIT 0, 1 into @x"},
+		{"(select * from t1 limit 30) lock in share mode", 20, 20, "(select * from t1 LIMIT 20, 10) lock in share mode"},
+		{"select a from t1 union select a from t2 order by a", 0, 10, "select a from t1 union select a from t2 order by a LIMIT 0, 10"},
+		{"select a from t1 union all select a from t2 limit 50", 40, 20, "select a from t1 union all select a from t2 LIMIT 40, 10"},
+		{"select a from t1 union (select a from t2 limit 3) order by a for update", 0, 10, "select a from t1 union (select a from t2 limit 3) order by a LIMIT 0, 10 for update"},
+		{"(select a from t1) union (select a from t2) limit 2, 8", 0, 10, "(select a from t1) union (select a from t2) LIMIT 2, 8"},
+		{"with c as (select id from t1) select * from c", 10, 10, "with c as (select id from t1) select * from c LIMIT 10, 10"},
+	} {
+		stmts, err := new(MysqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		got, err := sqlparser.Paginate(sqlparser.Mysql, stmts[0], c.offset, c.count)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: Paginate() = %s, want %s", c.sql, got, c.want)
+		}
+		if _, err := new(MysqlParser).Parse(got); err != nil {
+			t.Errorf("%s: Paginate() = %s: %v", c.sql, got, err)
+		}
+	}
+
+	for _, sql := range []string{"select * from t1 limit @n", "update t1 set a = 1"} {
+		stmts, err := new(MysqlParser).Parse(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		if _, err := Paginate(stmts[0], 0, 10); !errors.Is(err, sqlparser.ErrNotPaginable) {
+			t.Errorf("%s: err = %v, want ErrNotPaginable", sql, err)
+		}
+	}
+
+	// limit对应的语法树节点不是limit子句时不能改写
+	stmts, err := new(MysqlParser).Parse("select * from t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs := stmts[0].(*sqlstmt.SimpleSelectStmt).QuerySpecification
+	qs.Limit = &sqlstmt.Limit{Node: qs.Node, RowCount: 5}
+	if _, err := Paginate(stmts[0], 0, 10); !errors.Is(err, sqlparser.ErrNotPaginable) {
+		t.Errorf("foreign limit: err = %v, want ErrNotPaginable", err)
+	}
+}
+
+func TestCountQuery(t *testing.T) {
+	for _, c := range []struct {
+		sql  string
+		want string
+	}{
+		{"select * from t1 where a = 1 order by id desc", "select COUNT(*) from t1 where a = 1"},
+		{"select a.id, b.name from t1 a join t2 b on a.id = b.aid order by a.id", "select COUNT(*) from t1 a join t2 b on a.id = b.aid"},
+		{"select distinct a from t1", "SELECT COUNT(*) FROM (select distinct a from t1) AS t"},
+		{"select a, count(*) from t1 group by a having count(*) > 1 order by a", "SELECT COUNT(*) FROM (select a, count(*) from t1 group by a having count(*) > 1) AS t"},
+		{"select count(*) from t1", "SELECT COUNT(*) FROM (select count(*) from t1) AS t"},
+		{"select * from t1 order by id limit 10, 20", "SELECT COUNT(*) FROM (select * from t1 limit 10, 20) AS t"},
+		{"select a from t1 union select a from t2 order by a", "SELECT COUNT(*) FROM (select a from t1 union select a from t2) AS t"},
+		{"(select a from t1) union all (select a from t2) order by a limit 5", "SELECT COUNT(*) FROM ((select a from t1) union all (select a from t2) limit 5) AS t"},
+		{"with c as (select id from t1) select id from c order by id", "with c as (select id from t1) select COUNT(*) from c"},
+	} {
+		stmts, err := new(MysqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		got, err := sqlstmt.CountQuery(stmts[0])
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: CountQuery() = %s, want %s", c.sql, got, c.want)
+		}
+		if _, err := new(MysqlParser).Parse(got); err != nil {
+			t.Errorf("%s: CountQuery() = %s: %v", c.sql, got, err)
+		}
+	}
+
+	for _, sql := range []string{"select id into @x from t1", "update t1 set a = 1", "select * from t1 for update", "select * from t1 where id in (select id from t2 lock in share mode)"} {
+		stmts, err := new(MysqlParser).Parse(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		if _, err := sqlstmt.CountQuery(stmts[0]); !errors.Is(err, sqlstmt.ErrNotCountable) {
+			t.Errorf("%s: err = %v, want ErrNotCountable", sql, err)
+		}
+	}
+}
+
+func TestInjectPredicate(t *testing.T) {
+	// 仅为t1、t2注入租户条件
+	tenant := func(table *sqlstmt.TableName, alias string) string {
+		if name := table.Identifier.Value; name == "t1" || name == "t2" {
+			if alias == "" {
+				alias = name
+			}
+			return alias + ".tenant_id = 42"
+		}
+		return ""
+	}
+	for _, c := range []struct {
+		sql  string
+		want string
+	}{
+		{"select * from t1 where a = 1 or b = 2 order by id",
+			"select * from t1 where (a = 1 or b = 2) AND t1.tenant_id = 42 order by id"},
+		{"select * from t1 a join t2 b on a.id = b.aid join t3 on t3.id = a.id group by a.id",
+			"select * from t1 a join t2 b on a.id = b.aid join t3 on t3.id = a.id WHERE a.tenant_id = 42 AND b.tenant_id = 42 group by a.id"},
+		{"select * from t1 a left join t2 b on a.id = b.aid",
+			"select * from t1 a left join t2 b on (a.id = b.aid) AND b.tenant_id = 42 WHERE a.tenant_id = 42"},
+		{"select * from t1 a right join t3 on a.id = t3.aid",
+			"select * from t1 a right join t3 on (a.id = t3.aid) AND a.tenant_id = 42"},
+		{"select * from t3 where id in (select aid from t2 where x = 1) and exists (select 1 from t1)",
+			"select * from t3 where id in (select aid from t2 where (x = 1) AND t2.tenant_id = 42) and exists (select 1 from t1 WHERE t1.tenant_id = 42)"},
+		{"select * from (select * from t1) d, t2",
+			"select * from (select * from t1 WHERE t1.tenant_id = 42) d, t2 WHERE t2.tenant_id = 42"},
+		{"with c as (select * from t1) select * from c join t2 on c.id = t2.id",
+			"with c as (select * from t1 WHERE t1.tenant_id = 42) select * from c join t2 on c.id = t2.id WHERE t2.tenant_id = 42"},
+		{"select a from t1 union select a from t2 order by a limit 10",
+			"select a from t1 WHERE t1.tenant_id = 42 union select a from t2 WHERE t2.tenant_id = 42 order by a limit 10"},
+		{"update t1 a join t2 b on a.id = b.aid set a.name = b.name",
+			"update t1 a join t2 b on a.id = b.aid set a.name = b.name WHERE a.tenant_id = 42 AND b.tenant_id = 42"},
+		{"update t1 set name = 'x' where id = 1 limit 1", "update t1 set name = 'x' where (id = 1) AND t1.tenant_id = 42 limit 1"},
+		{"delete from t1 a order by id limit 1", "delete from t1 a WHERE a.tenant_id = 42 order by id limit 1"},
+		{"delete t1 from t1 join t3 on t1.id = t3.id", "delete t1 from t1 join t3 on t1.id = t3.id WHERE t1.tenant_id = 42"},
+		{"insert into t3 select * from t1", "insert into t3 select * from t1 WHERE t1.tenant_id = 42"},
+		{"insert into t3 values ((select max(id) from t1))", "insert into t3 values ((select max(id) from t1 WHERE t1.tenant_id = 42))"},
+		{"insert into t3 set a = (select 1 from t1) on duplicate key update a = (select 2 from t2)",
+			"insert into t3 set a = (select 1 from t1 WHERE t1.tenant_id = 42) on duplicate key update a = (select 2 from t2 WHERE t2.tenant_id = 42)"},
+		{"call p((select id from t1), 1)", "call p((select id from t1 WHERE t1.tenant_id = 42), 1)"},
+		{"select * from t3", "select * from t3"},
+		{"select * from t1 natural join t3", "select * from t1 natural join t3 WHERE t1.tenant_id = 42"},
+		{"values (1, (select max(id) from t1))", "values (1, (select max(id) from t1 WHERE t1.tenant_id = 42))"},
+	} {
+		stmts, err := new(MysqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		got, err := sqlstmt.InjectPredicate(stmts[0], tenant)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: InjectPredicate() = %s, want %s", c.sql, got, c.want)
+		}
+		if _, err := new(MysqlParser).Parse(got); err != nil {
+			t.Errorf("%s: InjectPredicate() = %s: %v", c.sql, got, err)
+		}
+	}
+
+	for _, sql := range []string{
+		"select * from t3 x left join t1 using (id)",
+		"select * from t3 natural left join t1",
+		"select * from t1 natural right join t3",
+		"table t1",
+		"select * from t3, lateral (select * from t1) as d",
+		"handler t1 read first",
+	} {
+		stmts, err := new(MysqlParser).Parse(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		if _, err := sqlstmt.InjectPredicate(stmts[0], tenant); !errors.Is(err, sqlstmt.ErrNotInjectable) {
+			t.Errorf("%s: err = %v, want ErrNotInjectable", sql, err)
+		}
+	}
+}
+
+func TestParseUnsupportedConstruct(t *testing.T) {
+	// 语法树结构不符合预期时返回错误而不是panic
+	_, err := base.VisitTree[*sqlstmt.Limit](new(MysqlVisitor), mustParseTree(t, "select * from t_db"), &base.ParseErrorListener{BaseLine: 2})
+	var unsupported *base.ErrUnsupportedConstruct
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("err = %v, want *base.ErrUnsupportedConstruct", err)
+	}
+	if unsupported.Rule != "root" || unsupported.Line != 3 {
+		t.Fatalf("unexpected error: %+v", unsupported)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	for _, c := range []struct {
+		sqls []string
+		want string
+	}{
+		{[]string{
+			"select * from t1 where id = 1 and name = 'a'",
+			"SELECT *\n  FROM t1 /* c */ WHERE id=2 AND name = \"b\"; -- end",
+			"select * from t1 where id = -3 and name = _utf8mb4'c' 'd'",
+		}, "select * from t1 where id = ? and name = ?"},
+		{[]string{
+			"select a from t1 where id in (1, 2, 3) and b in ('x')",
+			"select a from t1 where id IN (4) and b in ('y', -1)",
+		}, "select a from t1 where id in ( ? ) and b in ( ? )"},
+		{[]string{
+			"insert into t1 (a, b) values (1, 'a'), (2, 'b')",
+			"INSERT INTO t1 (a, b) VALUES (3, x'ff')",
+		}, "insert into t1 ( a , b ) values ( ? )"},
+		{[]string{
+			"insert into t1 values (1, now()), (2, now())",
+			"insert into t1 values (3, now()), (4, now()), (5, now())",
+		}, "insert into t1 values ( ? , now ( ) )"},
+		{[]string{
+			"select a - 1, b from t1 where c in (select c from t2) limit 10",
+			"select a-2, b from t1 where c in (select c from t2) LIMIT 5",
+		}, "select a - ? , b from t1 where c in ( select c from t2 ) limit ?"},
+		{[]string{
+			"/*!40101 SET NAMES utf8 */ update `T1` set a = a + 1 where id = 1",
+			"/*!40101  SET NAMES utf8\n*/ update `T1` set a = a + 2 /* c */ where id = 3",
+		}, "/*!40101 SET NAMES utf8 */ update `T1` set a = a + ? where id = ?"},
+		{[]string{
+			"select * from t1 where a = true and b = 1",
+			"select * from t1 where a = FALSE and b = false",
+		}, "select * from t1 where a = ? and b = ?"},
+	} {
+		var digest string
+		for _, sql := range c.sqls {
+			got, err := sqlparser.Fingerprint(sqlparser.Mysql, sql)
+			if err != nil {
+				t.Fatalf("%s: %v", sql, err)
+			}
+			if got.Text != c.want {
+				t.Errorf("%s: Fingerprint() = %s, want %s", sql, got.Text, c.want)
+			}
+			if digest != "" && got.Digest != digest {
+				t.Errorf("%s: digest %s differs from %s", sql, got.Digest, digest)
+			}
+			digest = got.Digest
+		}
+	}
+
+	a, _ := Fingerprint("select * from t1 where id = 1")
+	b, _ := Fingerprint("select * from t2 where id = 1")
+	if len(a.Digest) != 64 || a.Digest == b.Digest || a.Hash == b.Hash {
+		t.Errorf("digests of different statements: %s %d, %s %d", a.Digest, a.Hash, b.Digest, b.Hash)
+	}
+	// /*! */中的内容会被执行，不能与无注释的语句视为相同
+	a, _ = Fingerprint("select /*!40001 SQL_NO_CACHE */ * from t1")
+	b, _ = Fingerprint("select * from t1")
+	if a.Digest == b.Digest {
+		t.Errorf("executable comment dropped: %s", a.Text)
+	}
+	if _, err := Fingerprint("select * frm t1"); err == nil {
+		t.Error("Fingerprint() of invalid sql should fail")
+	}
+}
+
+func TestParameterize(t *testing.T) {
+	for _, c := range []struct {
+		sql  string
+		want string
+		args []any
+	}{
+		{"select * from t1 where id = 1 and name = 'a''b\\n' limit 10",
+			"select * from t1 where id = ? and name = ? limit ?", []any{int64(1), "a'b\n", int64(10)}},
+		{"select a, 'x' + 1 from t1 where e = 'x' \"y\" and b > -2 and c < - 1.5 and d in (18446744073709551615, 1e3) order by 1, a desc limit 5, 10",
+			"select a, ? + ? from t1 where e = ? and b > ? and c < ? and d in (?, ?) order by 1, a desc limit ?, ?",
+			[]any{"x", int64(1), "xy", int64(-2), float64(-1.5), uint64(18446744073709551615), float64(1000), int64(5), int64(10)}},
+		{"insert into t1 (a, b, c) values (1, null, true), (N'\\%', _utf8mb4'x', 0xff) on duplicate key update a = a + 1",
+			"insert into t1 (a, b, c) values (?, null, true), (?, _utf8mb4'x', 0xff) on duplicate key update a = a + ?",
+			[]any{int64(1), `\%`, int64(1)}},
+		{"update t1 set a = concat(a, '-') where id in (select b from t2 where c = 'z' group by 1)",
+			"update t1 set a = concat(a, ?) where id in (select b from t2 where c = ? group by 1)", []any{"-", "z"}},
+		{"delete from t1 where created_at < now() - interval 7 day limit 100",
+			"delete from t1 where created_at < now() - interval ? day limit ?", []any{int64(7), int64(100)}},
+		{"select doc->'$.name', doc->>'$.tags[0]' from t1 where doc->>'$.id' = 'x'",
+			"select doc->'$.name', doc->>'$.tags[0]' from t1 where doc->>'$.id' = ?", []any{"x"}},
+	} {
+		stmts, err := new(MysqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		got, args, err := sqlparser.Parameterize(sqlparser.Mysql, stmts[0])
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if got != c.want || fmt.Sprintf("%#v", args) != fmt.Sprintf("%#v", c.args) {
+			t.Errorf("%s: Parameterize() = %s %#v, want %s %#v", c.sql, got, args, c.want, c.args)
+		}
+	}
+
+	stmts, _ := new(MysqlParser).Parse("create table t (a int default 1)")
+	if _, _, err := Parameterize(stmts[0]); !errors.Is(err, sqlparser.ErrNotParameterizable) {
+		t.Errorf("Parameterize() of ddl = %v, want ErrNotParameterizable", err)
+	}
+	if _, _, err := Parameterize(new(sqlstmt.SimpleSelectStmt)); !errors.Is(err, sqlparser.ErrNotParameterizable) {
+		t.Errorf("Parameterize() of unparsed select = %v, want ErrNotParameterizable", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, c := range []struct {
+		sql     string
+		options base.FormatOptions
+		want    string
+	}{
+		{"select a, count(*) c from t1 left join t2 on t1.id = t2.id where a = 1 and b between 1 and 2 group by a order by c desc limit 10;",
+			base.FormatOptions{},
+			"SELECT a, COUNT(*) c\nFROM\n  t1\n  LEFT JOIN t2 ON t1.id = t2.id\nWHERE a = 1 AND b BETWEEN 1 AND 2\nGROUP BY a\nORDER BY c DESC\nLIMIT 10;"},
+		{"SELECT `Name`, Status -- columns\nFROM users /* c */ where id in (select uid from orders where amount > 100)",
+			base.FormatOptions{KeywordCase: base.KeywordLower},
+			"select `Name`, Status -- columns\nfrom users /* c */\nwhere id in (select uid from orders where amount > 100)"},
+		{"select column_one, column_two, column_three from t1 where column_one = 'a' or column_two = 'b'",
+			base.FormatOptions{CommaPlacement: base.CommaLeading, IndentWidth: 4, LineWidth: 30},
+			"SELECT\n    column_one\n    , column_two\n    , column_three\nFROM t1\nWHERE\n    column_one = 'a'\n    OR column_two = 'b'"},
+		{"-- c\nselect 3, 4 from t",
+			base.FormatOptions{},
+			"-- c\nSELECT 3, 4\nFROM t"},
+		{"/* a long leading comment that makes the line wide */ select column_one, column_two from t",
+			base.FormatOptions{LineWidth: 60},
+			"/* a long leading comment that makes the line wide */ SELECT\n  column_one,\n  column_two\nFROM t"},
+		{"select a, concat(b, c, d) from t1 where x in (1, 2, 3)",
+			base.FormatOptions{CommaPlacement: base.CommaLeading},
+			"SELECT a, CONCAT(b, c, d)\nFROM t1\nWHERE x IN (1, 2, 3)"},
+		{"create table t1 (id bigint not null, name varchar(20), price decimal(10, 2), primary key (id))",
+			base.FormatOptions{CommaPlacement: base.CommaLeading, LineWidth: 20},
+			"CREATE TABLE t1 (\n  id BIGINT NOT NULL\n  , name VARCHAR(20)\n  , price DECIMAL(10, 2)\n  , PRIMARY KEY (id)\n)"},
+		{"create table t1 (id bigint not null, price decimal(10, 2))",
+			base.FormatOptions{LineWidth: 20},
+			"CREATE TABLE t1 (\n  id BIGINT NOT NULL,\n  price DECIMAL(10, 2)\n)"},
+		{"insert into t1 (a, b) values (1, 'x'), (2, 'y') on duplicate key update b = values(b)",
+			base.FormatOptions{},
+			"INSERT INTO t1 (a, b)\nVALUES (1, 'x'), (2, 'y')\nON DUPLICATE KEY UPDATE b = VALUES(b)"},
+		{"update t1 set a = 1, b = b + 1 where id = 2; delete from t1 where id = 3",
+			base.FormatOptions{KeywordCase: base.KeywordPreserve},
+			"update t1\nset a = 1, b = b + 1\nwhere id = 2;\ndelete from t1\nwhere id = 3"},
+		{"create table t1 (id bigint not null, name varchar(20) comment 'n', primary key (id)) engine=InnoDB",
+			base.FormatOptions{},
+			"CREATE TABLE t1 (\n  id BIGINT NOT NULL,\n  name VARCHAR(20) COMMENT 'n',\n  PRIMARY KEY (id)\n) ENGINE=InnoDB"},
+		{"DELIMITER $$\ncreate procedure p() begin select 1; end$$\nDELIMITER ;\n/*!40101 set names utf8 */;",
+			base.FormatOptions{},
+			"DELIMITER $$\nCREATE PROCEDURE p() BEGIN\nSELECT 1;\nEND$$\nDELIMITER ;\n/*!40101 set names utf8 */;"},
+	} {
+		got, err := sqlparser.Format(sqlparser.Mysql, c.sql, c.options)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: Format() = %q, want %q", c.sql, got, c.want)
+		}
+		// 格式化前后的token相同
+		if !strings.Contains(c.sql, "DELIMITER") && !equalTokens(c.sql, got) {
+			t.Errorf("%s: tokens of %s differ", c.sql, got)
+		}
+	}
+
+	if _, err := Format("select * frm t1", base.FormatOptions{}); err == nil {
+		t.Error("Format() of invalid sql should fail")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	parser := new(MysqlParser)
+	// 生成的sql与原sql的token相同，再次解析后生成的sql不变
+	corpus := append([]string{
+		"select distinct a, b as c from db.t1 x left join `t2` y on x.id = y.id and x.a = 1 straight_join t3 on t3.id = x.id\n" +
+			"where x.b in (1, 2) and not exists (select 1 from t4 where t4.id = x.id) group by a having count(*) > 1 order by a desc limit 5, 10 for update",
+		"select /*+ max_execution_time(1) */ * from t1 where id = 1 union all (select * from t2) order by id limit 1",
+		"with recursive c (n) as (select 1 union all select n + 1 from c where n < 3) select * from c",
+		"update t1 set a = 1, b = 'x' 'y' where id = 1 limit 1",
+		"delete from t1 where id in (select id from t2) limit 10",
+		"insert into t1 (a) select a from t2 on duplicate key update a = values(a)",
+		"replace into t1 select * from t2",
+		"insert into t1 (a, b) values (1, default), (2, (select max(id) from t2)) on duplicate key update b = default",
+		"insert into t1 set a = 1, b = (select 1 from t2)",
+		"replace into t1 (a) values ()",
+		"call p((select 1 from t1), 2)",
+		"do sleep(1), (select 1 from t1)",
+		"create table t1 like t2",
+		"create view v as select * from t1",
+		"drop table t1, t2",
+		"rename table t1 to t2, t3 to t4",
+		"truncate table t1",
+		"explain select * from t1",
+		"set names utf8mb4",
+	}, parseCorpus...)
+	for _, sql := range corpus {
+		stmts, err := parser.Parse(sql)
+		if err != nil {
+			t.Errorf("%s: %v", sql, err)
+			continue
+		}
+		for _, stmt := range stmts {
+			got, err := sqlstmt.SQL(stmt, sqlstmt.DialectMysql)
+			if err != nil {
+				t.Errorf("%s: %v", stmt.GetText(), err)
+				continue
+			}
+			if !equalTokens(got, stmt.GetText()) {
+				t.Errorf("SQL() = %s, want %s", got, stmt.GetText())
+			}
+			again, err := parser.Parse(got)
+			if err != nil {
+				t.Errorf("%s: %v", got, err)
+				continue
+			}
+			if restored, _ := sqlstmt.SQL(again[0], sqlstmt.DialectMysql); restored != got {
+				t.Errorf("SQL() = %s after parsing %s", restored, got)
+			}
+		}
+	}
+
+	// 修改表名及limit，注释、for update等字段未表示的部分保留
+	stmts, err := parser.Parse("select * from t1 /* keep */ where id = 1 limit 10 for update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs := stmts[0].(*sqlstmt.SimpleSelectStmt).QuerySpecification
+	qs.Limit.RowCount = 20
+	qs.From.TableSources[0].(*sqlstmt.TableSourceBase).TableSourceItem.(*sqlstmt.AtomTableItem).TableName.Identifier.Value = "t2"
+	if got, err := sqlstmt.SQL(stmts[0], sqlstmt.DialectMysql); err != nil || got != "select * from t2 /* keep */ where id = 1 LIMIT 20 for update" {
+		t.Errorf("SQL() = %s, %v", got, err)
+	}
+
+	// 替换为手动构造的节点时，所在的节点按字段生成，select语句中的for update不在其中
+	qs.Where = &sqlstmt.LogicalExpr{Operator: "or", Exprs: []sqlstmt.IExpr{qs.Where, &sqlstmt.LogicalExpr{Operator: "and", Exprs: []sqlstmt.IExpr{
+		&sqlstmt.PredicateExpr{Predicate: &sqlstmt.ExprAtomPredicate{ExprAtom: &sqlstmt.ExprAtomColumnName{ColumnName: &sqlstmt.ColumnName{
+			Identifier: &sqlstmt.IdentifierValue{Value: "deleted"}}}}},
+		&sqlstmt.PredicateExpr{Predicate: &sqlstmt.BinaryComparisonPredicate{
+			Left: &sqlstmt.ExprAtomPredicate{ExprAtom: &sqlstmt.ExprAtomColumnName{ColumnName: &sqlstmt.ColumnName{
+				Owner: "t2", Identifier: &sqlstmt.IdentifierValue{Value: "order id"}}}},
+			ComparisonOperator: "=",
+			Right:              &sqlstmt.ExprAtomPredicate{ExprAtom: &sqlstmt.ExprAtomConstant{Constant: &sqlstmt.Constant{Value: "2"}}},
+		}},
+	}}}}
+	if got, err := sqlstmt.SQL(stmts[0], sqlstmt.DialectMysql); err != nil || got != "SELECT * FROM t2 WHERE id = 1 OR (deleted AND t2.`order id` = 2) LIMIT 20 for update" {
+		t.Errorf("SQL() = %s, %v", got, err)
+	}
+
+	// insert的values及赋值，修改常量后生成的sql随之改变，增加手动构造的行时按字段生成
+	stmts, err = parser.Parse("insert /* keep */ into t1 (a, b) values (1, default) on duplicate key update b = 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	is := stmts[0].(*sqlstmt.InsertStmt)
+	is.DuplicatedElements[0].Value.(*sqlstmt.PredicateExpr).Predicate.(*sqlstmt.ExprAtomPredicate).ExprAtom.(*sqlstmt.ExprAtomConstant).Constant.Value = "3"
+	if got, err := sqlstmt.SQL(is, sqlstmt.DialectMysql); err != nil || got != "insert /* keep */ into t1 (a, b) values (1, default) on duplicate key update b = 3" {
+		t.Errorf("SQL() = %s, %v", got, err)
+	}
+	is.Values = append(is.Values, &sqlstmt.ValuesRow{Exprs: []sqlstmt.IExpr{is.Values[0].Exprs[0], nil}})
+	if got, err := sqlstmt.SQL(is, sqlstmt.DialectMysql); err != nil || got != "INSERT INTO t1 (a, b) VALUES (1, default), (1, DEFAULT) ON DUPLICATE KEY UPDATE b = 3" {
+		t.Errorf("SQL() = %s, %v", got, err)
+	}
+
+	if _, err := sqlstmt.SQL(&sqlstmt.Expr{}, sqlstmt.DialectMysql); !errors.Is(err, sqlstmt.ErrNotRestorable) {
+		t.Errorf("err = %v, want ErrNotRestorable", err)
+	}
+}
+
+// firstNode 语句中第一个T类型的节点
+func firstNode[T sqlstmt.INode](stmt sqlstmt.INode) T {
+	var found T
+	var ok bool
+	sqlstmt.Inspect(stmt, func(node sqlstmt.INode) bool {
+		if !ok {
+			found, ok = node.(T)
+		}
+		return !ok
+	})
+	return found
+}
+
+func TestRestoreModified(t *testing.T) {
+	// 修改解析得到的节点的字段后，该节点按字段生成，不能生成的返回ErrNotRestorable
+	for _, c := range []struct {
+		sql    string
+		modify func(stmt sqlstmt.Stmt)
+		want   string
+	}{
+		{"select * from t1 /* keep */ x where id = 1", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.AtomTableItem](stmt).Alias = "y"
+		}, "select * from t1 AS y where id = 1"},
+		{"select * from (select 1) x", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.SubqueryTableItem](stmt).Alias = "y"
+		}, "select * from (select 1) AS y"},
+		{"select a b, c from t1", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.SelectColumnElement](stmt).Alias = "d"
+		}, "select a AS d, c from t1"},
+		{"select * from t1 where a = 1 and /* keep */ b = 2", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.LogicalExpr](stmt).Operator = "or"
+		}, "select * from t1 where a = 1 OR b = 2"},
+		{"select * from t1 where a = 1 and b = 2 or c = 3", func(stmt sqlstmt.Stmt) {
+			e := firstNode[*sqlstmt.LogicalExpr](stmt)
+			e.Exprs = e.Exprs[1:]
+		}, "select * from t1 where c = 3"},
+		{"select * from t1 where a = /* keep */ 1", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.BinaryComparisonPredicate](stmt).ComparisonOperator = "<>"
+		}, "select * from t1 where a <> 1"},
+		{"select * from t1 where a in (1, 2)", func(stmt sqlstmt.Stmt) {
+			p := firstNode[*sqlstmt.InPredicate](stmt)
+			p.Not, p.Exprs = true, p.Exprs[:1]
+		}, "select * from t1 where a NOT IN (1)"},
+		{"select distinct a from t1 order by a desc", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.QuerySpecification](stmt).Distinct = false
+			firstNode[*sqlstmt.OrderByExpr](stmt).Desc = false
+		}, "SELECT a FROM t1 ORDER BY a"},
+		{"update t1 set a = 1, /* keep */ b = 2 where id = 1", func(stmt sqlstmt.Stmt) {
+			u := stmt.(*sqlstmt.UpdateStmt)
+			u.UpdatedElements = u.UpdatedElements[:1]
+		}, "UPDATE t1 SET a = 1 WHERE id = 1"},
+		{"insert into t1 (a, b) values (1, 2)", func(stmt sqlstmt.Stmt) {
+			r := stmt.(*sqlstmt.InsertStmt).Values[0]
+			r.Exprs = r.Exprs[:1]
+		}, "insert into t1 (a, b) values (1)"},
+		{"select a from t1 where id = 1 for update", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.BinaryComparisonPredicate](stmt).ComparisonOperator = ">"
+		}, "select a from t1 where id > 1 for update"},
+		// 保留字作为标识符时加引号，原sql中未加引号的保持原样
+		{"select x.b from t1 x where x.b = 1", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.ColumnName](stmt).Identifier.Value = "order"
+			firstNode[*sqlstmt.TableName](stmt).Identifier.Value = "select"
+		}, "select x.`order` from `select` x where x.b = 1"},
+		{"select * from t1 where order_id = 1", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.BinaryComparisonPredicate](stmt).ComparisonOperator = ">"
+		}, "select * from t1 where order_id > 1"},
+		{"select * from t1 limit 10", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.Limit](stmt).Unlimited = true
+			firstNode[*sqlstmt.Limit](stmt).Offset = 5
+		}, "select * from t1 LIMIT 5, 18446744073709551615"},
+		{"select * from t3 natural left join t1", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.NaturalJoin](stmt).Type = "right"
+		}, "select * from t3 NATURAL RIGHT JOIN t1"},
+		{"select * from t1, lateral (select * from t2 where t2.id = t1.id) as d", func(stmt sqlstmt.Stmt) {
+			stmt.(*sqlstmt.LateralSelectStmt).Laterals[0].Alias = "e"
+		}, "select * from t1, lateral (select * from t2 where t2.id = t1.id) AS e"},
+		{"table t1 order by id limit 1", func(stmt sqlstmt.Stmt) {
+			stmt.(*sqlstmt.TableStmt).TableName.Identifier.Value = "t2"
+		}, "table t2 order by id LIMIT 1"},
+		{"values (1, 2), (3, 4)", func(stmt sqlstmt.Stmt) {
+			v := stmt.(*sqlstmt.ValuesStmt)
+			v.Rows = v.Rows[:1]
+		}, "VALUES (1, 2)"},
+		{"lock tables t1 read, t2 as b write", func(stmt sqlstmt.Stmt) {
+			stmt.(*sqlstmt.LockTablesStmt).Tables[1].Write = false
+		}, "lock tables t1 read, t2 AS b READ"},
+		// 字段未表示的语法无法保留
+		{"lock tables t1 read local", func(stmt sqlstmt.Stmt) {
+			stmt.(*sqlstmt.LockTablesStmt).Tables[0].Alias = "a"
+		}, ""},
+		{"select * from t1 limit 10", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.Limit](stmt).RowCount = -1
+		}, ""},
+		{"update t1 set a = 1, b = 2 order by id limit 1", func(stmt sqlstmt.Stmt) {
+			u := stmt.(*sqlstmt.UpdateStmt)
+			u.UpdatedElements = u.UpdatedElements[:1]
+		}, ""},
+		{"select a from t1 for update", func(stmt sqlstmt.Stmt) {
+			s := stmt.(*sqlstmt.SimpleSelectStmt)
+			s.QuerySpecification = &sqlstmt.QuerySpecification{SelectElements: s.QuerySpecification.SelectElements}
+		}, ""},
+		{"select * from t1 where a = 1", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.QuerySpecification](stmt).GroupBy = []sqlstmt.IExpr{&sqlstmt.Expr{}}
+		}, ""},
+	} {
+		stmts, err := new(MysqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		c.modify(stmts[0])
+		// 编码后被修改的节点同样按字段生成
+		data, err := sqlstmt.Marshal(stmts[0])
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		node, err := sqlstmt.Unmarshal(data)
+		if err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		for _, node := range []sqlstmt.INode{stmts[0], node} {
+			got, err := sqlstmt.SQL(node, sqlstmt.DialectMysql)
+			if c.want == "" {
+				if !errors.Is(err, sqlstmt.ErrNotRestorable) {
+					t.Errorf("%s: SQL() = %s, %v, want ErrNotRestorable", c.sql, got, err)
+				}
+			} else if err != nil || got != c.want {
+				t.Errorf("%s: SQL() = %s, %v, want %s", c.sql, got, err, c.want)
+			}
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	stmts, err := new(MysqlParser).Parse("select t1.a, (select max(b) from t2 where t2.id = t1.id) + c from t1 join t3 on t1.id = t3.id where d in (select e from t4) union select f from t5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 列按在sql中出现的顺序访问，包括子查询及union各分支中的列
+	var columns []string
+	sqlstmt.Inspect(stmts[0], func(node sqlstmt.INode) bool {
+		if c, ok := node.(*sqlstmt.ColumnName); ok {
+			columns = append(columns, c.GetText())
+		}
+		return true
+	})
+	if want := "t1.a b t2.id t1.id c t1.id t3.id d e f"; strings.Join(columns, " ") != want {
+		t.Errorf("columns = %v, want %s", columns, want)
+	}
+
+	// 表所在的查询
+	var tables []string
+	sqlstmt.InspectStack(stmts[0], func(node sqlstmt.INode, stack []sqlstmt.INode) bool {
+		if table, ok := node.(*sqlstmt.TableName); ok {
+			for i := len(stack) - 1; i >= 0; i-- {
+				if qs, ok := stack[i].(*sqlstmt.QuerySpecification); ok {
+					tables = append(tables, table.GetText()+":"+qs.SelectElements.GetText())
+					break
+				}
+			}
+		}
+		// 不进入子查询
+		_, ok := node.(sqlstmt.ISelectStmt)
+		return !ok || len(stack) == 0
+	})
+	if want := "t1:t1.a, (select max(b) from t2 where t2.id = t1.id) + c t3:t1.a, (select max(b) from t2 where t2.id = t1.id) + c t5:f"; strings.Join(tables, " ") != want {
+		t.Errorf("tables = %v, want %s", tables, want)
+	}
+}
+
+func TestWalkInsert(t *testing.T) {
+	for _, c := range []struct {
+		sql  string
+		want string
+	}{
+		{"insert into t1 (a, b) values (1, (select c from t2)), (default, d) on duplicate key update a = e", "a b c d a e"},
+		{"insert into t1 set a = (select b from t2), c = d", "a b c d"},
+		{"replace into t1 (a) select b from t2", "a b"},
+	} {
+		stmts, err := new(MysqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		var columns []string
+		sqlstmt.Inspect(stmts[0], func(node sqlstmt.INode) bool {
+			if c, ok := node.(*sqlstmt.ColumnName); ok {
+				columns = append(columns, c.GetText())
+			}
+			return true
+		})
+		if got := strings.Join(columns, " "); got != c.want {
+			t.Errorf("%s: columns = %s, want %s", c.sql, got, c.want)
+		}
+	}
+}
+
+func TestWalkStmts(t *testing.T) {
+	// table、values、lateral、load data、handler、lock tables中的表及列
+	for _, c := range []struct {
+		sql  string
+		want string
+	}{
+		{"table t1 order by a limit 1", "t1 a"},
+		{"values (a, (select b from t1)), (c, 1)", "a b t1 c"},
+		{"select a from t1, lateral (select b from t2 where t2.id = t1.id) as d", "a t1 b t2 t2.id t1.id"},
+		{"load data infile 'x' into table t1", "t1"},
+		{"handler t1 read next where a = 1", "t1 a"},
+		{"lock tables t1 read, t2 as b write", "t1 t2"},
+	} {
+		stmts, err := new(MysqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		var names []string
+		sqlstmt.Inspect(stmts[0], func(node sqlstmt.INode) bool {
+			switch n := node.(type) {
+			case *sqlstmt.ColumnName, *sqlstmt.TableName:
+				names = append(names, n.GetText())
+			}
+			return true
+		})
+		if got := strings.Join(names, " "); got != c.want {
+			t.Errorf("%s: names = %s, want %s", c.sql, got, c.want)
+		}
+	}
+}
+
+func TestPosition(t *testing.T) {
+	text := "select 1;\n-- 注释\nselect '😀', a from t1 where b =\n  '中文' and c = 1; select 2"
+	results, err := ParseEach(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := results[1].Stmt
+	if pos, end := stmt.Pos(), stmt.End(); pos != (sqlstmt.Position{Offset: 20, Line: 3, Column: 0}) || end != (sqlstmt.Position{Offset: 75, Line: 4, Column: 16}) {
+		t.Errorf("Pos() = %+v, End() = %+v", pos, end)
+	}
+
+	// 第二行的条件，位置为在原sql中的位置
+	where := stmt.(*sqlstmt.SimpleSelectStmt).QuerySpecification.Where
+	if got := text[where.GetStartIndex() : where.GetStopIndex()+1]; got != "b =\n  '中文' and c = 1" {
+		t.Errorf("where = %q", got)
+	}
+	if pos, end := where.Pos(), where.End(); pos.Line != 3 || pos.Column != 28 || end.Line != 4 || end.Column != 16 {
+		t.Errorf("Pos() = %v, End() = %v", pos, end)
+	}
+	// 😀在UTF-16中为2个码元
+	if got := base.UTF16Column(text, where.GetStartIndex()); got != 29 {
+		t.Errorf("UTF16Column() = %d, want 29", got)
+	}
+	if got := base.UTF16Offset(text, where.GetStartIndex()); got != 45 {
+		t.Errorf("UTF16Offset() = %d, want 45", got)
+	}
+
+	// 同一行中的第二条语句
+	if pos := results[2].Stmt.Pos(); pos != (sqlstmt.Position{Offset: 77, Line: 4, Column: 18}) {
+		t.Errorf("Pos() = %+v", pos)
+	}
+	if pos := (&sqlstmt.Limit{}).Pos(); pos.IsValid() {
+		t.Errorf("Pos() = %v, want invalid", pos)
+	}
+}
+
+func TestDetach(t *testing.T) {
+	results, err := ParseEach("select 1;\nselect a, (select max(b) from t2) + c from t1 /* keep */ where id = 1 limit 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := results[1].Stmt
+	columns := func() string {
+		var names []string
+		sqlstmt.Inspect(stmt, func(node sqlstmt.INode) bool {
+			if c, ok := node.(*sqlstmt.ColumnName); ok {
+				names = append(names, c.GetText()+"@"+c.Pos().String())
+			}
+			return true
+		})
+		return strings.Join(names, " ")
+	}
+	text, pos, end, wantColumns := stmt.GetText(), stmt.Pos(), stmt.End(), columns()
+
+	sqlstmt.Detach(stmt)
+	sqlstmt.Inspect(stmt, func(node sqlstmt.INode) bool {
+		if n, ok := node.(interface{ GetParser() antlr.Parser }); ok && n.GetParser() != nil {
+			t.Errorf("%T references the parser", node)
+		}
+		return true
+	})
+	if stmt.GetText() != text || stmt.Pos() != pos || stmt.End() != end {
+		t.Errorf("GetText() = %s, Pos() = %v, End() = %v", stmt.GetText(), stmt.Pos(), stmt.End())
+	}
+	if got := columns(); got != wantColumns {
+		t.Errorf("columns = %s, want %s", got, wantColumns)
+	}
+
+	// 生成sql不依赖parser
+	stmt.(*sqlstmt.SimpleSelectStmt).QuerySpecification.Limit.RowCount = 20
+	if got, err := sqlstmt.SQL(stmt, sqlstmt.DialectMysql); err != nil || got != "select a, (select max(b) from t2) + c from t1 /* keep */ where id = 1 LIMIT 20" {
+		t.Errorf("SQL() = %s, %v", got, err)
+	}
+
+	// 同一次解析得到的语句分别Detach
+	stmts, err := new(MysqlParser).Parse("select 1;\nselect '中文', a from t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range stmts {
+		sqlstmt.Detach(stmt)
+	}
+	if got, pos := stmts[1].GetText(), stmts[1].Pos(); got != "select '中文', a from t1" || pos != (sqlstmt.Position{Offset: 10, Line: 2, Column: 0}) {
+		t.Errorf("GetText() = %s, Pos() = %+v", got, pos)
+	}
+}
+
+func BenchmarkDetach(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("select * from t1 where id in (")
+	for i := 0; sb.Len() < 100<<10; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(strconv.Itoa(i))
+	}
+	sb.WriteString(")")
+	sql := sb.String()
+	// 解析耗时较长，只解析一次，每次由语法树生成新的节点
+	tree, _, err := GetMysqlParserTree(0, sql)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(sql)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		stmts, err := visitTree(tree, &base.ParseErrorListener{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		for _, stmt := range stmts {
+			sqlstmt.Detach(stmt)
+		}
+	}
+}
+
+// nodePositions 各节点的类型、原文及位置
+func nodePositions(node sqlstmt.INode) string {
+	var sb strings.Builder
+	sqlstmt.Inspect(node, func(n sqlstmt.INode) bool {
+		if n != nil {
+			fmt.Fprintf(&sb, "%T %q %+v %+v\n", n, n.GetText(), n.Pos(), n.End())
+		}
+		return true
+	})
+	return sb.String()
+}
+
+func TestJSON(t *testing.T) {
+	parser := new(MysqlParser)
+	corpus := append([]string{
+		"select distinct a, b as c from db.t1 x left join `t2` y on x.id = y.id\n" +
+			"where x.b in (1, 2.0, 'x') and not exists (select 1 from t4 where t4.id = x.id) order by a desc limit 5, 10 for update",
+		"with c (n) as (select 1) select * from c union all select 2",
+		"update t1 set a = 1 where id = -1",
+		"explain select * from t1",
+		"insert into t1 (a, b) values (1, default), (2, (select 1 from t2)) on duplicate key update b = 3",
+		"insert into t1 set a = 1",
+		"call p(1, (select 1 from t1))",
+		"do 1",
+		"table t1 order by a limit 1",
+		"values (1, 2), (3, 4)",
+		"select * from t1, lateral (select * from t2 where t2.id = t1.id) as d",
+		"load data infile 'x' into table t1",
+		"handler t1 read next where a = 1",
+		"lock tables t1 read, t2 as b write",
+	}, parseCorpus...)
+	for _, sql := range corpus {
+		stmts, err := parser.Parse(sql)
+		if err != nil {
+			t.Errorf("%s: %v", sql, err)
+			continue
+		}
+		for _, stmt := range stmts {
+			data, err := sqlstmt.Marshal(stmt)
+			if err != nil {
+				t.Errorf("%s: %v", stmt.GetText(), err)
+				continue
+			}
+			node, err := sqlstmt.Unmarshal(data)
+			if err != nil {
+				t.Errorf("%s: %v", data, err)
+				continue
+			}
+			if again, err := sqlstmt.Marshal(node); err != nil || string(again) != string(data) {
+				t.Errorf("Marshal(Unmarshal()) = %s, %v, want %s", again, err, data)
+			}
+			// 原文只输出一次，各节点以原文中的范围表示，解码后位置不变
+			if n := strings.Count(string(data), `"text":`); n != 1 {
+				t.Errorf("%s: %d sources", data, n)
+			}
+			if got, want := nodePositions(node), nodePositions(stmt); got != want {
+				t.Errorf("positions = %s, want %s", got, want)
+			}
+			want, _ := sqlstmt.SQL(stmt, sqlstmt.DialectMysql)
+			if got, err := sqlstmt.SQL(node, sqlstmt.DialectMysql); err != nil || got != want {
+				t.Errorf("SQL() = %s, %v, want %s", got, err, want)
+			}
+		}
+	}
+
+	// 多条语句中的语句位于原sql中间
+	results, err := ParseEach("select 1;\n-- 注释\nselect '😀', a from t1 where b =\n  '中文' and c = 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		data, err := sqlstmt.Marshal(result.Stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node, err := sqlstmt.Unmarshal(data)
+		if err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if got, want := nodePositions(node), nodePositions(result.Stmt); got != want {
+			t.Errorf("positions = %s, want %s", got, want)
+		}
+	}
+
+	stmt, err := parser.Parse("select * from t1 /* keep */ where id = 1 and b > 1.0 limit 10 for update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(stmt[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"type":"SimpleSelectStmt"`, `"type":"BinaryComparisonPredicate"`, `"Arg":1`, `"Arg":1.0`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s does not contain %s", data, want)
+		}
+	}
+	var decoded sqlstmt.SimpleSelectStmt
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	var args []any
+	sqlstmt.Inspect(&decoded, func(node sqlstmt.INode) bool {
+		if c, ok := node.(*sqlstmt.Constant); ok {
+			args = append(args, c.Arg)
+		}
+		return true
+	})
+	if len(args) != 2 || args[0] != any(int64(1)) || args[1] != any(1.0) {
+		t.Errorf("args = %#v", args)
+	}
+	decoded.QuerySpecification.Limit.RowCount = 20
+	if got, err := sqlstmt.SQL(&decoded, sqlstmt.DialectMysql); err != nil || got != "select * from t1 /* keep */ where id = 1 and b > 1.0 LIMIT 20 for update" {
+		t.Errorf("SQL() = %s, %v", got, err)
+	}
+
+	if _, err := sqlstmt.Unmarshal([]byte(`{"version":0,"node":null}`)); err == nil {
+		t.Error("Unmarshal() with an unsupported version succeeded")
+	}
+	if err := json.Unmarshal([]byte(`{"type":"UpdateStmt"}`), &decoded); err == nil {
+		t.Error("json.Unmarshal() into a different node type succeeded")
+	}
+}
+
+func TestExprTypes(t *testing.T) {
+	stmts, err := new(MysqlParser).Parse("select * from t where not a between 1 and 2 and b not like 'x!%' escape '!' and c is not null " +
+		"and (d, e) = (1, -2) and g rlike '^x' and h > all (select max(h) from t2) and (i + 1) * 2 is not true and j not in (1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nodes []string
+	sqlstmt.Inspect(stmts[0], func(node sqlstmt.INode) bool {
+		switch n := node.(type) {
+		case *sqlstmt.NotExpr:
+			nodes = append(nodes, "not "+n.Operand.GetText())
+		case *sqlstmt.IsExpr:
+			nodes = append(nodes, fmt.Sprintf("%s is not=%v %s", n.Predicate.GetText(), n.Not, n.TestValue))
+		case *sqlstmt.BetweenPredicate:
+			nodes = append(nodes, fmt.Sprintf("%s not=%v between %s and %s", n.Predicate.GetText(), n.Not, n.Low.GetText(), n.High.GetText()))
+		case *sqlstmt.LikePredicate:
+			nodes = append(nodes, fmt.Sprintf("%s not=%v like %s escape %s", n.Predicate.GetText(), n.Not, n.Pattern.GetText(), n.Escape))
+		case *sqlstmt.IsNullPredicate:
+			nodes = append(nodes, fmt.Sprintf("%s is not=%v null", n.Predicate.GetText(), n.Not))
+		case *sqlstmt.RegexpPredicate:
+			nodes = append(nodes, fmt.Sprintf("%s not=%v %s %s", n.Predicate.GetText(), n.Not, n.Operator, n.Pattern.GetText()))
+		case *sqlstmt.SubqueryComparisonPredicate:
+			nodes = append(nodes, fmt.Sprintf("%s %s %s (%s)", n.Predicate.GetText(), n.ComparisonOperator, n.Quantifier, n.SelectStmt.GetText()))
+			return false
+		case *sqlstmt.InPredicate:
+			nodes = append(nodes, fmt.Sprintf("%s not=%v in %d", n.Predicate.GetText(), n.Not, len(n.Exprs)))
+		case *sqlstmt.ExprAtomNested:
+			nodes = append(nodes, fmt.Sprintf("nested %d", len(n.Exprs)))
+		case *sqlstmt.ExprAtomUnary:
+			nodes = append(nodes, n.Operator+" "+n.Operand.GetText())
+		case *sqlstmt.ExprAtomArithmetic:
+			nodes = append(nodes, n.Left.GetText()+" "+n.Operator+" "+n.Right.GetText())
+		}
+		return true
+	})
+	want := []string{
+		"not a between 1 and 2",
+		"a not=false between 1 and 2",
+		"b not=true like 'x!%' escape '!'",
+		"c is not=true null",
+		"nested 2",
+		"nested 2",
+		"g not=false RLIKE '^x'",
+		"h > ALL (select max(h) from t2)",
+		"(i + 1) * 2 is not=true TRUE",
+		"(i + 1) * 2",
+		"nested 1",
+		"i + 1",
+		"j not=true in 1",
+	}
+	if got := strings.Join(nodes, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("nodes =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// 条件中的列及子查询
+	var columns []string
+	for _, ref := range sqlstmt.ReferencedColumns(stmts[0]) {
+		columns = append(columns, ref.ColumnName.GetText())
+	}
+	if got := strings.Join(columns, ","); got != "a,b,c,d,e,g,h,h,i,j" {
+		t.Errorf("ReferencedColumns() = %s", got)
+	}
+
+	// 按字段生成
+	column := func(name string) sqlstmt.IPredicate {
+		return &sqlstmt.ExprAtomPredicate{ExprAtom: &sqlstmt.ExprAtomColumnName{ColumnName: &sqlstmt.ColumnName{Identifier: sqlstmt.NewIdentifierValue(name)}}}
+	}
+	constant := func(value string) sqlstmt.IExprAtom {
+		return &sqlstmt.ExprAtomConstant{Constant: &sqlstmt.Constant{Value: value}}
+	}
+	expr := &sqlstmt.LogicalExpr{Operator: "and", Exprs: []sqlstmt.IExpr{
+		&sqlstmt.NotExpr{Operand: &sqlstmt.LogicalExpr{Operator: "or", Exprs: []sqlstmt.IExpr{
+			&sqlstmt.PredicateExpr{Predicate: &sqlstmt.IsNullPredicate{Predicate: column("a")}},
+			&sqlstmt.PredicateExpr{Predicate: &sqlstmt.BetweenPredicate{Predicate: column("a"), Not: true,
+				Low: &sqlstmt.ExprAtomPredicate{ExprAtom: constant("1")}, High: &sqlstmt.ExprAtomPredicate{ExprAtom: constant("2")}}},
+		}}},
+		&sqlstmt.PredicateExpr{Predicate: &sqlstmt.LikePredicate{Predicate: column("b"), Pattern: &sqlstmt.ExprAtomPredicate{ExprAtom: constant("'x!%'")}, Escape: "'!'"}},
+		&sqlstmt.PredicateExpr{Predicate: &sqlstmt.BinaryComparisonPredicate{Left: column("c"), ComparisonOperator: "=", Right: &sqlstmt.ExprAtomPredicate{
+			ExprAtom: &sqlstmt.ExprAtomArithmetic{Left: &sqlstmt.ExprAtomUnary{Operator: "-", Operand: constant("-1")}, Operator: "*", Right: &sqlstmt.ExprAtomNested{
+				Exprs: []sqlstmt.IExpr{&sqlstmt.PredicateExpr{Predicate: column("d")}}}}}}},
+	}}
+	if got, err := sqlstmt.SQL(expr, sqlstmt.DialectMysql); err != nil || got != "NOT (a IS NULL OR a NOT BETWEEN 1 AND 2) AND b LIKE 'x!%' ESCAPE '!' AND c = - -1 * (d)" {
+		t.Errorf("SQL() = %s, %v", got, err)
+	}
+}
+
+// equalTokens a与b的token除关键字大小写外相同
+func equalTokens(a, b string) bool {
+	tokens := func(sql string) []string {
+		var texts []string
+		lexer := mysqlparser.NewMySqlLexer(antlr.NewInputStream(sql))
+		for token := lexer.NextToken(); token.GetTokenType() != antlr.TokenEOF; token = lexer.NextToken() {
+			if token.GetChannel() == antlr.TokenDefaultChannel {
+				texts = append(texts, token.GetText())
+			}
+		}
+		return texts
+	}
+	ta, tb := tokens(a), tokens(b)
+	if len(ta) != len(tb) {
+		return false
+	}
+	for i := range ta {
+		if !strings.EqualFold(ta[i], tb[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustParseTree(t *testing.T, sql string) antlr.ParseTree {
+	tree, _, err := GetMysqlParserTree(0, sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+// parseCorpus 可解析的语句，用于TestRestore、TestJSON及FuzzParse的种子
+var parseCorpus = []string{
+	";",
+	"",
+	"select * from t where a = 1 and b like 'x%' escape '!' or c between 1 and 2",
+	"select a, count(*), t.* , (select 1) x from t1 t join (select * from t2) t2 on t.id = t2.id",
+	"select * from (t1, t2) where exists (select 1) and (a, b) in ((1, 2)) and a = any (select 1)",
+	"select * from json_table('[]', '$[*]' columns (a int path '$')) j",
+	"update t set a = a + 1, b = not c where d is null",
+	"delete t1 from t1 join t2 where t1.id = t2.id",
+	"insert into t values (1, 'a')",
+	"show create view v",
+	"DELIMITER ;;\nselect 1;;\nDELIMITER ;\nselect 2;",
+	"create procedure p() begin select 1; end",
+	"select @a := 1, b collate utf8mb4_bin, interval 1 day + now(), j->'$.a', ~1, binary 'a'",
+}
+
+// invalidCorpus 语法错误的语句，仅用于FuzzParse的种子
+var invalidCorpus = []string{
+	";;",
+	"select 1;;select 2",
+	"select 'unterminated",
+}
+
+func FuzzParse(f *testing.F) {
+	for _, sql := range append(parseCorpus, invalidCorpus...) {
+		f.Add(sql)
+	}
+
+	parser := new(MysqlParser)
+	f.Fuzz(func(t *testing.T, sql string) {
+		// 任意输入都不能panic
+		_, _ = parser.Parse(sql)
+		_, _ = ParseEach(sql)
+		_ = CheckSyntax(sql)
+		_, _ = SplitSQL(sql)
+	})
+}
@@ -0,0 +1,120 @@
+package mysql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/may-fly/go-sqlparser"
+	"github.com/may-fly/go-sqlparser/base"
+	mysqlparser "github.com/may-fly/go-sqlparser/mysql/antlr4"
+	"github.com/may-fly/go-sqlparser/sqlstmt"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// Paginate 改写查询的limit为`LIMIT offset, count`，返回查询第offset行起的count行的sql
+// 已有limit时取二者的交集；union的limit作用于整个union；圆括号查询改写括号内的limit；for update等加锁子句保持在末尾
+// limit为变量等非常量时返回sqlparser.ErrNotPaginable
+func Paginate(stmt sqlstmt.Stmt, offset, count int) (string, error) {
+	if offset < 0 || count < 0 {
+		return "", fmt.Errorf("sqlparser: invalid page offset %d or count %d", offset, count)
+	}
+
+	var node *sqlstmt.Node
+	// limit所在的查询，为nil时limit位于union末尾
+	var qs *sqlstmt.QuerySpecification
+	var limit *sqlstmt.Limit
+	var union bool
+	switch s := stmt.(type) {
+	case *sqlstmt.SimpleSelectStmt:
+		node, qs = s.Node, s.QuerySpecification
+	case *sqlstmt.ParenthesisSelect:
+		node = s.Node
+		qe := s.QueryExpr
+		for qe != nil && qe.QueryExpr != nil {
+			qe = qe.QueryExpr
+		}
+		if qe != nil {
+			qs = qe.QuerySpecification
+		}
+	case *sqlstmt.UnionSelectStmt:
+		node, limit, union = s.Node, s.Limit, true
+		// 末尾的查询未加括号时，其后的order by、limit被解析至该查询中，实际作用于整个union
+		if n := len(s.UnionStmts); limit == nil && n > 0 && s.UnionStmts[n-1].QuerySpecification != nil {
+			qs = s.UnionStmts[n-1].QuerySpecification
+		}
+	}
+	ctx, ok := node.GetRuleContext().(antlr.ParserRuleContext)
+	if !ok || (qs == nil && !union) {
+		return "", fmt.Errorf("%w: %T", sqlparser.ErrNotPaginable, stmt)
+	}
+	if qs != nil {
+		limit = qs.Limit
+	}
+
+	limitOffset, limitCount := 0, -1
+	var limitCtx *mysqlparser.LimitClauseContext
+	if limit != nil {
+		if limitCtx, ok = limit.GetRuleContext().(*mysqlparser.LimitClauseContext); !ok {
+			return "", fmt.Errorf("%w: %T", sqlparser.ErrNotPaginable, stmt)
+		}
+		var err error
+		if limitCount, err = limitClauseAtomValue(limitCtx.GetLimit()); err != nil {
+			return "", err
+		}
+		if oc := limitCtx.GetOffset(); oc != nil {
+			if limitOffset, err = limitClauseAtomValue(oc); err != nil {
+				return "", err
+			}
+		}
+	}
+	offset, count = base.PageWindow(limitOffset, limitCount, offset, count)
+	clause := fmt.Sprintf("LIMIT %d, %d", offset, count)
+
+	rewriter := base.NewRewriter(node.GetParser())
+	switch {
+	case limitCtx != nil:
+		rewriter.Replace(limitCtx, clause)
+	case qs != nil:
+		// limit位于查询末尾，select ... into需在into之前
+		qsCtx, ok := qs.GetRuleContext().(antlr.ParserRuleContext)
+		if !ok {
+			return "", fmt.Errorf("%w: %T", sqlparser.ErrNotPaginable, stmt)
+		}
+		if into, ok := qsCtx.GetChild(qsCtx.GetChildCount() - 1).(mysqlparser.ISelectIntoExpressionContext); ok {
+			rewriter.InsertBefore(into, clause+" ")
+		} else {
+			rewriter.InsertAfter(qsCtx, " "+clause)
+		}
+	default:
+		// union的limit位于加锁子句之前
+		if lc := lockClause(ctx); lc != nil {
+			rewriter.InsertBefore(lc, clause+" ")
+		} else {
+			rewriter.InsertAfter(ctx, " "+clause)
+		}
+	}
+	return rewriter.Text(ctx), nil
+}
+
+// lockClause 语句末尾的加锁子句
+func lockClause(ctx antlr.ParserRuleContext) *mysqlparser.LockClauseContext {
+	for _, child := range ctx.GetChildren() {
+		if lc, ok := child.(*mysqlparser.LockClauseContext); ok {
+			return lc
+		}
+	}
+	return nil
+}
+
+// limitClauseAtomValue limit中的常量值，变量等无法确定值时返回sqlparser.ErrNotPaginable
+func limitClauseAtomValue(ctx mysqlparser.ILimitClauseAtomContext) (int, error) {
+	if ctx.DecimalLiteral() == nil {
+		return 0, fmt.Errorf("%w: limit %s is not a constant", sqlparser.ErrNotPaginable, ctx.GetText())
+	}
+	value, err := strconv.Atoi(ctx.GetText())
+	if err != nil {
+		return 0, fmt.Errorf("%w: limit %s: %v", sqlparser.ErrNotPaginable, ctx.GetText(), err)
+	}
+	return value, nil
+}
@@ -0,0 +1,149 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/may-fly/go-sqlparser"
+	"github.com/may-fly/go-sqlparser/base"
+	mysqlparser "github.com/may-fly/go-sqlparser/mysql/antlr4"
+	"github.com/may-fly/go-sqlparser/sqlstmt"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// Parameterize 将语句中的字面量替换为?，返回替换后的sql及按?顺序排列的参数值，用于将拼接的sql转换为预编译语句
+// 参数值：字符串为string(已处理转义及相邻字符串的拼接)，整数为int64(超出时为uint64)，小数为float64，负数包含负号；limit的行数及偏移同样替换
+// NULL、布尔值、类型取决于上下文的十六进制及位值、带字符集或collate的字符串、order by及group by中的列序号、->及->>中的json路径保持不变
+// 仅支持select、insert、replace、update、delete，其他语句返回sqlparser.ErrNotParameterizable
+func Parameterize(stmt sqlstmt.Stmt) (string, []any, error) {
+	switch stmt.Kind() {
+	case sqlstmt.KindSelect, sqlstmt.KindInsert, sqlstmt.KindReplace, sqlstmt.KindUpdate, sqlstmt.KindDelete:
+	default:
+		return "", nil, fmt.Errorf("%w: %s statement", sqlparser.ErrNotParameterizable, stmt.Kind())
+	}
+	node, ok := stmt.(interface {
+		GetParser() antlr.Parser
+		GetRuleContext() antlr.RuleContext
+	})
+	var ctx antlr.ParserRuleContext
+	if ok && node.GetParser() != nil {
+		ctx, _ = node.GetRuleContext().(antlr.ParserRuleContext)
+	}
+	if ctx == nil {
+		return "", nil, fmt.Errorf("%w: %T", sqlparser.ErrNotParameterizable, stmt)
+	}
+
+	params := base.NewParameters(node.GetParser(), func(int) string { return "?" })
+	parameterize(params, ctx)
+	sql, args := params.Text(ctx)
+	return sql, args, nil
+}
+
+func parameterize(params *base.Parameters, tree antlr.Tree) {
+	switch ctx := tree.(type) {
+	case *mysqlparser.UnaryExpressionAtomContext:
+		// -1可能解析为负号与常量
+		if atom, ok := ctx.ExpressionAtom().(*mysqlparser.ConstantExpressionAtomContext); ok && ctx.UnaryOperator().GetText() == "-" {
+			if c := atom.Constant(); c.MINUS() == nil && c.DecimalLiteral() != nil {
+				if value, ok := base.NumberValue("-" + c.DecimalLiteral().GetText()); ok {
+					params.Replace(ctx, value)
+					return
+				}
+			}
+		}
+	case *mysqlparser.ConstantContext:
+		if value, ok := constantValue(ctx); ok && !isPosition(ctx) {
+			params.Replace(ctx, value)
+			return
+		}
+	case *mysqlparser.JsonExpressionAtomContext:
+		// ->、->>右侧的json路径只能是字符串常量
+		parameterize(params, ctx.GetLeft())
+		return
+	case *mysqlparser.LimitClauseAtomContext:
+		if dl := ctx.DecimalLiteral(); dl != nil {
+			if value, ok := base.NumberValue(dl.GetText()); ok {
+				params.Replace(ctx, value)
+			}
+			return
+		}
+	}
+	for _, child := range tree.GetChildren() {
+		parameterize(params, child)
+	}
+}
+
+// isPosition 是否为order by、group by中的列序号，如 order by 1
+func isPosition(ctx *mysqlparser.ConstantContext) bool {
+	return base.IsWholeChild(ctx, func(parent antlr.Tree) bool {
+		switch parent.(type) {
+		case *mysqlparser.OrderByExpressionContext, *mysqlparser.GroupByItemContext:
+			return true
+		}
+		return false
+	})
+}
+
+// constantValue 常量作为参数时的值，无法参数化时返回false
+func constantValue(ctx mysqlparser.IConstantContext) (any, bool) {
+	switch {
+	case ctx.StringLiteral() != nil:
+		return stringLiteralValue(ctx.StringLiteral())
+	case ctx.DecimalLiteral() != nil:
+		if ctx.MINUS() != nil {
+			return base.NumberValue("-" + ctx.DecimalLiteral().GetText())
+		}
+		return base.NumberValue(ctx.DecimalLiteral().GetText())
+	case ctx.REAL_LITERAL() != nil:
+		return base.NumberValue(ctx.REAL_LITERAL().GetText())
+	}
+	return nil, false
+}
+
+// stringLiteralValue 字符串的值，拼接相邻的字符串，字符集及collate无法通过参数指定，返回false
+func stringLiteralValue(ctx mysqlparser.IStringLiteralContext) (any, bool) {
+	if ctx.STRING_CHARSET_NAME() != nil || ctx.COLLATE() != nil {
+		return nil, false
+	}
+	var sb strings.Builder
+	if national := ctx.START_NATIONAL_STRING_LITERAL(); national != nil {
+		sb.WriteString(unquoteString(national.GetText()[1:]))
+	}
+	for _, s := range ctx.AllSTRING_LITERAL() {
+		// 反引号为标识符
+		if strings.HasPrefix(s.GetText(), "`") {
+			return nil, false
+		}
+		sb.WriteString(unquoteString(s.GetText()))
+	}
+	return sb.String(), true
+}
+
+// mysqlEscapes 反斜杠转义的字符，\%及\_保持原样以用于like，其余字符转义为其本身
+var mysqlEscapes = map[byte]string{
+	'0': "\x00", 'b': "\b", 'n': "\n", 'r': "\r", 't': "\t", 'Z': "\x1a", '%': `\%`, '_': `\_`,
+}
+
+// unquoteString 去除字符串两侧的引号，处理反斜杠转义及重复的引号
+func unquoteString(text string) string {
+	quote, s := text[0], text[1:len(text)-1]
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			if escaped, ok := mysqlEscapes[s[i]]; ok {
+				sb.WriteString(escaped)
+			} else {
+				sb.WriteByte(s[i])
+			}
+		case c == quote && i+1 < len(s) && s[i+1] == quote:
+			i++
+			sb.WriteByte(quote)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
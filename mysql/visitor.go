@@ -3,14 +3,18 @@ package mysql
 import (
 	"strings"
 
+	"github.com/may-fly/go-sqlparser/base"
 	mysqlparser "github.com/may-fly/go-sqlparser/mysql/antlr4"
 	"github.com/may-fly/go-sqlparser/sqlstmt"
 
+	"github.com/antlr4-go/antlr/v4"
 	"github.com/may-fly/cast"
 )
 
 type MysqlVisitor struct {
 	*mysqlparser.BaseMySqlParserVisitor
+
+	sideEffects map[antlr.Tree]sqlstmt.SelectSideEffects // 已遍历的查询的副作用，见selectSideEffects
 }
 
 func (v *MysqlVisitor) VisitRoot(ctx *mysqlparser.RootContext) interface{} {
@@ -19,18 +23,129 @@ func (v *MysqlVisitor) VisitRoot(ctx *mysqlparser.RootContext) interface{} {
 		return stms.Accept(v)
 	}
 
-	return nil
+	return make([]sqlstmt.Stmt, 0)
 }
 
 func (v *MysqlVisitor) VisitSqlStatements(ctx *mysqlparser.SqlStatementsContext) interface{} {
-	allSqlStatement := ctx.AllSqlStatement()
 	stmts := make([]sqlstmt.Stmt, 0)
-	for _, sqlStatement := range allSqlStatement {
-		stmts = append(stmts, sqlStatement.Accept(v).(sqlstmt.Stmt))
+	// mysql语法中with子句被解析为独立的语句，需合并至其后的语句
+	var with *sqlstmt.WithClause
+	var withCtx *mysqlparser.SqlStatementContext
+	for _, child := range ctx.GetChildren() {
+		sqlStatement, ok := child.(*mysqlparser.SqlStatementContext)
+		if !ok {
+			if with != nil {
+				// with后直接结束的语句
+				stmts = append(stmts, base.Accept[sqlstmt.Stmt](v, withCtx))
+				with = nil
+			}
+			continue
+		}
+
+		if dsc := sqlStatement.DmlStatement(); dsc != nil && dsc.WithStatement() != nil {
+			if with != nil {
+				stmts = append(stmts, base.Accept[sqlstmt.Stmt](v, withCtx))
+			}
+			with, withCtx = base.Accept[*sqlstmt.WithClause](v, dsc.WithStatement()), sqlStatement
+			continue
+		}
+
+		if with != nil {
+			extendStart(sqlStatement, withCtx.GetStart())
+		}
+		stmt := base.Accept[sqlstmt.Stmt](v, sqlStatement)
+		if with != nil && !setWith(stmt, with) {
+			stmts = append(stmts, base.Accept[sqlstmt.Stmt](v, withCtx))
+		}
+		with = nil
+		if stmt != nil {
+			stmts = append(stmts, stmt)
+		}
+	}
+	if with != nil {
+		stmts = append(stmts, base.Accept[sqlstmt.Stmt](v, withCtx))
 	}
 	return stmts
 }
 
+// extendStart 将语句的起始位置前移至with子句，使语句的文本包含with子句
+func extendStart(ctx antlr.ParserRuleContext, start antlr.Token) {
+	for ctx != nil {
+		switch ctx.(type) {
+		case *mysqlparser.SqlStatementContext, *mysqlparser.DmlStatementContext, mysqlparser.ISelectStatementContext,
+			*mysqlparser.UpdateStatementContext, *mysqlparser.SingleUpdateStatementContext, *mysqlparser.MultipleUpdateStatementContext,
+			*mysqlparser.DeleteStatementContext, *mysqlparser.SingleDeleteStatementContext, *mysqlparser.MultipleDeleteStatementContext:
+			ctx.SetStart(start)
+		default:
+			return
+		}
+		ctx, _ = ctx.GetChild(0).(antlr.ParserRuleContext)
+	}
+}
+
+// spanContext 以start、stop为起止的语法树节点，用于语法中无对应规则的片段，如单表update、delete中的表名及别名
+func spanContext(parent antlr.ParserRuleContext, start, stop antlr.Token) antlr.ParserRuleContext {
+	span := antlr.NewBaseParserRuleContext(parent, -1)
+	span.SetStart(start)
+	span.SetStop(stop)
+	return span
+}
+
+// setWith 设置语句的with子句，语句不支持with时返回false
+func setWith(stmt sqlstmt.INode, with *sqlstmt.WithClause) bool {
+	switch s := stmt.(type) {
+	case *sqlstmt.SimpleSelectStmt:
+		s.With = with
+	case *sqlstmt.UnionSelectStmt:
+		s.With = with
+	case *sqlstmt.ParenthesisSelect:
+		s.With = with
+	case *sqlstmt.SelectStmt:
+		s.With = with
+	case *sqlstmt.UpdateStmt:
+		s.With = with
+	case *sqlstmt.DeleteStmt:
+		s.With = with
+	default:
+		return false
+	}
+	return true
+}
+
+func (v *MysqlVisitor) VisitWithStatement(ctx *mysqlparser.WithStatementContext) interface{} {
+	with := new(sqlstmt.WithClause)
+	with.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	with.Recursive = ctx.RECURSIVE() != nil
+	for _, ctec := range ctx.AllCommonTableExpressions() {
+		with.CTEs = append(with.CTEs, v.getCommonTableExprs(ctec)...)
+	}
+	return with
+}
+
+func (v *MysqlVisitor) VisitWithClause(ctx *mysqlparser.WithClauseContext) interface{} {
+	with := new(sqlstmt.WithClause)
+	with.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	with.Recursive = ctx.RECURSIVE() != nil
+	with.CTEs = v.getCommonTableExprs(ctx.CommonTableExpressions())
+	return with
+}
+
+// getCommonTableExprs commonTableExpressions规则中以逗号分隔的后续表达式嵌套在其中
+func (v *MysqlVisitor) getCommonTableExprs(ctx mysqlparser.ICommonTableExpressionsContext) []*sqlstmt.CommonTableExpr {
+	var ctes []*sqlstmt.CommonTableExpr
+	for ; ctx != nil; ctx = ctx.CommonTableExpressions() {
+		cte := new(sqlstmt.CommonTableExpr)
+		cte.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+		cte.Name = ctx.CteName().GetText()
+		for _, ccnc := range ctx.AllCteColumnName() {
+			cte.Columns = append(cte.Columns, ccnc.GetText())
+		}
+		cte.Stmt = base.Accept[sqlstmt.Stmt](v, ctx.DmlStatement())
+		ctes = append(ctes, cte)
+	}
+	return ctes
+}
+
 func (v *MysqlVisitor) VisitSqlStatement(ctx *mysqlparser.SqlStatementContext) interface{} {
 	if ctx.DmlStatement() != nil {
 		return ctx.DmlStatement().Accept(v)
@@ -41,16 +156,87 @@ func (v *MysqlVisitor) VisitSqlStatement(ctx *mysqlparser.SqlStatementContext) i
 	if ctx.AdministrationStatement() != nil {
 		return ctx.AdministrationStatement().Accept(v)
 	}
+	if ctx.UtilityStatement() != nil {
+		return ctx.UtilityStatement().Accept(v)
+	}
+	if tsc := ctx.TransactionStatement(); tsc != nil {
+		if ltc := tsc.LockTables(); ltc != nil {
+			return ltc.Accept(v)
+		}
+		tcl := new(sqlstmt.TclStmt)
+		tcl.Node = sqlstmt.NewNode(tsc.GetParser(), tsc)
+		return tcl
+	}
 
-	return sqlstmt.NewNode(ctx.GetParser(), ctx)
+	os := new(sqlstmt.OtherStmt)
+	os.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	return os
+}
+
+func (v *MysqlVisitor) VisitLockTables(ctx *mysqlparser.LockTablesContext) interface{} {
+	lts := new(sqlstmt.LockTablesStmt)
+	lts.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	for _, ltec := range ctx.AllLockTableElement() {
+		lt := new(sqlstmt.LockTable)
+		lt.Node = sqlstmt.NewNode(ltec.GetParser(), ltec)
+		lt.TableName = base.Accept[*sqlstmt.TableName](v, ltec.TableName())
+		if uc := ltec.Uid(); uc != nil {
+			lt.Alias = uc.GetText()
+		}
+		lt.Write = ltec.LockAction().WRITE() != nil
+		lts.Tables = append(lts.Tables, lt)
+	}
+	return lts
 }
 
 func (v *MysqlVisitor) VisitEmptyStatement_(ctx *mysqlparser.EmptyStatement_Context) interface{} {
-	return ""
+	return nil
 }
 
 func (v *MysqlVisitor) VisitDdlStatement(ctx *mysqlparser.DdlStatementContext) interface{} {
-	ddlStmt := sqlstmt.DdlStmt{}
+	if cdc := ctx.CreateDatabase(); cdc != nil {
+		return cdc.Accept(v)
+	}
+	if c := ctx.CreateTable(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.CreateIndex(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.CreateView(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.AlterTable(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.DropIndex(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.DropTable(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.DropView(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.RenameTable(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.TruncateTable(); c != nil {
+		return c.Accept(v)
+	}
+	// 角色属于权限控制
+	if ctx.CreateRole() != nil || ctx.DropRole() != nil {
+		dcl := new(sqlstmt.DclStmt)
+		dcl.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+		return dcl
+	}
+	if ctx.SetRole() != nil {
+		set := new(sqlstmt.SetStmt)
+		set.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+		return set
+	}
+
+	ddlStmt := new(sqlstmt.DdlStmt)
 	ddlStmt.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 	return ddlStmt
 }
@@ -68,58 +254,246 @@ func (v *MysqlVisitor) VisitDmlStatement(ctx *mysqlparser.DmlStatementContext) i
 	if isc := ctx.InsertStatement(); isc != nil {
 		return isc.Accept(v)
 	}
+	if rsc := ctx.ReplaceStatement(); rsc != nil {
+		return rsc.Accept(v)
+	}
+	if csc := ctx.CallStatement(); csc != nil {
+		return csc.Accept(v)
+	}
+	if dsc := ctx.DoStatement(); dsc != nil {
+		return dsc.Accept(v)
+	}
+	if tsc := ctx.TableStatement(); tsc != nil {
+		return tsc.Accept(v)
+	}
+	if vsc := ctx.ValuesStatement(); vsc != nil {
+		return vsc.Accept(v)
+	}
+	if ldc := ctx.LoadDataStatement(); ldc != nil {
+		return ldc.Accept(v)
+	}
+	if lxc := ctx.LoadXmlStatement(); lxc != nil {
+		return lxc.Accept(v)
+	}
+	if hsc := ctx.HandlerStatement(); hsc != nil {
+		return hsc.Accept(v)
+	}
 
-	dmlStmt := sqlstmt.DmlStmt{}
+	dmlStmt := new(sqlstmt.DmlStmt)
 	dmlStmt.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 	return dmlStmt
 }
 
+// VisitTableStatement table t等同于select * from t
+func (v *MysqlVisitor) VisitTableStatement(ctx *mysqlparser.TableStatementContext) interface{} {
+	ts := new(sqlstmt.TableStmt)
+	ts.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	ts.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
+	if obc := ctx.OrderByClause(); obc != nil {
+		ts.OrderBy = base.Accept[[]*sqlstmt.OrderByExpr](v, obc)
+	}
+	if lc := ctx.LimitClause(); lc != nil {
+		ts.Limit = base.Accept[*sqlstmt.Limit](v, lc)
+	}
+	return ts
+}
+
+func (v *MysqlVisitor) VisitValuesStatement(ctx *mysqlparser.ValuesStatementContext) interface{} {
+	vs := new(sqlstmt.ValuesStmt)
+	vs.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	vs.Rows = v.valuesRows(ctx.GetParser(), ctx)
+	return vs
+}
+
+func (v *MysqlVisitor) VisitLoadDataStatement(ctx *mysqlparser.LoadDataStatementContext) interface{} {
+	ld := new(sqlstmt.LoadDataStmt)
+	ld.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	ld.Replace = ctx.REPLACE() != nil
+	ld.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
+	for _, uec := range ctx.AllUpdatedElement() {
+		ld.SetElements = append(ld.SetElements, base.Accept[*sqlstmt.UpdatedElement](v, uec))
+	}
+	return ld
+}
+
+func (v *MysqlVisitor) VisitLoadXmlStatement(ctx *mysqlparser.LoadXmlStatementContext) interface{} {
+	ld := new(sqlstmt.LoadDataStmt)
+	ld.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	ld.Xml = true
+	ld.Replace = ctx.REPLACE() != nil
+	ld.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
+	for _, uec := range ctx.AllUpdatedElement() {
+		ld.SetElements = append(ld.SetElements, base.Accept[*sqlstmt.UpdatedElement](v, uec))
+	}
+	return ld
+}
+
+func (v *MysqlVisitor) VisitHandlerStatement(ctx *mysqlparser.HandlerStatementContext) interface{} {
+	hs := new(sqlstmt.HandlerStmt)
+	hs.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	switch c := ctx.GetChild(0).(type) {
+	case *mysqlparser.HandlerOpenStatementContext:
+		hs.TableName = base.Accept[*sqlstmt.TableName](v, c.TableName())
+		if uc := c.Uid(); uc != nil {
+			hs.Alias = uc.GetText()
+		}
+	case *mysqlparser.HandlerReadIndexStatementContext:
+		hs.TableName = base.Accept[*sqlstmt.TableName](v, c.TableName())
+		if ec := c.Expression(); ec != nil {
+			hs.Where = v.GetExpr(ec)
+		}
+	case *mysqlparser.HandlerReadStatementContext:
+		hs.TableName = base.Accept[*sqlstmt.TableName](v, c.TableName())
+		if ec := c.Expression(); ec != nil {
+			hs.Where = v.GetExpr(ec)
+		}
+	case *mysqlparser.HandlerCloseStatementContext:
+		hs.TableName = base.Accept[*sqlstmt.TableName](v, c.TableName())
+	}
+	return hs
+}
+
+func (v *MysqlVisitor) VisitCallStatement(ctx *mysqlparser.CallStatementContext) interface{} {
+	call := new(sqlstmt.CallStmt)
+	call.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	// 仅包含常量的参数被解析为constants
+	if cc := ctx.Constants(); cc != nil {
+		for _, c := range cc.AllConstant() {
+			call.Args = append(call.Args, v.constantExpr(c))
+		}
+	}
+	if ec := ctx.Expressions(); ec != nil {
+		call.Args = v.GetExprs(ec.AllExpression())
+	}
+	return call
+}
+
+func (v *MysqlVisitor) VisitDoStatement(ctx *mysqlparser.DoStatementContext) interface{} {
+	do := new(sqlstmt.DoStmt)
+	do.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	if ec := ctx.Expressions(); ec != nil {
+		do.Exprs = v.GetExprs(ec.AllExpression())
+	}
+	return do
+}
+
+// constantExpr 由常量构造表达式，用于语法中只允许常量的位置
+func (v *MysqlVisitor) constantExpr(ctx mysqlparser.IConstantContext) sqlstmt.IExpr {
+	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
+	atom := new(sqlstmt.ExprAtomConstant)
+	atom.Node = node
+	atom.Constant = base.Accept[*sqlstmt.Constant](v, ctx)
+	eap := new(sqlstmt.ExprAtomPredicate)
+	eap.Node = node
+	eap.ExprAtom = atom
+	pe := new(sqlstmt.PredicateExpr)
+	pe.Node = node
+	pe.Predicate = eap
+	return pe
+}
+
 func (v *MysqlVisitor) VisitAdministrationStatement(ctx *mysqlparser.AdministrationStatementContext) interface{} {
-	if ssc := ctx.ShowStatement(); ssc != nil {
-		return ssc.Accept(v)
+	if ctx.ShowStatement() != nil {
+		show := new(sqlstmt.ShowStmt)
+		show.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+		return show
+	}
+	if ssc := ctx.SetStatement(); ssc != nil {
+		set := new(sqlstmt.SetStmt)
+		set.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+		switch c := ssc.(type) {
+		case *mysqlparser.SetVariableContext:
+			set.Values = v.GetExprs(c.AllExpression())
+		case *mysqlparser.SetNewValueInsideTriggerContext:
+			set.Values = v.GetExprs(c.AllExpression())
+		}
+		return set
+	}
+	if ctx.AlterUser() != nil || ctx.CreateUser() != nil || ctx.DropUser() != nil || ctx.RenameUser() != nil ||
+		ctx.GrantStatement() != nil || ctx.GrantProxy() != nil || ctx.RevokeStatement() != nil || ctx.RevokeProxy() != nil {
+		dcl := new(sqlstmt.DclStmt)
+		dcl.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+		return dcl
 	}
-	return sqlstmt.NewNode(ctx.GetParser(), ctx)
+
+	os := new(sqlstmt.OtherStmt)
+	os.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	return os
+}
+
+func (v *MysqlVisitor) VisitUtilityStatement(ctx *mysqlparser.UtilityStatementContext) interface{} {
+	if sdc := ctx.SimpleDescribeStatement(); sdc != nil {
+		return sdc.Accept(v)
+	}
+	if fdc := ctx.FullDescribeStatement(); fdc != nil {
+		return fdc.Accept(v)
+	}
+	if uc := ctx.UseStatement(); uc != nil {
+		use := new(sqlstmt.UseStmt)
+		use.Node = sqlstmt.NewNode(uc.GetParser(), uc)
+		return use
+	}
+	if hc := ctx.HelpStatement(); hc != nil {
+		ort := new(sqlstmt.OtherReadStmt)
+		ort.Node = sqlstmt.NewNode(hc.GetParser(), hc)
+		return ort
+	}
+
+	os := new(sqlstmt.OtherStmt)
+	os.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	return os
 }
 
 func (v *MysqlVisitor) VisitSimpleSelect(ctx *mysqlparser.SimpleSelectContext) interface{} {
 	sss := new(sqlstmt.SimpleSelectStmt)
 	sss.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	sss.QuerySpecification = ctx.QuerySpecification().Accept(v).(*sqlstmt.QuerySpecification)
+	sss.SideEffects = v.selectSideEffects(ctx)
+	sss.QuerySpecification = base.Accept[*sqlstmt.QuerySpecification](v, ctx.QuerySpecification())
 	return sss
 }
 
 func (v *MysqlVisitor) VisitUnionSelect(ctx *mysqlparser.UnionSelectContext) interface{} {
 	uss := new(sqlstmt.UnionSelectStmt)
 	uss.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	uss.SideEffects = v.selectSideEffects(ctx)
 
-	if lc := ctx.LimitClause(); lc != nil {
-		uss.Limit = lc.Accept(v).(*sqlstmt.Limit)
+	if obc := ctx.OrderByClause(); obc != nil {
+		uss.OrderBy = base.Accept[[]*sqlstmt.OrderByExpr](v, obc)
 	}
-
-	if ausc := ctx.AllUnionStatement(); ausc != nil {
-		unionStmts := make([]*sqlstmt.UnionStmt, 0)
-		for _, usc := range ausc {
-			unionStmts = append(unionStmts, usc.Accept(v).(*sqlstmt.UnionStmt))
-		}
-		uss.UnionStmts = unionStmts
+	if lc := ctx.LimitClause(); lc != nil {
+		uss.Limit = base.Accept[*sqlstmt.Limit](v, lc)
 	}
 
-	if qsc := ctx.QuerySpecification(); qsc != nil {
-		uss.QuerySpecification = qsc.Accept(v).(*sqlstmt.QuerySpecification)
-	}
 	if qscn := ctx.QuerySpecificationNointo(); qscn != nil {
-		uss.QuerySpecification = qscn.Accept(v).(*sqlstmt.QuerySpecification)
-	}
-	if qec := ctx.QueryExpression(); qec != nil {
-		uss.QueryExpr = qec.Accept(v).(*sqlstmt.QueryExpr)
+		uss.QuerySpecification = base.Accept[*sqlstmt.QuerySpecification](v, qscn)
 	}
 	if qenc := ctx.QueryExpressionNointo(); qenc != nil {
-		uss.QueryExpr = qenc.Accept(v).(*sqlstmt.QueryExpr)
+		uss.QueryExpr = base.Accept[*sqlstmt.QueryExpr](v, qenc)
 	}
 
+	unionStmts := v.appendNestedUnionStmts(make([]*sqlstmt.UnionStmt, 0), ctx.QuerySpecificationNointo())
+	for _, usc := range ctx.AllUnionStatement() {
+		unionStmts = append(unionStmts, base.Accept[*sqlstmt.UnionStmt](v, usc))
+		unionStmts = v.appendNestedUnionStmts(unionStmts, usc.QuerySpecificationNointo())
+	}
+
+	// 末尾可包含into的union
 	if ui := ctx.UNION(); ui != nil {
 		uss.UnionType = ui.GetText()
+
+		us := new(sqlstmt.UnionStmt)
+		us.UnionType = ui.GetText()
+		if qsc := ctx.QuerySpecification(); qsc != nil {
+			us.Node = sqlstmt.NewNode(qsc.GetParser(), qsc)
+			us.QuerySpecification = base.Accept[*sqlstmt.QuerySpecification](v, qsc)
+		}
+		if qec := ctx.QueryExpression(); qec != nil {
+			us.Node = sqlstmt.NewNode(qec.GetParser(), qec)
+			us.QueryExpr = base.Accept[*sqlstmt.QueryExpr](v, qec)
+		}
+		unionStmts = append(unionStmts, us)
 	}
+	uss.UnionStmts = unionStmts
 
 	return uss
 }
@@ -127,24 +501,130 @@ func (v *MysqlVisitor) VisitUnionSelect(ctx *mysqlparser.UnionSelectContext) int
 func (v *MysqlVisitor) VisitParenthesisSelect(ctx *mysqlparser.ParenthesisSelectContext) interface{} {
 	ps := new(sqlstmt.ParenthesisSelect)
 	ps.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	ps.SideEffects = v.selectSideEffects(ctx)
 
 	if qec := ctx.QueryExpression(); qec != nil {
-		ps.QueryExpr = qec.Accept(v).(*sqlstmt.QueryExpr)
+		ps.QueryExpr = base.Accept[*sqlstmt.QueryExpr](v, qec)
 	}
 
 	return ps
 }
 
 func (v *MysqlVisitor) VisitUnionParenthesisSelect(ctx *mysqlparser.UnionParenthesisSelectContext) interface{} {
-	ss := new(sqlstmt.SelectStmt)
-	ss.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ss
+	uss := new(sqlstmt.UnionSelectStmt)
+	uss.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	uss.SideEffects = v.selectSideEffects(ctx)
+
+	if obc := ctx.OrderByClause(); obc != nil {
+		uss.OrderBy = base.Accept[[]*sqlstmt.OrderByExpr](v, obc)
+	}
+	if lc := ctx.LimitClause(); lc != nil {
+		uss.Limit = base.Accept[*sqlstmt.Limit](v, lc)
+	}
+
+	uss.QueryExpr = base.Accept[*sqlstmt.QueryExpr](v, ctx.QueryExpressionNointo())
+
+	unionStmts := make([]*sqlstmt.UnionStmt, 0)
+	for _, upc := range ctx.AllUnionParenthesis() {
+		us := new(sqlstmt.UnionStmt)
+		us.Node = sqlstmt.NewNode(upc.GetParser(), upc)
+		us.UnionType = upc.UNION().GetText()
+		us.QueryExpr = base.Accept[*sqlstmt.QueryExpr](v, upc.QueryExpressionNointo())
+		unionStmts = append(unionStmts, us)
+	}
+	if ui := ctx.UNION(); ui != nil {
+		uss.UnionType = ui.GetText()
+
+		us := new(sqlstmt.UnionStmt)
+		us.Node = sqlstmt.NewNode(ctx.QueryExpression().GetParser(), ctx.QueryExpression())
+		us.UnionType = ui.GetText()
+		us.QueryExpr = base.Accept[*sqlstmt.QueryExpr](v, ctx.QueryExpression())
+		unionStmts = append(unionStmts, us)
+	}
+	uss.UnionStmts = unionStmts
+
+	return uss
 }
 
 func (v *MysqlVisitor) VisitWithLateralStatement(ctx *mysqlparser.WithLateralStatementContext) interface{} {
-	ss := new(sqlstmt.SelectStmt)
-	ss.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ss
+	ls := new(sqlstmt.LateralSelectStmt)
+	ls.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	ls.SideEffects = v.selectSideEffects(ctx)
+	ls.QuerySpecification = base.Accept[*sqlstmt.QuerySpecification](v, ctx.QuerySpecificationNointo())
+	for _, lsc := range ctx.AllLateralStatement() {
+		ls.Laterals = append(ls.Laterals, base.Accept[*sqlstmt.SubqueryTableItem](v, lsc))
+	}
+	return ls
+}
+
+// VisitLateralStatement lateral派生表，不包含lateral关键字，其中的查询在语法中无对应的select规则，以查询本身为select的起止
+func (v *MysqlVisitor) VisitLateralStatement(ctx *mysqlparser.LateralStatementContext) interface{} {
+	sti := new(sqlstmt.SubqueryTableItem)
+	start := ctx.GetStart()
+	switch c := ctx.GetChild(1).(type) {
+	case antlr.TerminalNode:
+		start = c.GetSymbol()
+	case antlr.ParserRuleContext:
+		start = c.GetStart()
+	}
+	sti.Node = sqlstmt.NewNode(ctx.GetParser(), spanContext(ctx, start, ctx.GetStop()))
+	if qsc := ctx.QuerySpecificationNointo(); qsc != nil {
+		sss := new(sqlstmt.SimpleSelectStmt)
+		sss.Node = sqlstmt.NewNode(qsc.GetParser(), qsc)
+		sss.QuerySpecification = base.Accept[*sqlstmt.QuerySpecification](v, qsc)
+		sti.SelectStmt = sss
+	}
+	if qec := ctx.QueryExpressionNointo(); qec != nil {
+		ps := new(sqlstmt.ParenthesisSelect)
+		ps.Node = sqlstmt.NewNode(qec.GetParser(), qec)
+		ps.QueryExpr = base.Accept[*sqlstmt.QueryExpr](v, qec)
+		sti.SelectStmt = ps
+	}
+	if uc := ctx.Uid(); uc != nil {
+		sti.Alias = uc.GetText()
+	}
+	return sti
+}
+
+// selectSideEffects 查询的语法树(包含子查询)中写文件、加锁等副作用
+// 首次调用时遍历一次语法树并记录其中各查询的副作用，之后访问嵌套的查询时不再重复遍历
+func (v *MysqlVisitor) selectSideEffects(ctx mysqlparser.ISelectStatementContext) sqlstmt.SelectSideEffects {
+	if effects, ok := v.sideEffects[ctx]; ok {
+		return effects
+	}
+	if v.sideEffects == nil {
+		v.sideEffects = make(map[antlr.Tree]sqlstmt.SelectSideEffects)
+	}
+	var walk func(antlr.Tree) sqlstmt.SelectSideEffects
+	walk = func(t antlr.Tree) sqlstmt.SelectSideEffects {
+		var effects sqlstmt.SelectSideEffects
+		switch t.(type) {
+		case *mysqlparser.SelectIntoTextFileContext, *mysqlparser.SelectIntoDumpFileContext:
+			effects.IntoFile = true
+		case *mysqlparser.SelectIntoVariablesContext:
+			effects.IntoVariables = true
+		case *mysqlparser.LockClauseContext:
+			effects.Locking = true
+		}
+		for _, child := range t.GetChildren() {
+			effects = effects.Merge(walk(child))
+		}
+		if _, ok := t.(mysqlparser.ISelectStatementContext); ok {
+			v.sideEffects[t] = effects
+		}
+		return effects
+	}
+	return walk(ctx)
+}
+
+// appendNestedUnionStmts querySpecificationNointo规则末尾可包含union，按出现顺序展开为union的各分支
+func (v *MysqlVisitor) appendNestedUnionStmts(unionStmts []*sqlstmt.UnionStmt, ctx mysqlparser.IQuerySpecificationNointoContext) []*sqlstmt.UnionStmt {
+	for ctx != nil && ctx.UnionStatement() != nil {
+		usc := ctx.UnionStatement()
+		unionStmts = append(unionStmts, base.Accept[*sqlstmt.UnionStmt](v, usc))
+		ctx = usc.QuerySpecificationNointo()
+	}
+	return unionStmts
 }
 
 func (v *MysqlVisitor) VisitUnionStatement(ctx *mysqlparser.UnionStatementContext) interface{} {
@@ -152,10 +632,10 @@ func (v *MysqlVisitor) VisitUnionStatement(ctx *mysqlparser.UnionStatementContex
 	us.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
 	if qs := ctx.QuerySpecificationNointo(); qs != nil {
-		us.QuerySpecification = qs.Accept(v).(*sqlstmt.QuerySpecification)
+		us.QuerySpecification = base.Accept[*sqlstmt.QuerySpecification](v, qs)
 	}
 	if qec := ctx.QueryExpressionNointo(); qec != nil {
-		us.QueryExpr = qec.Accept(v).(*sqlstmt.QueryExpr)
+		us.QueryExpr = base.Accept[*sqlstmt.QueryExpr](v, qec)
 	}
 
 	if ui := ctx.UNION(); ui != nil {
@@ -169,7 +649,8 @@ func (v *MysqlVisitor) VisitQuerySpecification(ctx *mysqlparser.QuerySpecificati
 	qs := new(sqlstmt.QuerySpecification)
 	qs.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
-	qs.SelectElements = ctx.SelectElements().Accept(v).(*sqlstmt.SelectElements)
+	qs.Distinct = isDistinct(ctx.AllSelectSpec())
+	qs.SelectElements = base.Accept[*sqlstmt.SelectElements](v, ctx.SelectElements())
 
 	if fromClause := ctx.FromClause(); fromClause != nil {
 		where := fromClause.GetWhereExpr()
@@ -181,13 +662,25 @@ func (v *MysqlVisitor) VisitQuerySpecification(ctx *mysqlparser.QuerySpecificati
 		if tableSourcesCtx != nil {
 			tss := new(sqlstmt.TableSources)
 			tss.Node = sqlstmt.NewNode(tableSourcesCtx.GetParser(), tableSourcesCtx)
-			tss.TableSources = tableSourcesCtx.Accept(v).([]sqlstmt.ITableSource)
+			tss.TableSources = base.Accept[[]sqlstmt.ITableSource](v, tableSourcesCtx)
 			qs.From = tss
 		}
 	}
 
+	if gbc := ctx.GroupByClause(); gbc != nil {
+		for _, gbic := range gbc.AllGroupByItem() {
+			qs.GroupBy = append(qs.GroupBy, v.GetExpr(gbic.Expression()))
+		}
+	}
+	if hc := ctx.HavingClause(); hc != nil {
+		qs.Having = v.GetExpr(hc.GetHavingExpr())
+	}
+	if obc := ctx.OrderByClause(); obc != nil {
+		qs.OrderBy = base.Accept[[]*sqlstmt.OrderByExpr](v, obc)
+	}
+
 	if limitClause := ctx.LimitClause(); limitClause != nil {
-		qs.Limit = limitClause.Accept(v).(*sqlstmt.Limit)
+		qs.Limit = base.Accept[*sqlstmt.Limit](v, limitClause)
 	}
 
 	return qs
@@ -197,7 +690,8 @@ func (v *MysqlVisitor) VisitQuerySpecificationNointo(ctx *mysqlparser.QuerySpeci
 	qs := new(sqlstmt.QuerySpecification)
 	qs.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
-	qs.SelectElements = ctx.SelectElements().Accept(v).(*sqlstmt.SelectElements)
+	qs.Distinct = isDistinct(ctx.AllSelectSpec())
+	qs.SelectElements = base.Accept[*sqlstmt.SelectElements](v, ctx.SelectElements())
 
 	if fromClause := ctx.FromClause(); fromClause != nil {
 		where := fromClause.GetWhereExpr()
@@ -209,28 +703,66 @@ func (v *MysqlVisitor) VisitQuerySpecificationNointo(ctx *mysqlparser.QuerySpeci
 		if tableSourcesCtx != nil {
 			tss := new(sqlstmt.TableSources)
 			tss.Node = sqlstmt.NewNode(tableSourcesCtx.GetParser(), tableSourcesCtx)
-			tss.TableSources = tableSourcesCtx.Accept(v).([]sqlstmt.ITableSource)
+			tss.TableSources = base.Accept[[]sqlstmt.ITableSource](v, tableSourcesCtx)
 			qs.From = tss
 		}
 	}
 
+	if gbc := ctx.GroupByClause(); gbc != nil {
+		for _, gbic := range gbc.AllGroupByItem() {
+			qs.GroupBy = append(qs.GroupBy, v.GetExpr(gbic.Expression()))
+		}
+	}
+	if hc := ctx.HavingClause(); hc != nil {
+		qs.Having = v.GetExpr(hc.GetHavingExpr())
+	}
+	if obc := ctx.OrderByClause(); obc != nil {
+		qs.OrderBy = base.Accept[[]*sqlstmt.OrderByExpr](v, obc)
+	}
+
 	if limitClause := ctx.LimitClause(); limitClause != nil {
-		qs.Limit = limitClause.Accept(v).(*sqlstmt.Limit)
+		qs.Limit = base.Accept[*sqlstmt.Limit](v, limitClause)
 	}
 
 	return qs
 }
 
+func (v *MysqlVisitor) VisitOrderByClause(ctx *mysqlparser.OrderByClauseContext) interface{} {
+	orderBy := make([]*sqlstmt.OrderByExpr, 0)
+	for _, obec := range ctx.AllOrderByExpression() {
+		orderBy = append(orderBy, base.Accept[*sqlstmt.OrderByExpr](v, obec))
+	}
+	return orderBy
+}
+
+func (v *MysqlVisitor) VisitOrderByExpression(ctx *mysqlparser.OrderByExpressionContext) interface{} {
+	obe := new(sqlstmt.OrderByExpr)
+	obe.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	obe.Expr = v.GetExpr(ctx.Expression())
+	obe.Desc = ctx.DESC() != nil
+	return obe
+}
+
+// isDistinct 查询是否去重
+func isDistinct(selectSpecs []mysqlparser.ISelectSpecContext) bool {
+	for _, ssc := range selectSpecs {
+		if ssc.DISTINCT() != nil || ssc.DISTINCTROW() != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func (v *MysqlVisitor) VisitQueryExpression(ctx *mysqlparser.QueryExpressionContext) interface{} {
 	qe := new(sqlstmt.QueryExpr)
 	qe.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
 	if qec := ctx.QueryExpression(); qec != nil {
-		qe.QueryExpr = qec.Accept(v).(*sqlstmt.QueryExpr)
+		qe.QueryExpr = base.Accept[*sqlstmt.QueryExpr](v, qec)
 	}
 
 	if qsc := ctx.QuerySpecification(); qsc != nil {
-		qe.QuerySpecification = qsc.Accept(v).(*sqlstmt.QuerySpecification)
+		qe.QuerySpecification = base.Accept[*sqlstmt.QuerySpecification](v, qsc)
 	}
 
 	return qe
@@ -241,11 +773,11 @@ func (v *MysqlVisitor) VisitQueryExpressionNointo(ctx *mysqlparser.QueryExpressi
 	qe.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
 	if qec := ctx.QueryExpressionNointo(); qec != nil {
-		qe.QueryExpr = qec.Accept(v).(*sqlstmt.QueryExpr)
+		qe.QueryExpr = base.Accept[*sqlstmt.QueryExpr](v, qec)
 	}
 
 	if qsc := ctx.QuerySpecificationNointo(); qsc != nil {
-		qe.QuerySpecification = qsc.Accept(v).(*sqlstmt.QuerySpecification)
+		qe.QuerySpecification = base.Accept[*sqlstmt.QuerySpecification](v, qsc)
 	}
 
 	return qe
@@ -262,7 +794,7 @@ func (v *MysqlVisitor) VisitSelectElements(ctx *mysqlparser.SelectElementsContex
 	eles := make([]sqlstmt.ISelectElement, 0)
 	ase := ctx.AllSelectElement()
 	for _, selectElement := range ase {
-		eles = append(eles, selectElement.Accept(v).(sqlstmt.ISelectElement))
+		eles = append(eles, base.Accept[sqlstmt.ISelectElement](v, selectElement))
 	}
 	ses.Elements = eles
 
@@ -279,7 +811,7 @@ func (v *MysqlVisitor) VisitSelectStarElement(ctx *mysqlparser.SelectStarElement
 func (v *MysqlVisitor) VisitSelectColumnElement(ctx *mysqlparser.SelectColumnElementContext) interface{} {
 	sce := new(sqlstmt.SelectColumnElement)
 	sce.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	sce.FullColumnName = ctx.FullColumnName().Accept(v).(*sqlstmt.ColumnName)
+	sce.FullColumnName = base.Accept[*sqlstmt.ColumnName](v, ctx.FullColumnName())
 	if uid := ctx.Uid(); uid != nil {
 		sce.Alias = uid.GetText()
 	}
@@ -287,20 +819,31 @@ func (v *MysqlVisitor) VisitSelectColumnElement(ctx *mysqlparser.SelectColumnEle
 }
 
 func (v *MysqlVisitor) VisitSelectFunctionElement(ctx *mysqlparser.SelectFunctionElementContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	sfe := new(sqlstmt.SelectFunctionElement)
+	sfe.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	if uid := ctx.Uid(); uid != nil {
+		sfe.Alias = uid.GetText()
+	}
+	sfe.Subqueries = v.subqueries(ctx.FunctionCall())
+	sfe.Columns = v.columns(ctx.FunctionCall())
+	return sfe
 }
 
 func (v *MysqlVisitor) VisitSelectExpressionElement(ctx *mysqlparser.SelectExpressionElementContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	see := new(sqlstmt.SelectExpressionElement)
+	see.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	see.Expr = v.GetExpr(ctx.Expression())
+	if uid := ctx.Uid(); uid != nil {
+		see.Alias = uid.GetText()
+	}
+	return see
 }
 
 func (v *MysqlVisitor) VisitTableSources(ctx *mysqlparser.TableSourcesContext) interface{} {
 	tableSourcesCtx := ctx.AllTableSource()
 	tableSources := make([]sqlstmt.ITableSource, 0)
 	for _, tableSourceCtx := range tableSourcesCtx {
-		tableSources = append(tableSources, tableSourceCtx.Accept(v).(sqlstmt.ITableSource))
+		tableSources = append(tableSources, base.Accept[sqlstmt.ITableSource](v, tableSourceCtx))
 	}
 	return tableSources
 }
@@ -313,10 +856,51 @@ func (v *MysqlVisitor) VisitTableSourceBase(ctx *mysqlparser.TableSourceBaseCont
 	return tsb
 }
 
+func (v *MysqlVisitor) VisitTableSourceNested(ctx *mysqlparser.TableSourceNestedContext) interface{} {
+	tsb := new(sqlstmt.TableSourceBase)
+	tsb.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	tsb.TableSourceItem = v.GetTableSourceItem(ctx.TableSourceItem())
+	tsb.JoinParts = v.GetJoinParts(ctx.AllJoinPart())
+	return tsb
+}
+
+func (v *MysqlVisitor) VisitTableJson(ctx *mysqlparser.TableJsonContext) interface{} {
+	ts := new(sqlstmt.TableSource)
+	ts.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	return ts
+}
+
+func (v *MysqlVisitor) VisitSubqueryTableItem(ctx *mysqlparser.SubqueryTableItemContext) interface{} {
+	sti := new(sqlstmt.SubqueryTableItem)
+	sti.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	if ssc := ctx.SelectStatement(); ssc != nil {
+		sti.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, ssc)
+	}
+	if psc := ctx.GetParenthesisSubquery(); psc != nil {
+		sti.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, psc)
+	}
+	if alias := ctx.GetAlias(); alias != nil {
+		sti.Alias = alias.GetText()
+	}
+	return sti
+}
+
+func (v *MysqlVisitor) VisitTableSourcesItem(ctx *mysqlparser.TableSourcesItemContext) interface{} {
+	tsi := new(sqlstmt.TableSourcesItem)
+	tsi.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	if tssc := ctx.TableSources(); tssc != nil {
+		tss := new(sqlstmt.TableSources)
+		tss.Node = sqlstmt.NewNode(tssc.GetParser(), tssc)
+		tss.TableSources = base.Accept[[]sqlstmt.ITableSource](v, tssc)
+		tsi.TableSources = tss
+	}
+	return tsi
+}
+
 func (v *MysqlVisitor) VisitAtomTableItem(ctx *mysqlparser.AtomTableItemContext) interface{} {
 	tableSourceItem := new(sqlstmt.AtomTableItem)
 	tableSourceItem.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	tableSourceItem.TableName = ctx.TableName().Accept(v).(*sqlstmt.TableName)
+	tableSourceItem.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
 
 	if alias := ctx.GetAlias(); alias != nil {
 		tableSourceItem.Alias = alias.GetText()
@@ -329,6 +913,7 @@ func (v *MysqlVisitor) VisitInnerJoin(ctx *mysqlparser.InnerJoinContext) interfa
 	ij := new(sqlstmt.InnerJoin)
 	ij.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 	ij.TableSourceItem = v.GetTableSourceItem(ctx.TableSourceItem())
+	v.setJoinSpecs(&ij.JoinPart, ctx.AllJoinSpec())
 	return ij
 }
 
@@ -336,36 +921,78 @@ func (v *MysqlVisitor) VisitStraightJoin(ctx *mysqlparser.StraightJoinContext) i
 	jp := new(sqlstmt.JoinPart)
 	jp.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 	jp.TableSourceItem = v.GetTableSourceItem(ctx.TableSourceItem())
+	jp.On = v.andExprs(ctx.AllExpression())
 	return jp
 }
 
 func (v *MysqlVisitor) VisitOuterJoin(ctx *mysqlparser.OuterJoinContext) interface{} {
 	oj := new(sqlstmt.OuterJoin)
 	oj.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	oj.Type = "RIGHT"
+	if ctx.LEFT() != nil {
+		oj.Type = "LEFT"
+	}
 	oj.TableSourceItem = v.GetTableSourceItem(ctx.TableSourceItem())
+	v.setJoinSpecs(&oj.JoinPart, ctx.AllJoinSpec())
 	return oj
 }
 
 func (v *MysqlVisitor) VisitNaturalJoin(ctx *mysqlparser.NaturalJoinContext) interface{} {
 	nj := new(sqlstmt.NaturalJoin)
 	nj.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	if ctx.LEFT() != nil {
+		nj.Type = "LEFT"
+	} else if ctx.RIGHT() != nil {
+		nj.Type = "RIGHT"
+	}
 	nj.TableSourceItem = v.GetTableSourceItem(ctx.TableSourceItem())
 	return nj
 }
 
-func (v *MysqlVisitor) VisitJoinSpec(ctx *mysqlparser.JoinSpecContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+// setJoinSpecs 设置join的on条件及using中的列，存在多个on条件时以and连接
+func (v *MysqlVisitor) setJoinSpecs(jp *sqlstmt.JoinPart, ctxs []mysqlparser.IJoinSpecContext) {
+	var ons []mysqlparser.IExpressionContext
+	for _, jsc := range ctxs {
+		if ec := jsc.Expression(); ec != nil {
+			ons = append(ons, ec)
+		}
+		if ulc := jsc.UidList(); ulc != nil {
+			for _, uid := range ulc.AllUid() {
+				jp.Using = append(jp.Using, uid.GetText())
+			}
+		}
+	}
+	jp.On = v.andExprs(ons)
+}
+
+// andExprs 多个条件以and连接，仅一个条件时直接返回该条件
+func (v *MysqlVisitor) andExprs(ctxs []mysqlparser.IExpressionContext) sqlstmt.IExpr {
+	switch len(ctxs) {
+	case 0:
+		return nil
+	case 1:
+		return v.GetExpr(ctxs[0])
+	}
+	le := new(sqlstmt.LogicalExpr)
+	le.Operator = "and"
+	le.Exprs = v.GetExprs(ctxs)
+	return le
 }
 
 func (v *MysqlVisitor) VisitIsExpression(ctx *mysqlparser.IsExpressionContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	ie := new(sqlstmt.IsExpr)
+	ie.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	ie.Predicate = base.Accept[sqlstmt.IPredicate](v, ctx.Predicate())
+	ie.Not = ctx.NOT() != nil
+	ie.TestValue = strings.ToUpper(ctx.GetTestValue().GetText())
+	return ie
 }
 
 func (v *MysqlVisitor) VisitNotExpression(ctx *mysqlparser.NotExpressionContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	ne := new(sqlstmt.NotExpr)
+	ne.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	ne.Operand = v.GetExpr(ctx.Expression())
+	return ne
 }
 
 func (v *MysqlVisitor) VisitLogicalExpression(ctx *mysqlparser.LogicalExpressionContext) interface{} {
@@ -377,16 +1004,43 @@ func (v *MysqlVisitor) VisitLogicalExpression(ctx *mysqlparser.LogicalExpression
 }
 
 func (v *MysqlVisitor) VisitPredicateExpression(ctx *mysqlparser.PredicateExpressionContext) interface{} {
-	return ctx.Predicate().Accept(v)
+	pe := new(sqlstmt.PredicateExpr)
+	pe.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	pe.Predicate = base.Accept[sqlstmt.IPredicate](v, ctx.Predicate())
+	return pe
+}
+
+func (v *MysqlVisitor) VisitSubqueryComparisonPredicate(ctx *mysqlparser.SubqueryComparisonPredicateContext) interface{} {
+	scp := new(sqlstmt.SubqueryComparisonPredicate)
+	scp.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	scp.Predicate = base.Accept[sqlstmt.IPredicate](v, ctx.Predicate())
+	scp.ComparisonOperator = base.Accept[string](v, ctx.ComparisonOperator())
+	scp.Quantifier = strings.ToUpper(ctx.GetQuantifier().GetText())
+	scp.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, ctx.SelectStatement())
+	return scp
+}
+
+func (v *MysqlVisitor) VisitJsonMemberOfPredicate(ctx *mysqlparser.JsonMemberOfPredicateContext) interface{} {
+	predicate := new(sqlstmt.Predicate)
+	predicate.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	predicate.Subqueries = v.subqueries(ctx)
+	predicate.Columns = v.columns(ctx)
+	return predicate
 }
 
 func (v *MysqlVisitor) VisitSoundsLikePredicate(ctx *mysqlparser.SoundsLikePredicateContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	predicate := new(sqlstmt.Predicate)
+	predicate.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	predicate.Subqueries = v.subqueries(ctx)
+	predicate.Columns = v.columns(ctx)
+	return predicate
 }
 
 func (v *MysqlVisitor) VisitExpressionAtomPredicate(ctx *mysqlparser.ExpressionAtomPredicateContext) interface{} {
-	return ctx.ExpressionAtom().Accept(v).(sqlstmt.IExprAtom)
+	eap := new(sqlstmt.ExprAtomPredicate)
+	eap.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	eap.ExprAtom = base.Accept[sqlstmt.IExprAtom](v, ctx.ExpressionAtom())
+	return eap
 }
 
 func (v *MysqlVisitor) VisitLogicalOperator(ctx *mysqlparser.LogicalOperatorContext) interface{} {
@@ -396,9 +1050,9 @@ func (v *MysqlVisitor) VisitLogicalOperator(ctx *mysqlparser.LogicalOperatorCont
 func (v *MysqlVisitor) VisitBinaryComparisonPredicate(ctx *mysqlparser.BinaryComparisonPredicateContext) interface{} {
 	bcp := new(sqlstmt.BinaryComparisonPredicate)
 	bcp.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	bcp.Left = ctx.GetLeft().Accept(v).(sqlstmt.IPredicate)
-	bcp.Right = ctx.GetRight().Accept(v).(sqlstmt.IPredicate)
-	bcp.ComparisonOperator = ctx.ComparisonOperator().Accept(v).(string)
+	bcp.Left = base.Accept[sqlstmt.IPredicate](v, ctx.GetLeft())
+	bcp.Right = base.Accept[sqlstmt.IPredicate](v, ctx.GetRight())
+	bcp.ComparisonOperator = base.Accept[string](v, ctx.ComparisonOperator())
 	return bcp
 }
 
@@ -406,10 +1060,11 @@ func (v *MysqlVisitor) VisitInPredicate(ctx *mysqlparser.InPredicateContext) int
 	inPredicate := new(sqlstmt.InPredicate)
 	inPredicate.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 	if pc := ctx.Predicate(); pc != nil {
-		inPredicate.Predicate = pc.Accept(v).(sqlstmt.IPredicate)
+		inPredicate.Predicate = base.Accept[sqlstmt.IPredicate](v, pc)
 	}
+	inPredicate.Not = ctx.NOT() != nil
 	if ssc := ctx.SelectStatement(); ssc != nil {
-		inPredicate.SelectStmt = ssc.Accept(v).(sqlstmt.ISelectStmt)
+		inPredicate.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, ssc)
 	}
 	if ec := ctx.Expressions(); ec != nil {
 		inPredicate.Exprs = v.GetExprs(ec.AllExpression())
@@ -419,104 +1074,175 @@ func (v *MysqlVisitor) VisitInPredicate(ctx *mysqlparser.InPredicateContext) int
 }
 
 func (v *MysqlVisitor) VisitBetweenPredicate(ctx *mysqlparser.BetweenPredicateContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	bp := new(sqlstmt.BetweenPredicate)
+	bp.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	predicates := ctx.AllPredicate()
+	bp.Predicate = base.Accept[sqlstmt.IPredicate](v, predicates[0])
+	bp.Not = ctx.NOT() != nil
+	bp.Low = base.Accept[sqlstmt.IPredicate](v, predicates[1])
+	bp.High = base.Accept[sqlstmt.IPredicate](v, predicates[2])
+	return bp
 }
 
 func (v *MysqlVisitor) VisitIsNullPredicate(ctx *mysqlparser.IsNullPredicateContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	inp := new(sqlstmt.IsNullPredicate)
+	inp.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	inp.Predicate = base.Accept[sqlstmt.IPredicate](v, ctx.Predicate())
+	inp.Not = ctx.NullNotnull().NOT() != nil
+	return inp
 }
 
 func (v *MysqlVisitor) VisitLikePredicate(ctx *mysqlparser.LikePredicateContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	lp := new(sqlstmt.LikePredicate)
+	lp.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	predicates := ctx.AllPredicate()
+	lp.Predicate = base.Accept[sqlstmt.IPredicate](v, predicates[0])
+	lp.Not = ctx.NOT() != nil
+	lp.Pattern = base.Accept[sqlstmt.IPredicate](v, predicates[1])
+	if escape := ctx.STRING_LITERAL(); escape != nil {
+		lp.Escape = escape.GetText()
+	}
+	return lp
 }
 
 func (v *MysqlVisitor) VisitRegexpPredicate(ctx *mysqlparser.RegexpPredicateContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	rp := new(sqlstmt.RegexpPredicate)
+	rp.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	predicates := ctx.AllPredicate()
+	rp.Predicate = base.Accept[sqlstmt.IPredicate](v, predicates[0])
+	rp.Not = ctx.NOT() != nil
+	rp.Operator = strings.ToUpper(ctx.GetRegex().GetText())
+	rp.Pattern = base.Accept[sqlstmt.IPredicate](v, predicates[1])
+	return rp
 }
 
 func (v *MysqlVisitor) VisitUnaryExpressionAtom(ctx *mysqlparser.UnaryExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	unary := new(sqlstmt.ExprAtomUnary)
+	unary.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	unary.Operator = strings.ToUpper(ctx.UnaryOperator().GetText())
+	unary.Operand = base.Accept[sqlstmt.IExprAtom](v, ctx.ExpressionAtom())
+	return unary
 }
 
 func (v *MysqlVisitor) VisitCollateExpressionAtom(ctx *mysqlparser.CollateExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	exprAtom := new(sqlstmt.ExprAtom)
+	exprAtom.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	exprAtom.Subqueries = v.subqueries(ctx)
+	exprAtom.Columns = v.columns(ctx)
+	return exprAtom
 }
 
 func (v *MysqlVisitor) VisitVariableAssignExpressionAtom(ctx *mysqlparser.VariableAssignExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	exprAtom := new(sqlstmt.ExprAtom)
+	exprAtom.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	exprAtom.Subqueries = v.subqueries(ctx)
+	exprAtom.Columns = v.columns(ctx)
+	return exprAtom
 }
 
 func (v *MysqlVisitor) VisitMysqlVariableExpressionAtom(ctx *mysqlparser.MysqlVariableExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	exprAtom := new(sqlstmt.ExprAtom)
+	exprAtom.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	exprAtom.Subqueries = v.subqueries(ctx)
+	exprAtom.Columns = v.columns(ctx)
+	return exprAtom
 }
 
 func (v *MysqlVisitor) VisitNestedExpressionAtom(ctx *mysqlparser.NestedExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	nested := new(sqlstmt.ExprAtomNested)
+	nested.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	nested.Exprs = v.GetExprs(ctx.AllExpression())
+	return nested
 }
 
 func (v *MysqlVisitor) VisitNestedRowExpressionAtom(ctx *mysqlparser.NestedRowExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	nested := new(sqlstmt.ExprAtomNested)
+	nested.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	nested.Row = true
+	nested.Exprs = v.GetExprs(ctx.AllExpression())
+	return nested
 }
 
 func (v *MysqlVisitor) VisitMathExpressionAtom(ctx *mysqlparser.MathExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	arithmetic := new(sqlstmt.ExprAtomArithmetic)
+	arithmetic.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	arithmetic.Left = base.Accept[sqlstmt.IExprAtom](v, ctx.GetLeft())
+	if oc := ctx.MultOperator(); oc != nil {
+		arithmetic.Operator = strings.ToUpper(oc.GetText())
+	} else {
+		arithmetic.Operator = ctx.AddOperator().GetText()
+	}
+	arithmetic.Right = base.Accept[sqlstmt.IExprAtom](v, ctx.GetRight())
+	return arithmetic
 }
 
 func (v *MysqlVisitor) VisitExistsExpressionAtom(ctx *mysqlparser.ExistsExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	exists := new(sqlstmt.ExprAtomExists)
+	exists.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	exists.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, ctx.SelectStatement())
+	return exists
 }
 
 func (v *MysqlVisitor) VisitIntervalExpressionAtom(ctx *mysqlparser.IntervalExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	exprAtom := new(sqlstmt.ExprAtom)
+	exprAtom.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	exprAtom.Subqueries = v.subqueries(ctx)
+	exprAtom.Columns = v.columns(ctx)
+	return exprAtom
 }
 
 func (v *MysqlVisitor) VisitJsonExpressionAtom(ctx *mysqlparser.JsonExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	exprAtom := new(sqlstmt.ExprAtom)
+	exprAtom.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	exprAtom.Subqueries = v.subqueries(ctx)
+	exprAtom.Columns = v.columns(ctx)
+	return exprAtom
 }
 
 func (v *MysqlVisitor) VisitSubqueryExpressionAtom(ctx *mysqlparser.SubqueryExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	subquery := new(sqlstmt.ExprAtomSubquery)
+	subquery.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	subquery.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, ctx.SelectStatement())
+	return subquery
 }
 
 func (v *MysqlVisitor) VisitConstantExpressionAtom(ctx *mysqlparser.ConstantExpressionAtomContext) interface{} {
 	constExprAtom := new(sqlstmt.ExprAtomConstant)
 	constExprAtom.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	constExprAtom.Constant = ctx.Constant().Accept(v).(*sqlstmt.Constant)
+	constExprAtom.Constant = base.Accept[*sqlstmt.Constant](v, ctx.Constant())
 	return constExprAtom
 }
 
 func (v *MysqlVisitor) VisitFunctionCallExpressionAtom(ctx *mysqlparser.FunctionCallExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	exprAtom := new(sqlstmt.ExprAtom)
+	exprAtom.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	exprAtom.Subqueries = v.subqueries(ctx)
+	exprAtom.Columns = v.columns(ctx)
+	return exprAtom
 }
 
 func (v *MysqlVisitor) VisitBinaryExpressionAtom(ctx *mysqlparser.BinaryExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	exprAtom := new(sqlstmt.ExprAtom)
+	exprAtom.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	exprAtom.Subqueries = v.subqueries(ctx)
+	exprAtom.Columns = v.columns(ctx)
+	return exprAtom
 }
 
 func (v *MysqlVisitor) VisitFullColumnNameExpressionAtom(ctx *mysqlparser.FullColumnNameExpressionAtomContext) interface{} {
-	return ctx.FullColumnName().Accept(v)
+	columnExprAtom := new(sqlstmt.ExprAtomColumnName)
+	columnExprAtom.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	columnExprAtom.ColumnName = base.Accept[*sqlstmt.ColumnName](v, ctx.FullColumnName())
+	return columnExprAtom
 }
 
 func (v *MysqlVisitor) VisitBitExpressionAtom(ctx *mysqlparser.BitExpressionAtomContext) interface{} {
-	node := sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return node
+	arithmetic := new(sqlstmt.ExprAtomArithmetic)
+	arithmetic.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	arithmetic.Left = base.Accept[sqlstmt.IExprAtom](v, ctx.GetLeft())
+	arithmetic.Operator = ctx.BitOperator().GetText()
+	arithmetic.Right = base.Accept[sqlstmt.IExprAtom](v, ctx.GetRight())
+	return arithmetic
 }
 
 func (v *MysqlVisitor) VisitComparisonOperator(ctx *mysqlparser.ComparisonOperatorContext) interface{} {
@@ -524,17 +1250,21 @@ func (v *MysqlVisitor) VisitComparisonOperator(ctx *mysqlparser.ComparisonOperat
 }
 
 func (v *MysqlVisitor) VisitTableName(ctx *mysqlparser.TableNameContext) interface{} {
-	tableName := new(sqlstmt.TableName)
+	tableName := v.getTableName(base.Accept[*sqlstmt.FullId](v, ctx.FullId()))
 	tableName.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	fullId := ctx.FullId().Accept(v).(*sqlstmt.FullId)
+	return tableName
+}
 
+// getTableName 视图名等以fullId表示的表名
+func (v *MysqlVisitor) getTableName(fullId *sqlstmt.FullId) *sqlstmt.TableName {
+	tableName := new(sqlstmt.TableName)
+	tableName.Node = fullId.Node
 	if uids := fullId.Uids; len(uids) == 1 {
 		tableName.Identifier = sqlstmt.NewIdentifierValue(uids[0])
 	} else {
 		tableName.Owner = uids[0]
 		tableName.Identifier = sqlstmt.NewIdentifierValue(uids[1])
 	}
-
 	return tableName
 }
 
@@ -565,14 +1295,16 @@ func (v *MysqlVisitor) VisitFullColumnName(ctx *mysqlparser.FullColumnNameContex
 	fullColumnName := new(sqlstmt.ColumnName)
 	fullColumnName.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
-	adis := ctx.AllDottedId()
-	// 不存在.则直接取标识符
-	if len(adis) == 0 {
-		fullColumnName.Identifier = sqlstmt.NewIdentifierValue(ctx.Uid().GetText())
-	} else {
-		fullColumnName.Owner = ctx.Uid().GetText()
-		fullColumnName.Identifier = sqlstmt.NewIdentifierValue(adis[0].GetText())
+	names := make([]string, 0)
+	if uid := ctx.Uid(); uid != nil {
+		names = append(names, uid.GetText())
+	}
+	for _, di := range ctx.AllDottedId() {
+		names = append(names, strings.TrimPrefix(di.GetText(), "."))
 	}
+	// 最后一个为列名，之前的为[库名.]表名
+	fullColumnName.Owner = strings.Join(names[:len(names)-1], ".")
+	fullColumnName.Identifier = sqlstmt.NewIdentifierValue(names[len(names)-1])
 
 	return fullColumnName
 }
@@ -586,6 +1318,7 @@ func (v *MysqlVisitor) VisitConstant(ctx *mysqlparser.ConstantContext) interface
 	constant := new(sqlstmt.Constant)
 	constant.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 	constant.Value = ctx.GetText()
+	constant.Arg, _ = constantValue(ctx)
 	return constant
 }
 
@@ -605,10 +1338,84 @@ func (v *MysqlVisitor) VisitLimitClause(ctx *mysqlparser.LimitClauseContext) int
 func (v *MysqlVisitor) VisitInsertStatement(ctx *mysqlparser.InsertStatementContext) interface{} {
 	is := new(sqlstmt.InsertStmt)
 	is.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	is.TableName = ctx.TableName().Accept(v).(*sqlstmt.TableName)
+	is.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
+	if cc := ctx.GetColumns(); cc != nil {
+		for _, fcc := range cc.AllFullColumnName() {
+			is.Columns = append(is.Columns, base.Accept[*sqlstmt.ColumnName](v, fcc))
+		}
+	}
+	if isvc := ctx.InsertStatementValue(); isvc != nil {
+		is.Values = v.valuesRows(isvc.GetParser(), isvc)
+		is.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, isvc.SelectStatement())
+	}
+	is.SetElements = v.updatedElements(ctx.GetSetFirst(), ctx.GetSetElements())
+	is.DuplicatedElements = v.updatedElements(ctx.GetDuplicatedFirst(), ctx.GetDuplicatedElements())
 	return is
 }
 
+func (v *MysqlVisitor) VisitReplaceStatement(ctx *mysqlparser.ReplaceStatementContext) interface{} {
+	rs := new(sqlstmt.ReplaceStmt)
+	rs.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	rs.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
+	if cc := ctx.GetColumns(); cc != nil {
+		for _, uc := range cc.AllUid() {
+			columnName := new(sqlstmt.ColumnName)
+			columnName.Node = sqlstmt.NewNode(ctx.GetParser(), uc)
+			columnName.Identifier = sqlstmt.NewIdentifierValue(uc.GetText())
+			rs.Columns = append(rs.Columns, columnName)
+		}
+	}
+	if isvc := ctx.InsertStatementValue(); isvc != nil {
+		rs.Values = v.valuesRows(isvc.GetParser(), isvc)
+		rs.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, isvc.SelectStatement())
+	}
+	rs.SetElements = v.updatedElements(ctx.GetSetFirst(), ctx.GetSetElements())
+	return rs
+}
+
+// valuesRows insert ... values及values语句中的各行，行在语法中无对应的规则，以两侧的括号为起止
+func (v *MysqlVisitor) valuesRows(parser antlr.Parser, ctx antlr.ParserRuleContext) []*sqlstmt.ValuesRow {
+	if isvc, ok := ctx.(mysqlparser.IInsertStatementValueContext); ok && isvc.SelectStatement() != nil {
+		return nil
+	}
+	rows := make([]*sqlstmt.ValuesRow, 0)
+	var lb antlr.Token
+	var ewc mysqlparser.IExpressionsWithDefaultsContext
+	for _, child := range ctx.GetChildren() {
+		switch c := child.(type) {
+		case mysqlparser.IExpressionsWithDefaultsContext:
+			ewc = c
+		case antlr.TerminalNode:
+			switch c.GetSymbol().GetTokenType() {
+			case mysqlparser.MySqlParserLR_BRACKET:
+				lb, ewc = c.GetSymbol(), nil
+			case mysqlparser.MySqlParserRR_BRACKET:
+				row := new(sqlstmt.ValuesRow)
+				row.Node = sqlstmt.NewNode(parser, spanContext(ctx, lb, c.GetSymbol()))
+				if ewc != nil {
+					for _, eodc := range ewc.AllExpressionOrDefault() {
+						row.Exprs = append(row.Exprs, v.exprOrDefault(eodc.Expression()))
+					}
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows
+}
+
+// updatedElements insert ... set、on duplicate key update中以first开头的赋值列表
+func (v *MysqlVisitor) updatedElements(first mysqlparser.IUpdatedElementContext, rest []mysqlparser.IUpdatedElementContext) []*sqlstmt.UpdatedElement {
+	if first == nil {
+		return nil
+	}
+	ues := []*sqlstmt.UpdatedElement{base.Accept[*sqlstmt.UpdatedElement](v, first)}
+	for _, uec := range rest {
+		ues = append(ues, base.Accept[*sqlstmt.UpdatedElement](v, uec))
+	}
+	return ues
+}
+
 func (v *MysqlVisitor) VisitUpdateStatement(ctx *mysqlparser.UpdateStatementContext) interface{} {
 	if sus := ctx.SingleUpdateStatement(); sus != nil {
 		return sus.Accept(v)
@@ -626,10 +1433,16 @@ func (v *MysqlVisitor) VisitSingleUpdateStatement(ctx *mysqlparser.SingleUpdateS
 	sus := new(sqlstmt.UpdateStmt)
 	sus.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
+	// 表名及别名
+	tableCtx := antlr.ParserRuleContext(ctx.TableName())
+	if uid := ctx.Uid(); uid != nil {
+		tableCtx = spanContext(ctx, ctx.TableName().GetStart(), uid.GetStop())
+	}
 	tss := new(sqlstmt.TableSources)
-	tss.Node = sqlstmt.NewNode(ctx.TableName().GetParser(), ctx.TableName())
+	tss.Node = sqlstmt.NewNode(ctx.GetParser(), tableCtx)
 	atomTable := new(sqlstmt.AtomTableItem)
-	atomTable.TableName = ctx.TableName().Accept(v).(*sqlstmt.TableName)
+	atomTable.Node = tss.Node
+	atomTable.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
 	if uid := ctx.Uid(); uid != nil {
 		atomTable.Alias = uid.GetText()
 	}
@@ -644,7 +1457,7 @@ func (v *MysqlVisitor) VisitSingleUpdateStatement(ctx *mysqlparser.SingleUpdateS
 	if aucs := ctx.AllUpdatedElement(); aucs != nil {
 		ues := make([]*sqlstmt.UpdatedElement, 0)
 		for _, auc := range aucs {
-			ues = append(ues, auc.Accept(v).(*sqlstmt.UpdatedElement))
+			ues = append(ues, base.Accept[*sqlstmt.UpdatedElement](v, auc))
 		}
 		sus.UpdatedElements = ues
 	}
@@ -663,14 +1476,14 @@ func (v *MysqlVisitor) VisitMultipleUpdateStatement(ctx *mysqlparser.MultipleUpd
 	if tssc := ctx.TableSources(); tssc != nil {
 		tss := new(sqlstmt.TableSources)
 		tss.Node = sqlstmt.NewNode(tssc.GetParser(), tssc)
-		tss.TableSources = tssc.Accept(v).([]sqlstmt.ITableSource)
+		tss.TableSources = base.Accept[[]sqlstmt.ITableSource](v, tssc)
 		mus.TableSources = tss
 	}
 
 	if aucs := ctx.AllUpdatedElement(); aucs != nil {
 		ues := make([]*sqlstmt.UpdatedElement, 0)
 		for _, auc := range aucs {
-			ues = append(ues, auc.Accept(v).(*sqlstmt.UpdatedElement))
+			ues = append(ues, base.Accept[*sqlstmt.UpdatedElement](v, auc))
 		}
 		mus.UpdatedElements = ues
 	}
@@ -685,11 +1498,19 @@ func (v *MysqlVisitor) VisitMultipleUpdateStatement(ctx *mysqlparser.MultipleUpd
 func (v *MysqlVisitor) VisitUpdatedElement(ctx *mysqlparser.UpdatedElementContext) interface{} {
 	ue := new(sqlstmt.UpdatedElement)
 	ue.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	ue.ColumnName = ctx.FullColumnName().Accept(v).(*sqlstmt.ColumnName)
-	ue.Value = v.GetExpr(ctx.Expression())
+	ue.ColumnName = base.Accept[*sqlstmt.ColumnName](v, ctx.FullColumnName())
+	ue.Value = v.exprOrDefault(ctx.Expression())
 	return ue
 }
 
+// exprOrDefault default可作为标识符，会被解析为列名，为default时返回nil
+func (v *MysqlVisitor) exprOrDefault(ctx mysqlparser.IExpressionContext) sqlstmt.IExpr {
+	if ctx == nil || strings.EqualFold(ctx.GetText(), "default") {
+		return nil
+	}
+	return v.GetExpr(ctx)
+}
+
 func (v *MysqlVisitor) VisitDeleteStatement(ctx *mysqlparser.DeleteStatementContext) interface{} {
 	if sus := ctx.SingleDeleteStatement(); sus != nil {
 		return sus.Accept(v)
@@ -707,10 +1528,18 @@ func (v *MysqlVisitor) VisitSingleDeleteStatement(ctx *mysqlparser.SingleDeleteS
 	ds := new(sqlstmt.DeleteStmt)
 	ds.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
+	// 表名、别名及分区
+	tableCtx := antlr.ParserRuleContext(ctx.TableName())
+	if rb := ctx.RR_BRACKET(); rb != nil {
+		tableCtx = spanContext(ctx, ctx.TableName().GetStart(), rb.GetSymbol())
+	} else if uid := ctx.Uid(); uid != nil {
+		tableCtx = spanContext(ctx, ctx.TableName().GetStart(), uid.GetStop())
+	}
 	tss := new(sqlstmt.TableSources)
-	tss.Node = sqlstmt.NewNode(ctx.TableName().GetParser(), ctx.TableName())
+	tss.Node = sqlstmt.NewNode(ctx.GetParser(), tableCtx)
 	atomTable := new(sqlstmt.AtomTableItem)
-	atomTable.TableName = ctx.TableName().Accept(v).(*sqlstmt.TableName)
+	atomTable.Node = tss.Node
+	atomTable.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
 	if uid := ctx.Uid(); uid != nil {
 		atomTable.Alias = uid.GetText()
 	}
@@ -733,10 +1562,14 @@ func (v *MysqlVisitor) VisitMultipleDeleteStatement(ctx *mysqlparser.MultipleDel
 	ds := new(sqlstmt.DeleteStmt)
 	ds.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
+	for _, tnc := range ctx.AllTableName() {
+		ds.Targets = append(ds.Targets, base.Accept[*sqlstmt.TableName](v, tnc))
+	}
+
 	if tssc := ctx.TableSources(); tssc != nil {
 		tss := new(sqlstmt.TableSources)
 		tss.Node = sqlstmt.NewNode(tssc.GetParser(), tssc)
-		tss.TableSources = tssc.Accept(v).([]sqlstmt.ITableSource)
+		tss.TableSources = base.Accept[[]sqlstmt.ITableSource](v, tssc)
 		ds.TableSources = tss
 	}
 
@@ -747,137 +1580,171 @@ func (v *MysqlVisitor) VisitMultipleDeleteStatement(ctx *mysqlparser.MultipleDel
 	return ds
 }
 
+// VisitSimpleDescribeStatement describe table等同于show columns
 func (v *MysqlVisitor) VisitSimpleDescribeStatement(ctx *mysqlparser.SimpleDescribeStatementContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+	show := new(sqlstmt.ShowStmt)
+	show.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	return show
 }
 
 func (v *MysqlVisitor) VisitFullDescribeStatement(ctx *mysqlparser.FullDescribeStatementContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
-}
-
-func (v *MysqlVisitor) VisitShowMasterLogs(ctx *mysqlparser.ShowMasterLogsContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
-}
-
-func (v *MysqlVisitor) VisitShowLogEvents(ctx *mysqlparser.ShowLogEventsContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
-}
-
-func (v *MysqlVisitor) VisitShowObjectFilter(ctx *mysqlparser.ShowObjectFilterContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
-}
-
-func (v *MysqlVisitor) VisitShowColumns(ctx *mysqlparser.ShowColumnsContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
-}
-
-func (v *MysqlVisitor) VisitShowCreateDb(ctx *mysqlparser.ShowCreateDbContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+	explain := new(sqlstmt.ExplainStmt)
+	explain.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	if dsc, ok := ctx.DescribeObjectClause().(*mysqlparser.DescribeStatementsContext); ok {
+		explain.Stmt = base.Accept[sqlstmt.Stmt](v, dsc)
+	}
+	return explain
 }
 
-func (v *MysqlVisitor) VisitShowCreateFullIdObject(ctx *mysqlparser.ShowCreateFullIdObjectContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitDescribeStatements(ctx *mysqlparser.DescribeStatementsContext) interface{} {
+	if ssc := ctx.SelectStatement(); ssc != nil {
+		return ssc.Accept(v)
+	}
+	if dsc := ctx.DeleteStatement(); dsc != nil {
+		return dsc.Accept(v)
+	}
+	if isc := ctx.InsertStatement(); isc != nil {
+		return isc.Accept(v)
+	}
+	if rsc := ctx.ReplaceStatement(); rsc != nil {
+		return rsc.Accept(v)
+	}
+	if usc := ctx.UpdateStatement(); usc != nil {
+		return usc.Accept(v)
+	}
+	return nil
 }
 
-func (v *MysqlVisitor) VisitShowCreateUser(ctx *mysqlparser.ShowCreateUserContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitCreateDatabase(ctx *mysqlparser.CreateDatabaseContext) interface{} {
+	cds := new(sqlstmt.CreateDatabase)
+	cds.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	return cds
 }
 
-func (v *MysqlVisitor) VisitShowEngine(ctx *mysqlparser.ShowEngineContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitCopyCreateTable(ctx *mysqlparser.CopyCreateTableContext) interface{} {
+	ct := new(sqlstmt.CreateTable)
+	ct.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	ct.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName(0))
+	ct.Like = base.Accept[*sqlstmt.TableName](v, ctx.TableName(1))
+	return ct
 }
 
-func (v *MysqlVisitor) VisitShowGlobalInfo(ctx *mysqlparser.ShowGlobalInfoContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitQueryCreateTable(ctx *mysqlparser.QueryCreateTableContext) interface{} {
+	ct := new(sqlstmt.CreateTable)
+	ct.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	ct.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
+	ct.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, ctx.SelectStatement())
+	return ct
 }
 
-func (v *MysqlVisitor) VisitShowErrors(ctx *mysqlparser.ShowErrorsContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitColumnCreateTable(ctx *mysqlparser.ColumnCreateTableContext) interface{} {
+	ct := new(sqlstmt.CreateTable)
+	ct.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	ct.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
+	return ct
 }
 
-func (v *MysqlVisitor) VisitShowCountErrors(ctx *mysqlparser.ShowCountErrorsContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitCreateIndex(ctx *mysqlparser.CreateIndexContext) interface{} {
+	ci := new(sqlstmt.CreateIndex)
+	ci.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	ci.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
+	return ci
 }
 
-func (v *MysqlVisitor) VisitShowSchemaFilter(ctx *mysqlparser.ShowSchemaFilterContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitCreateView(ctx *mysqlparser.CreateViewContext) interface{} {
+	cv := new(sqlstmt.CreateView)
+	cv.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	cv.TableName = v.getTableName(base.Accept[*sqlstmt.FullId](v, ctx.FullId()))
+	cv.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, ctx.SelectStatement())
+	if wcc := ctx.WithClause(); wcc != nil {
+		setWith(cv.SelectStmt, base.Accept[*sqlstmt.WithClause](v, wcc))
+	}
+	return cv
 }
 
-func (v *MysqlVisitor) VisitShowRoutine(ctx *mysqlparser.ShowRoutineContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitAlterTable(ctx *mysqlparser.AlterTableContext) interface{} {
+	at := new(sqlstmt.AlterTable)
+	at.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	at.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
+	return at
 }
 
-func (v *MysqlVisitor) VisitShowGrants(ctx *mysqlparser.ShowGrantsContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitDropIndex(ctx *mysqlparser.DropIndexContext) interface{} {
+	di := new(sqlstmt.DropIndex)
+	di.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	di.TableName = base.Accept[*sqlstmt.TableName](v, ctx.TableName())
+	return di
 }
 
-func (v *MysqlVisitor) VisitShowIndexes(ctx *mysqlparser.ShowIndexesContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitDropTable(ctx *mysqlparser.DropTableContext) interface{} {
+	dt := new(sqlstmt.DropTable)
+	dt.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	for _, tnc := range ctx.Tables().AllTableName() {
+		dt.TableNames = append(dt.TableNames, base.Accept[*sqlstmt.TableName](v, tnc))
+	}
+	return dt
 }
 
-func (v *MysqlVisitor) VisitShowOpenTables(ctx *mysqlparser.ShowOpenTablesContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitDropView(ctx *mysqlparser.DropViewContext) interface{} {
+	dv := new(sqlstmt.DropView)
+	dv.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	for _, fic := range ctx.AllFullId() {
+		dv.TableNames = append(dv.TableNames, v.getTableName(base.Accept[*sqlstmt.FullId](v, fic)))
+	}
+	return dv
 }
 
-func (v *MysqlVisitor) VisitShowProfile(ctx *mysqlparser.ShowProfileContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitRenameTable(ctx *mysqlparser.RenameTableContext) interface{} {
+	rt := new(sqlstmt.RenameTable)
+	rt.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	for _, rtc := range ctx.AllRenameTableClause() {
+		rt.OldTableNames = append(rt.OldTableNames, base.Accept[*sqlstmt.TableName](v, rtc.TableName(0)))
+		rt.NewTableNames = append(rt.NewTableNames, base.Accept[*sqlstmt.TableName](v, rtc.TableName(1)))
+	}
+	return rt
 }
 
-func (v *MysqlVisitor) VisitShowSlaveStatus(ctx *mysqlparser.ShowSlaveStatusContext) interface{} {
-	ort := new(sqlstmt.OtherReadStmt)
-	ort.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return ort
+func (v *MysqlVisitor) VisitTruncateTable(ctx *mysqlparser.TruncateTableContext) interface{} {
+	tt := new(sqlstmt.TruncateTable)
+	tt.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	tt.TableNames = []*sqlstmt.TableName{base.Accept[*sqlstmt.TableName](v, ctx.TableName())}
+	return tt
 }
 
-func (v *MysqlVisitor) VisitCreateDatabase(ctx *mysqlparser.CreateDatabaseContext) interface{} {
-	cds := new(sqlstmt.CreateDatabase)
-	cds.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return cds
+// subqueries 收集未解析为具体类型的节点中的子查询，子查询中嵌套的子查询由其自身收集
+func (v *MysqlVisitor) subqueries(tree antlr.Tree) []sqlstmt.ISelectStmt {
+	var selects []sqlstmt.ISelectStmt
+	for _, child := range tree.GetChildren() {
+		if ssc, ok := child.(mysqlparser.ISelectStatementContext); ok {
+			selects = append(selects, base.Accept[sqlstmt.ISelectStmt](v, ssc))
+			continue
+		}
+		selects = append(selects, v.subqueries(child)...)
+	}
+	return selects
+}
+
+// columns 收集未解析为具体类型的节点中引用的列，不包含子查询中的列
+func (v *MysqlVisitor) columns(tree antlr.Tree) []*sqlstmt.ColumnName {
+	var columns []*sqlstmt.ColumnName
+	for _, child := range tree.GetChildren() {
+		switch c := child.(type) {
+		case mysqlparser.ISelectStatementContext:
+			continue
+		case *mysqlparser.FullColumnNameContext:
+			columns = append(columns, base.Accept[*sqlstmt.ColumnName](v, c))
+			continue
+		}
+		columns = append(columns, v.columns(child)...)
+	}
+	return columns
 }
 
 func (v *MysqlVisitor) GetTableSourceItem(ctx mysqlparser.ITableSourceItemContext) sqlstmt.ITableSourceItem {
 	if ctx == nil {
 		return nil
 	}
-	return ctx.Accept(v).(sqlstmt.ITableSourceItem)
+	return base.Accept[sqlstmt.ITableSourceItem](v, ctx)
 }
 
 func (v *MysqlVisitor) GetExpr(ctx mysqlparser.IExpressionContext) sqlstmt.IExpr {
@@ -885,7 +1752,7 @@ func (v *MysqlVisitor) GetExpr(ctx mysqlparser.IExpressionContext) sqlstmt.IExpr
 		return nil
 	}
 
-	return ctx.Accept(v).(sqlstmt.IExpr)
+	return base.Accept[sqlstmt.IExpr](v, ctx)
 }
 
 func (v *MysqlVisitor) GetExprs(ctxs []mysqlparser.IExpressionContext) []sqlstmt.IExpr {
@@ -895,7 +1762,7 @@ func (v *MysqlVisitor) GetExprs(ctxs []mysqlparser.IExpressionContext) []sqlstmt
 
 	exprs := make([]sqlstmt.IExpr, 0)
 	for _, exprCtx := range ctxs {
-		exprs = append(exprs, exprCtx.Accept(v).(sqlstmt.IExpr))
+		exprs = append(exprs, base.Accept[sqlstmt.IExpr](v, exprCtx))
 	}
 	return exprs
 }
@@ -907,7 +1774,7 @@ func (v *MysqlVisitor) GetJoinParts(ctxs []mysqlparser.IJoinPartContext) []sqlst
 
 	joinPorts := make([]sqlstmt.IJoinPart, 0)
 	for _, joinPartCtx := range ctxs {
-		joinPorts = append(joinPorts, joinPartCtx.Accept(v).(sqlstmt.IJoinPart))
+		joinPorts = append(joinPorts, base.Accept[sqlstmt.IJoinPart](v, joinPartCtx))
 	}
 	return joinPorts
 }
@@ -0,0 +1,34 @@
+package pgsql
+
+import (
+	"github.com/may-fly/go-sqlparser/base"
+	pgparser "github.com/may-fly/go-sqlparser/pgsql/antlr4"
+)
+
+var pgsqlFingerprintTokens = &base.FingerprintTokens{
+	Numbers: []int{pgparser.PostgreSQLLexerIntegral, pgparser.PostgreSQLLexerNumeric},
+	Literals: []int{pgparser.PostgreSQLLexerStringConstant, pgparser.PostgreSQLLexerEscapeStringConstant,
+		pgparser.PostgreSQLLexerUnicodeEscapeStringConstant, pgparser.PostgreSQLLexerBinaryStringConstant,
+		pgparser.PostgreSQLLexerHexadecimalStringConstant},
+	LiteralBegin:     pgparser.PostgreSQLLexerBeginDollarStringConstant,
+	LiteralEnd:       pgparser.PostgreSQLLexerEndDollarStringConstant,
+	Identifiers:      []int{pgparser.PostgreSQLLexerQuotedIdentifier, pgparser.PostgreSQLLexerUnicodeQuotedIdentifier, pgparser.PostgreSQLLexerPARAM},
+	LowerIdentifiers: []int{pgparser.PostgreSQLLexerIdentifier},
+	Signs:            []int{pgparser.PostgreSQLLexerPLUS, pgparser.PostgreSQLLexerMINUS},
+	In:               pgparser.PostgreSQLLexerIN_P,
+	Values:           []int{pgparser.PostgreSQLLexerVALUES},
+	LeftParen:        pgparser.PostgreSQLLexerOPEN_PAREN,
+	RightParen:       pgparser.PostgreSQLLexerCLOSE_PAREN,
+	Comma:            pgparser.PostgreSQLLexerCOMMA,
+	Semicolon:        pgparser.PostgreSQLLexerSEMI,
+}
+
+// Fingerprint 计算sql的指纹，字面量(包括$$字符串)替换为?，仅含字面量的in列表及values行替换为( ? )，去除注释、关键字及未加引号的标识符小写
+// 参数($1)及加引号的标识符保持原样，sql存在语法错误时返回error
+func Fingerprint(sql string) (*base.Fingerprint, error) {
+	_, stream, err := getPgsqlParserTree(&base.ParseErrorListener{}, sql)
+	if err != nil {
+		return nil, err
+	}
+	return base.NewFingerprint(stream, pgsqlFingerprintTokens), nil
+}
@@ -0,0 +1,77 @@
+package pgsql
+
+import (
+	"github.com/may-fly/go-sqlparser/base"
+	pgparser "github.com/may-fly/go-sqlparser/pgsql/antlr4"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+var pgsqlFormatTokens = &base.FormatTokens{
+	Clauses: []base.FormatClause{
+		{Tokens: []int{pgparser.PostgreSQLLexerWITH}, Kind: base.ClauseList, Leading: true},
+		{Tokens: []int{pgparser.PostgreSQLLexerSELECT}, Kind: base.ClauseList},
+		{Tokens: []int{pgparser.PostgreSQLLexerINSERT}, Leading: true},
+		{Tokens: []int{pgparser.PostgreSQLLexerUPDATE}, Kind: base.ClauseTables, Leading: true},
+		{Tokens: []int{pgparser.PostgreSQLLexerDELETE_P}, Leading: true},
+		{Tokens: []int{pgparser.PostgreSQLLexerDELETE_P, pgparser.PostgreSQLLexerFROM}, Kind: base.ClauseTables, Leading: true},
+		{Tokens: []int{pgparser.PostgreSQLLexerFROM}, Kind: base.ClauseTables},
+		{Tokens: []int{pgparser.PostgreSQLLexerWHERE}, Kind: base.ClauseCondition},
+		{Tokens: []int{pgparser.PostgreSQLLexerGROUP_P, pgparser.PostgreSQLLexerBY}, Kind: base.ClauseList},
+		{Tokens: []int{pgparser.PostgreSQLLexerHAVING}, Kind: base.ClauseCondition},
+		{Tokens: []int{pgparser.PostgreSQLLexerWINDOW}, Kind: base.ClauseList},
+		{Tokens: []int{pgparser.PostgreSQLLexerORDER, pgparser.PostgreSQLLexerBY}, Kind: base.ClauseList},
+		{Tokens: []int{pgparser.PostgreSQLLexerLIMIT}},
+		{Tokens: []int{pgparser.PostgreSQLLexerOFFSET}},
+		{Tokens: []int{pgparser.PostgreSQLLexerFETCH}},
+		{Tokens: []int{pgparser.PostgreSQLLexerFOR}},
+		{Tokens: []int{pgparser.PostgreSQLLexerVALUES}, Kind: base.ClauseList},
+		{Tokens: []int{pgparser.PostgreSQLLexerSET}, Kind: base.ClauseList},
+		{Tokens: []int{pgparser.PostgreSQLLexerON, pgparser.PostgreSQLLexerCONFLICT}},
+		{Tokens: []int{pgparser.PostgreSQLLexerRETURNING}, Kind: base.ClauseList},
+		{Tokens: []int{pgparser.PostgreSQLLexerUNION}},
+		{Tokens: []int{pgparser.PostgreSQLLexerINTERSECT}},
+		{Tokens: []int{pgparser.PostgreSQLLexerEXCEPT}},
+	},
+	Statements: []int{pgparser.PostgreSQLLexerSELECT, pgparser.PostgreSQLLexerWITH, pgparser.PostgreSQLLexerINSERT, pgparser.PostgreSQLLexerUPDATE,
+		pgparser.PostgreSQLLexerDELETE_P, pgparser.PostgreSQLLexerVALUES, pgparser.PostgreSQLLexerCREATE},
+	Joins: []int{pgparser.PostgreSQLLexerJOIN},
+	JoinModifiers: []int{pgparser.PostgreSQLLexerLEFT, pgparser.PostgreSQLLexerRIGHT, pgparser.PostgreSQLLexerFULL, pgparser.PostgreSQLLexerINNER_P,
+		pgparser.PostgreSQLLexerCROSS, pgparser.PostgreSQLLexerOUTER_P, pgparser.PostgreSQLLexerNATURAL},
+	Conditions: []int{pgparser.PostgreSQLLexerAND, pgparser.PostgreSQLLexerOR},
+	Between:    pgparser.PostgreSQLLexerBETWEEN,
+	Create:     pgparser.PostgreSQLLexerCREATE,
+	Alter:      pgparser.PostgreSQLLexerALTER,
+	Table:      pgparser.PostgreSQLLexerTABLE,
+	// $$字符串的内容不是关键字
+	Identifiers: []int{pgparser.PostgreSQLLexerIdentifier, pgparser.PostgreSQLLexerQuotedIdentifier, pgparser.PostgreSQLLexerUnicodeQuotedIdentifier,
+		pgparser.PostgreSQLLexerDollarText},
+	IsIdentifier: func(tree antlr.Tree) bool {
+		switch tree.(type) {
+		case *pgparser.ColidContext, *pgparser.Table_aliasContext, *pgparser.Type_function_nameContext, *pgparser.CollabelContext,
+			*pgparser.Attr_nameContext, *pgparser.IdentifierContext:
+			return true
+		}
+		return false
+	},
+	LeftParen:  pgparser.PostgreSQLLexerOPEN_PAREN,
+	RightParen: pgparser.PostgreSQLLexerCLOSE_PAREN,
+	Comma:      pgparser.PostgreSQLLexerCOMMA,
+	Semicolon:  pgparser.PostgreSQLLexerSEMI,
+}
+
+// Format 格式化sql，select、insert、update、delete及create等语句每个子句独占一行，超出行宽的列表每项一行，保留注释
+// 仅改变token之间的空白及关键字的大小写，不改变语义；多条语句逐条格式化，psql元命令及COPY的数据块保持原样，存在语法错误时返回error
+func Format(sql string, options base.FormatOptions) (string, error) {
+	sqls, err := SplitSQL(sql)
+	if err != nil {
+		return "", err
+	}
+	return base.FormatScript(sql, sqls, func(s base.SingleSQL, index int) (string, error) {
+		tree, stream, err := getPgsqlParserTree(base.NewSplitErrorListener(sql, s, index), s.Text)
+		if err != nil {
+			return "", err
+		}
+		return base.Format(tree, stream, pgsqlFormatTokens, options), nil
+	})
+}
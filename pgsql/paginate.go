@@ -0,0 +1,105 @@
+package pgsql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/may-fly/go-sqlparser"
+	"github.com/may-fly/go-sqlparser/base"
+	pgparser "github.com/may-fly/go-sqlparser/pgsql/antlr4"
+	"github.com/may-fly/go-sqlparser/sqlstmt"
+)
+
+// Paginate 改写查询的limit为`LIMIT count OFFSET offset`，返回查询第offset行起的count行的sql
+// 已有limit、offset或fetch first时取二者的交集；union、圆括号查询的limit位于最外层，对其整体分页；for update等加锁子句保持不变
+// limit为参数等非常量或fetch ... with ties时返回sqlparser.ErrNotPaginable
+func Paginate(stmt sqlstmt.Stmt, offset, count int) (string, error) {
+	if offset < 0 || count < 0 {
+		return "", fmt.Errorf("sqlparser: invalid page offset %d or count %d", offset, count)
+	}
+
+	var node *sqlstmt.Node
+	switch s := stmt.(type) {
+	case *sqlstmt.SimpleSelectStmt:
+		node = s.Node
+	case *sqlstmt.ParenthesisSelect:
+		node = s.Node
+	case *sqlstmt.UnionSelectStmt:
+		node = s.Node
+	}
+	ctx, ok := node.GetRuleContext().(*pgparser.Select_no_parensContext)
+	if !ok {
+		return "", fmt.Errorf("%w: %T", sqlparser.ErrNotPaginable, stmt)
+	}
+
+	limitCtx := ctx.Select_limit()
+	if oslc := ctx.Opt_select_limit(); oslc != nil {
+		limitCtx = oslc.Select_limit()
+	}
+	limitOffset, limitCount := 0, -1
+	if limitCtx != nil {
+		var err error
+		if limitOffset, limitCount, err = selectLimitWindow(limitCtx); err != nil {
+			return "", err
+		}
+	}
+	offset, count = base.PageWindow(limitOffset, limitCount, offset, count)
+	clause := fmt.Sprintf("LIMIT %d OFFSET %d", count, offset)
+
+	// 无limit时添加至order by之后，加锁子句在其前后均可
+	rewriter := base.NewRewriter(ctx.GetParser())
+	osc := ctx.Opt_sort_clause()
+	switch {
+	case limitCtx != nil:
+		rewriter.Replace(limitCtx, clause)
+	case osc != nil && osc.Sort_clause() != nil:
+		rewriter.InsertAfter(osc.Sort_clause(), " "+clause)
+	default:
+		rewriter.InsertAfter(ctx.Select_clause(), " "+clause)
+	}
+	return rewriter.Text(ctx), nil
+}
+
+// selectLimitWindow limit、offset及fetch first子句的偏移与行数，不限制行数时count为-1
+func selectLimitWindow(ctx pgparser.ISelect_limitContext) (offset, count int, err error) {
+	count = -1
+	if lc := ctx.Limit_clause(); lc != nil {
+		switch {
+		case lc.COMMA() != nil:
+			return 0, 0, fmt.Errorf("%w: LIMIT #,# syntax is not supported", sqlparser.ErrNotPaginable)
+		case lc.TIES() != nil:
+			return 0, 0, fmt.Errorf("%w: FETCH ... WITH TIES", sqlparser.ErrNotPaginable)
+		case lc.Select_limit_value() != nil:
+			// limit all不限制行数
+			if lv := lc.Select_limit_value(); lv.ALL() == nil {
+				count, err = limitConstValue(lv.GetText())
+			}
+		case lc.Select_fetch_first_value() != nil:
+			count, err = limitConstValue(lc.Select_fetch_first_value().GetText())
+		default:
+			// fetch first row only
+			count = 1
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if oc := ctx.Offset_clause(); oc != nil {
+		if ov := oc.Select_offset_value(); ov != nil {
+			offset, err = limitConstValue(ov.GetText())
+		} else {
+			offset, err = limitConstValue(oc.Select_fetch_first_value().GetText())
+		}
+	}
+	return offset, count, err
+}
+
+// limitConstValue limit、offset中的常量值，参数等无法确定值时返回sqlparser.ErrNotPaginable
+func limitConstValue(text string) (int, error) {
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("%w: limit %s is not a constant", sqlparser.ErrNotPaginable, text)
+	}
+	return value, nil
+}
@@ -0,0 +1,202 @@
+package pgsql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/may-fly/go-sqlparser"
+	"github.com/may-fly/go-sqlparser/base"
+	pgparser "github.com/may-fly/go-sqlparser/pgsql/antlr4"
+	"github.com/may-fly/go-sqlparser/sqlstmt"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// Parameterize 将语句中的字面量替换为$1..$n，返回替换后的sql及按参数序号排列的参数值，用于将拼接的sql转换为预编译语句
+// 参数值：字符串为string(已处理E'...'中的转义及$$字符串)，整数为int64(超出时为uint64)，小数为float64，负数包含负号；limit、offset的值同样替换
+// NULL、布尔值、位串、U&'...'字符串、date '...'等带类型的字面量、order by及group by中的列序号保持不变
+// 仅支持select、insert、update、delete，语句中已有参数或其他语句返回sqlparser.ErrNotParameterizable
+func Parameterize(stmt sqlstmt.Stmt) (string, []any, error) {
+	switch stmt.Kind() {
+	case sqlstmt.KindSelect, sqlstmt.KindInsert, sqlstmt.KindUpdate, sqlstmt.KindDelete:
+	default:
+		return "", nil, fmt.Errorf("%w: %s statement", sqlparser.ErrNotParameterizable, stmt.Kind())
+	}
+	node, ok := stmt.(interface {
+		GetParser() antlr.Parser
+		GetRuleContext() antlr.RuleContext
+	})
+	var ctx antlr.ParserRuleContext
+	if ok && node.GetParser() != nil {
+		ctx, _ = node.GetRuleContext().(antlr.ParserRuleContext)
+	}
+	if ctx == nil {
+		return "", nil, fmt.Errorf("%w: %T", sqlparser.ErrNotParameterizable, stmt)
+	}
+
+	// 新参数的序号会与已有参数冲突
+	stream := node.GetParser().GetTokenStream()
+	for i := ctx.GetStart().GetTokenIndex(); i <= ctx.GetStop().GetTokenIndex(); i++ {
+		if token := stream.Get(i); token.GetTokenType() == pgparser.PostgreSQLLexerPARAM {
+			return "", nil, fmt.Errorf("%w: statement already has parameter %s", sqlparser.ErrNotParameterizable, token.GetText())
+		}
+	}
+
+	params := base.NewParameters(node.GetParser(), func(n int) string { return "$" + strconv.Itoa(n) })
+	parameterize(params, ctx)
+	sql, args := params.Text(ctx)
+	return sql, args, nil
+}
+
+func parameterize(params *base.Parameters, tree antlr.Tree) {
+	switch ctx := tree.(type) {
+	case *pgparser.A_expr_unary_signContext:
+		if ctx.MINUS() != nil {
+			if c := numericConst(ctx.A_expr_at_time_zone()); c != nil {
+				if value, ok := base.NumberValue("-" + c.GetText()); ok {
+					params.Replace(ctx, value)
+					return
+				}
+			}
+		}
+	case *pgparser.AexprconstContext:
+		if value, ok := aexprconstValue(ctx); ok && !isPosition(ctx) {
+			params.Replace(ctx, value)
+			return
+		}
+	}
+	for _, child := range tree.GetChildren() {
+		parameterize(params, child)
+	}
+}
+
+// numericConst 仅由数值常量构成的表达式中的常量，否则返回nil
+func numericConst(tree antlr.Tree) *pgparser.AexprconstContext {
+	for tree != nil {
+		if c, ok := tree.(*pgparser.AexprconstContext); ok {
+			if c.Iconst() == nil && c.Fconst() == nil {
+				return nil
+			}
+			return c
+		}
+		if tree.GetChildCount() != 1 {
+			return nil
+		}
+		tree = tree.GetChild(0)
+	}
+	return nil
+}
+
+// isPosition 是否为order by、group by中的列序号，如 order by 1
+func isPosition(ctx *pgparser.AexprconstContext) bool {
+	return base.IsWholeChild(ctx, func(parent antlr.Tree) bool {
+		switch parent.(type) {
+		case *pgparser.SortbyContext, *pgparser.Group_by_itemContext:
+			return true
+		}
+		return false
+	})
+}
+
+// aexprconstValue 常量作为参数时的值，无法参数化时返回false
+func aexprconstValue(ctx *pgparser.AexprconstContext) (any, bool) {
+	switch {
+	case ctx.Iconst() != nil && ctx.Constinterval() == nil:
+		return base.NumberValue(ctx.Iconst().GetText())
+	case ctx.Fconst() != nil:
+		return base.NumberValue(ctx.Fconst().GetText())
+	case ctx.Sconst() != nil && ctx.Func_name() == nil && ctx.Consttypename() == nil && ctx.Constinterval() == nil:
+		return sconstValue(ctx.Sconst())
+	}
+	return nil, false
+}
+
+// sconstValue 字符串的值，U&'...'及uescape返回false
+func sconstValue(ctx pgparser.ISconstContext) (any, bool) {
+	if ctx.Opt_uescape().GetChildCount() > 0 {
+		return nil, false
+	}
+	s := ctx.Anysconst()
+	switch {
+	case s.StringConstant() != nil:
+		text := s.StringConstant().GetText()
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), true
+	case s.EscapeStringConstant() != nil:
+		text := s.EscapeStringConstant().GetText()
+		return unescapeString(text[2 : len(text)-1])
+	case s.BeginDollarStringConstant() != nil:
+		var sb strings.Builder
+		for _, t := range s.AllDollarText() {
+			sb.WriteString(t.GetText())
+		}
+		return sb.String(), true
+	}
+	return nil, false
+}
+
+// unescapeString 处理E'...'字符串中重复的单引号及反斜杠转义，包括\ooo、\xhh、\uXXXX、\UXXXXXXXX
+func unescapeString(s string) (any, bool) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+			i++
+			sb.WriteByte(c)
+			continue
+		}
+		if c != '\\' || i+1 >= len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch c = s[i]; c {
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'x', 'u', 'U', '0', '1', '2', '3', '4', '5', '6', '7':
+			// 进制及最大位数
+			radix, digits, from := 16, map[byte]int{'x': 2, 'u': 4, 'U': 8}[c], i+1
+			if digits == 0 {
+				radix, digits, from = 8, 3, i
+			}
+			to := from
+			for to < len(s) && to-from < digits && isDigit(s[to], radix) {
+				to++
+			}
+			value, err := strconv.ParseUint(s[from:to], radix, 32)
+			// \u及\U需要完整的位数
+			if err != nil || (c == 'u' || c == 'U') && to-from != digits {
+				return nil, false
+			}
+			if c == 'u' || c == 'U' {
+				if !utf8.ValidRune(rune(value)) {
+					return nil, false
+				}
+				sb.WriteRune(rune(value))
+			} else {
+				sb.WriteByte(byte(value))
+			}
+			i = to - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), true
+}
+
+func isDigit(c byte, radix int) bool {
+	if radix == 8 {
+		return '0' <= c && c <= '7'
+	}
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
@@ -1,6 +1,11 @@
 package pgsql
 
 import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/may-fly/go-sqlparser"
 	pgparser "github.com/may-fly/go-sqlparser/pgsql/antlr4"
 
 	"github.com/may-fly/go-sqlparser/base"
@@ -10,21 +15,22 @@ import (
 )
 
 func GetPgsqlParserTree(baseLine int, statement string) (antlr.ParseTree, *antlr.CommonTokenStream, error) {
+	return getPgsqlParserTree(&base.ParseErrorListener{BaseLine: baseLine}, statement)
+}
+
+// getPgsqlParserTree 语法错误的行列及偏移按errorListener中的基准计算
+func getPgsqlParserTree(errorListener *base.ParseErrorListener, statement string) (antlr.ParseTree, *antlr.CommonTokenStream, error) {
 	lexer := pgparser.NewPostgreSQLLexer(antlr.NewInputStream(statement))
 	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
 	parser := pgparser.NewPostgreSQLParser(stream)
 
-	lexerErrorListener := &base.ParseErrorListener{
-		BaseLine: baseLine,
-	}
+	lexerErrorListener := *errorListener
 	lexer.RemoveErrorListeners()
-	lexer.AddErrorListener(lexerErrorListener)
+	lexer.AddErrorListener(&lexerErrorListener)
 
-	parserErrorListener := &base.ParseErrorListener{
-		BaseLine: baseLine,
-	}
+	parserErrorListener := *errorListener
 	parser.RemoveErrorListeners()
-	parser.AddErrorListener(parserErrorListener)
+	parser.AddErrorListener(&parserErrorListener)
 	parser.BuildParseTrees = true
 
 	tree := parser.Root()
@@ -40,14 +46,213 @@ func GetPgsqlParserTree(baseLine int, statement string) (antlr.ParseTree, *antlr
 	return tree, stream, nil
 }
 
+// CheckSyntax 检查sql语法，切割后逐条检查并借助antlr的错误恢复收集全部语法错误，存在错误时返回base.SyntaxErrors
+// psql的元命令及COPY数据块不检查
+func CheckSyntax(statement string) error {
+	sqls, err := SplitSQL(statement)
+	var errs base.SyntaxErrors
+	if err != nil {
+		var syntaxErr *base.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			return err
+		}
+		// 存在词法错误无法切割时整体检查
+		errs = checkSyntax(&base.ParseErrorListener{}, statement)
+		if len(errs) == 0 {
+			return err
+		}
+	}
+	// 逐条检查，antlr的错误恢复不一定在下一条语句处恢复，整体检查会遗漏或误报其他语句的错误
+	for i, sql := range base.FilterEmptySQL(sqls) {
+		if sql.Kind != base.SegmentStatement {
+			continue
+		}
+		errs = append(errs, checkSyntax(base.NewSplitErrorListener(statement, sql, i), sql.Text)...)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// visitTree 将语法树转换为语句，不支持的语法树结构返回base.ErrUnsupportedConstruct而不是panic
+func visitTree(tree antlr.ParseTree, errorListener *base.ParseErrorListener) ([]sqlstmt.Stmt, error) {
+	stmts, err := base.VisitTree[[]sqlstmt.Stmt](new(PgsqlVisitor), tree, errorListener)
+	var origin sqlstmt.Origin
+	if err == nil && errorListener.BaseOffset > 0 {
+		// 切割出的语句，节点的位置为在原sql中的位置
+		origin = sqlstmt.Origin{Line: errorListener.BaseLine, Column: errorListener.BaseColumn, Offset: errorListener.BaseOffset}
+	}
+	sqlstmt.SetParsed(stmts, origin)
+	return stmts, err
+}
+
+// setStatementIndex 整体解析出错时，按切割结果设置错误所在语句的序号
+func setStatementIndex(statement string, err error) {
+	if sqls, splitErr := SplitSQL(statement); splitErr == nil {
+		base.SetStatementIndex(err, sqls)
+	}
+}
+
+func checkSyntax(errorListener *base.ParseErrorListener, statement string) base.SyntaxErrors {
+	lexer := pgparser.NewPostgreSQLLexer(antlr.NewInputStream(statement))
+	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+	parser := pgparser.NewPostgreSQLParser(stream)
+
+	errorListener.CollectAll = true
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errorListener)
+	parser.RemoveErrorListeners()
+	parser.AddErrorListener(errorListener)
+	parser.BuildParseTrees = false
+
+	parser.Root()
+	return errorListener.Errs
+}
+
+var pgsqlSplitTokens = &base.SplitTokens{
+	Semicolon: pgparser.PostgreSQLLexerSEMI,
+	Create:    pgparser.PostgreSQLLexerCREATE,
+	Begin:     pgparser.PostgreSQLLexerBEGIN_P,
+	End:       pgparser.PostgreSQLLexerEND_P,
+	Case:      pgparser.PostgreSQLLexerCASE,
+}
+
+// psqlCommandRegexp 匹配psql的元命令(如\connect)及COPY ... FROM STDIN
+var psqlCommandRegexp = regexp.MustCompile(`(?im)^\s*\\|\bstdin\b`)
+
+// SplitSQL 根据词法切割多条sql，字符串、美元符引用($$)、注释及BEGIN ATOMIC ... END块中的分号不会切割
+// 支持psql的元命令及COPY ... FROM STDIN后的数据块，二者以独立的片段返回，类型见base.SingleSQL.Kind
+func SplitSQL(statement string) ([]base.SingleSQL, error) {
+	if psqlCommandRegexp.MatchString(statement) {
+		// 数据块及元命令不符合sql词法，需逐字节识别
+		return splitByReader(statement)
+	}
+	lexer := pgparser.NewPostgreSQLLexer(antlr.NewInputStream(statement))
+	return base.SplitByLexer(statement, lexer, pgsqlSplitTokens)
+}
+
+func splitByReader(statement string) ([]base.SingleSQL, error) {
+	reader, err := sqlparser.NewStatementReader(sqlparser.Pgsql, strings.NewReader(statement))
+	if err != nil {
+		return nil, err
+	}
+	reader.SetMaxStatementSize(len(statement))
+
+	var sqls []base.SingleSQL
+	for reader.Next() {
+		sqls = append(sqls, reader.Statement())
+	}
+	if err := reader.Err(); err != nil {
+		return nil, err
+	}
+	return sqls, nil
+}
+
 type PgsqlParser struct {
 }
 
+func init() {
+	sqlparser.Register(sqlparser.Pgsql, new(PgsqlParser))
+}
+
 func (*PgsqlParser) Parse(stmt string) ([]sqlstmt.Stmt, error) {
-	tree, _, err := GetPgsqlParserTree(1, stmt)
+	if psqlCommandRegexp.MatchString(stmt) {
+		return parseByReader(stmt)
+	}
+
+	errorListener := &base.ParseErrorListener{}
+	tree, _, err := getPgsqlParserTree(errorListener, stmt)
+	if err == nil {
+		var stmts []sqlstmt.Stmt
+		if stmts, err = visitTree(tree, errorListener); err == nil {
+			return stmts, nil
+		}
+	}
+	setStatementIndex(stmt, err)
+	return nil, err
+}
+
+// parseByReader 切割后仅解析sql语句，元命令及COPY数据块会被跳过，错误行号为原sql中的行号
+func parseByReader(stmt string) ([]sqlstmt.Stmt, error) {
+	sqls, err := splitByReader(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	stmts := make([]sqlstmt.Stmt, 0)
+	for i, sql := range base.FilterEmptySQL(sqls) {
+		if sql.Kind != base.SegmentStatement {
+			continue
+		}
+		errorListener := base.NewSplitErrorListener(stmt, sql, i)
+		tree, _, err := getPgsqlParserTree(errorListener, sql.Text)
+		if err != nil {
+			return nil, err
+		}
+		sqlStmts, err := visitTree(tree, errorListener)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, sqlStmts...)
+	}
+	return stmts, nil
+}
+
+// ParseEach 切割后逐条解析，单条语句的语法错误不影响其他语句，psql的元命令及COPY数据块不解析，仅返回其位置
+// 错误的行列为原sql中的位置
+func ParseEach(text string) ([]sqlparser.ParseResult, error) {
+	sqls, err := SplitSQL(text)
 	if err != nil {
 		return nil, err
 	}
 
-	return tree.Accept(new(PgsqlVisitor)).([]sqlstmt.Stmt), nil
+	results := make([]sqlparser.ParseResult, 0, len(sqls))
+	for i, sql := range base.FilterEmptySQL(sqls) {
+		result := sqlparser.ParseResult{SQL: sql}
+		if sql.Kind != base.SegmentStatement {
+			results = append(results, result)
+			continue
+		}
+		errorListener := base.NewSplitErrorListener(text, sql, i)
+		tree, _, err := getPgsqlParserTree(errorListener, sql.Text)
+		if err == nil {
+			var stmts []sqlstmt.Stmt
+			if stmts, err = visitTree(tree, errorListener); err == nil && len(stmts) > 0 {
+				result.Stmt = stmts[0]
+			}
+		}
+		result.Err = err
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (*PgsqlParser) ParseEach(text string) ([]sqlparser.ParseResult, error) {
+	return ParseEach(text)
+}
+
+func (*PgsqlParser) Split(text string) ([]base.SingleSQL, error) {
+	return SplitSQL(text)
+}
+
+func (*PgsqlParser) CheckSyntax(text string) error {
+	return CheckSyntax(text)
+}
+
+func (*PgsqlParser) Paginate(stmt sqlstmt.Stmt, offset, count int) (string, error) {
+	return Paginate(stmt, offset, count)
+}
+
+func (*PgsqlParser) Fingerprint(sql string) (*base.Fingerprint, error) {
+	return Fingerprint(sql)
+}
+
+func (*PgsqlParser) Parameterize(stmt sqlstmt.Stmt) (string, []any, error) {
+	return Parameterize(stmt)
+}
+
+func (*PgsqlParser) Format(sql string, options base.FormatOptions) (string, error) {
+	return Format(sql, options)
 }
@@ -1,10 +1,19 @@
 package pgsql
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/may-fly/go-sqlparser"
+	"github.com/may-fly/go-sqlparser/base"
+	pgparser "github.com/may-fly/go-sqlparser/pgsql/antlr4"
 	"github.com/may-fly/go-sqlparser/sqlstmt"
+
+	"github.com/antlr4-go/antlr/v4"
 )
 
 func TestParserSimpleSelect(t *testing.T) {
@@ -82,3 +91,959 @@ VALUES
 	}
 	t.Log(stmts)
 }
+
+func TestSplitSQL(t *testing.T) {
+	sql := `select 'a;b' from t_db; -- trailing; comment
+CREATE FUNCTION f_test() RETURNS int AS $$
+BEGIN
+  RETURN 1;
+END;
+$$ LANGUAGE plpgsql;
+select 2`
+	sqls, err := SplitSQL(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqls) != 3 {
+		t.Fatalf("sqls len = %d, want 3", len(sqls))
+	}
+	if sqls[1].FirstStatementLine != 1 || sqls[1].LastLine != 5 {
+		t.Fatalf("unexpected function sql: %+v", sqls[1])
+	}
+	if sqls[2].FirstStatementLine != 6 {
+		t.Fatalf("unexpected last sql: %+v", sqls[2])
+	}
+}
+
+func TestSplitSQLCopy(t *testing.T) {
+	sql := "\\connect db1\nCOPY t_db (id, name) FROM stdin;\n1\ta;b\n\\.\nselect 2;"
+	sqls, err := SplitSQL(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqls) != 4 || sqls[0].Kind != base.SegmentMetaCommand || sqls[2].Kind != base.SegmentCopyData {
+		t.Fatalf("unexpected sqls: %+v", sqls)
+	}
+	if sqls[2].Text != "1\ta;b\n" {
+		t.Fatalf("unexpected copy data: %q", sqls[2].Text)
+	}
+}
+
+func TestCheckSyntax(t *testing.T) {
+	err := CheckSyntax("select * fro t_db;\nselect 1;\nupdate t_db set;")
+	errs, ok := err.(base.SyntaxErrors)
+	if !ok || len(errs) < 2 || errs[0].Line != 1 || errs[len(errs)-1].Line != 3 {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+	// 每条语句的错误都会报告，且不误报正确的语句
+	var got []string
+	for _, e := range errs {
+		got = append(got, fmt.Sprintf("%d:%d %d %s", e.Line, e.Column, e.StatementIndex, e.Token))
+	}
+	if want := []string{"1:9 0 fro", "3:15 2 ;"}; !slices.Equal(got, want) {
+		t.Errorf("CheckSyntax() = %q, want %q", got, want)
+	}
+}
+
+func TestParseEach(t *testing.T) {
+	sql := "select * from t_db;\nselect * from where;\nselect 1;"
+	results, err := ParseEach(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || results[0].Stmt == nil || results[1].Err == nil || results[2].Stmt == nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if syntaxErr, ok := results[1].Err.(*base.SyntaxError); !ok || syntaxErr.Line != 2 {
+		t.Fatalf("unexpected error: %v", results[1].Err)
+	}
+}
+
+func TestStmtKind(t *testing.T) {
+	for _, c := range []struct {
+		sql      string
+		kind     sqlstmt.StmtKind
+		readOnly bool
+	}{
+		{"select * from t_db", sqlstmt.KindSelect, true},
+		{"insert into t_db values (1)", sqlstmt.KindInsert, false},
+		{"update t_db set a = 1", sqlstmt.KindUpdate, false},
+		{"delete from t_db", sqlstmt.KindDelete, false},
+		{"merge into t using s on t.id = s.id when matched then update set a = 1", sqlstmt.KindMerge, false},
+		{"create table t (id int)", sqlstmt.KindDDL, false},
+		{"truncate t_db", sqlstmt.KindDDL, false},
+		{"grant select on t_db to u", sqlstmt.KindDCL, false},
+		{"begin", sqlstmt.KindTCL, false},
+		{"show search_path", sqlstmt.KindShow, true},
+		{"explain select * from t_db", sqlstmt.KindExplain, true},
+		{"explain (analyze false) delete from t_db", sqlstmt.KindExplain, true},
+		{"explain analyze delete from t_db", sqlstmt.KindExplain, false},
+		{"set search_path to s", sqlstmt.KindSet, false},
+		{"call p()", sqlstmt.KindCall, false},
+	} {
+		stmts, err := new(PgsqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if kind := stmts[0].Kind(); kind != c.kind {
+			t.Errorf("%s: Kind() = %v, want %v", c.sql, kind, c.kind)
+		}
+		if readOnly := sqlstmt.IsReadOnly(stmts[0]); readOnly != c.readOnly {
+			t.Errorf("%s: IsReadOnly() = %v, want %v", c.sql, readOnly, c.readOnly)
+		}
+	}
+}
+
+func TestSelectSideEffects(t *testing.T) {
+	for _, c := range []struct {
+		sql     string
+		effects sqlstmt.SelectSideEffects
+	}{
+		{"select * from t_db", sqlstmt.SelectSideEffects{}},
+		{"select * into t_new from t_db", sqlstmt.SelectSideEffects{IntoTable: true}},
+		{"select * from t_db for update", sqlstmt.SelectSideEffects{Locking: true}},
+		{"select * from t_db for share skip locked", sqlstmt.SelectSideEffects{Locking: true}},
+		{"select * from t_db for read only", sqlstmt.SelectSideEffects{}},
+		{"(select * from t_db for update)", sqlstmt.SelectSideEffects{Locking: true}},
+		{"with d as (delete from t_db returning *) select * from d", sqlstmt.SelectSideEffects{ModifyingCTE: true}},
+		{"with d as (select * from t_db) select * from d", sqlstmt.SelectSideEffects{}},
+	} {
+		stmts, err := new(PgsqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		selectStmt, ok := stmts[0].(sqlstmt.ISelectStmt)
+		if !ok {
+			t.Fatalf("%s: %T is not a select", c.sql, stmts[0])
+		}
+		if effects := selectStmt.GetSideEffects(); effects != c.effects {
+			t.Errorf("%s: GetSideEffects() = %+v, want %+v", c.sql, effects, c.effects)
+		}
+		if readOnly := sqlstmt.IsReadOnly(stmts[0]); readOnly != c.effects.IsReadOnly() {
+			t.Errorf("%s: IsReadOnly() = %v", c.sql, readOnly)
+		}
+	}
+}
+
+func TestReferencedTables(t *testing.T) {
+	for _, c := range []struct {
+		sql  string
+		want string
+	}{
+		{"select * from t1 a join s.t2 b on a.id = b.id left join t3 on t3.id = (select max(id) from t4)", "t1 a Read,s.t2 b Read,t3 Read,t4 Read"},
+		{"select * from t1 where id in (select id from t2) and exists (select 1 from t3)", "t1 Read,t2 Read,t3 Read"},
+		{"select (select 1 from t2) c from (select * from t1) d, lateral (select * from t3) e", "t2 Read,t1 Read,t3 Read"},
+		{"select * from t1 union select * from t2 except (select * from t3 intersect table t4)", "t1 Read,t2 Read,t3 Read,t4 Read"},
+		{"select * from (t1 cross join t2) natural join t3", "t1 Read,t2 Read,t3 Read"},
+		{"with c as (select * from t1), d as (select * from c) select * from d join t2 using (id)", "t1 Read,t2 Read"},
+		{"with recursive c (n) as (select 1 union all select n + 1 from c) select * from c", ""},
+		{"with d as (delete from t1 returning *) insert into t2 select * from d", "t1 Write,t2 Write"},
+		{"insert into t1 select * from t2", "t1 Write,t2 Read"},
+		{"insert into t1 values ((select max(id) from t2)), (default)", "t1 Write,t2 Read"},
+		{"insert into t1 (a) values (1) on conflict (a) do update set a = (select 1 from t2) where t1.b = (select 1 from t3)", "t1 Write,t2 Read,t3 Read"},
+		{"call p((select 1 from t1), 2)", "t1 Read"},
+		{"update t1 a set name = b.name from t2 b where a.id = b.id", "t1 a Write,t2 b Read"},
+		{"delete from t1 using t2 where t1.id = t2.id and t2.id in (select id from t3)", "t1 Write,t2 Read,t3 Read"},
+		{"create table t1 as select * from t2", "t1 Create,t2 Read"},
+		{"create view v1 as select * from t1", "v1 Create,t1 Read"},
+		{"alter table s.t1 add column c int", "s.t1 Alter"},
+		{"create index idx on t1 (c)", "t1 Alter"},
+		{"drop table t1, t2", "t1 Drop,t2 Drop"},
+		{"truncate t1", "t1 Drop"},
+	} {
+		stmts, err := new(PgsqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if len(stmts) != 1 {
+			t.Fatalf("%s: got %d statements", c.sql, len(stmts))
+		}
+		refs := make([]string, 0)
+		for _, ref := range sqlstmt.ReferencedTables(stmts[0]) {
+			name := ref.TableName.Identifier.Value
+			if ref.TableName.Owner != "" {
+				name = ref.TableName.Owner + "." + name
+			}
+			if ref.Alias != "" {
+				name += " " + ref.Alias
+			}
+			refs = append(refs, name+" "+ref.Access.String())
+		}
+		if got := strings.Join(refs, ","); got != c.want {
+			t.Errorf("%s: ReferencedTables() = %s, want %s", c.sql, got, c.want)
+		}
+	}
+}
+
+func TestReferencedColumns(t *testing.T) {
+	for _, c := range []struct {
+		sql  string
+		want string
+	}{
+		{"select a.id, b.name n, count(c.x) from t1 a join t2 b on a.id = b.aid where b.status = 1 group by a.id having count(*) > 1 order by n",
+			"a.id Select Resolved t1,b.name Select Resolved t2,c.x Select Unresolved,a.id Join Resolved t1,b.aid Join Resolved t2,b.status Where Resolved t2,a.id GroupBy Resolved t1,n OrderBy SelectAlias"},
+		{"select id, t1.* from t1 where name = 'x' order by id desc", "id Select Resolved t1,name Where Resolved t1,id OrderBy Resolved t1"},
+		{"select id from t1, t2", "id Select Ambiguous"},
+		{"select * from t1 a where exists (select 1 from t2 b where b.aid = a.id)", "b.aid Where Resolved t2,a.id Where Resolved t1"},
+		{"with c as (select id from t1) select d.id from (select id from c) d", "id Select Resolved t1,d.id Select Derived,id Select Derived"},
+		{"select s.t1.id from s.t1", "s.t1.id Select Resolved t1"},
+		{"update t1 set name = t2.name from t2 where t1.id = t2.id", "name Set Resolved t1,t2.name Set Resolved t2,t1.id Where Resolved t1,t2.id Where Resolved t2"},
+		{"delete from t1 using t2 where t1.id = t2.id", "t1.id Where Resolved t1,t2.id Where Resolved t2"},
+		{"insert into t1 (a, b) values (1, (select x from t2)) on conflict (a) do update set b = 2 where t1.c = 1",
+			"a Insert Resolved t1,b Insert Resolved t1,x Select Resolved t2,b Set Resolved t1,t1.c Where Resolved t1"},
+		{"insert into t1 (a) select x from t2", "a Insert Resolved t1,x Select Resolved t2"},
+		{"insert into t1 (a, b) values (default, c) on conflict do update set b = default", "a Insert Resolved t1,b Insert Resolved t1,c Insert Resolved t1,b Set Resolved t1"},
+		{"select id, t2.id from t1 full join t2 using (id) where id > 1", "id Select Joined,t2.id Select Resolved t2,id Where Joined"},
+	} {
+		stmts, err := new(PgsqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		refs := make([]string, 0)
+		for _, ref := range sqlstmt.ReferencedColumns(stmts[0]) {
+			name := ref.ColumnName.Identifier.Value
+			if ref.ColumnName.Owner != "" {
+				name = ref.ColumnName.Owner + "." + name
+			}
+			name += " " + ref.Clause.String() + " " + ref.Resolution.String()
+			if ref.Table != nil {
+				name += " " + ref.Table.TableName.Identifier.Value
+			}
+			refs = append(refs, name)
+		}
+		if got := strings.Join(refs, ","); got != c.want {
+			t.Errorf("%s: ReferencedColumns() = %s, want %s", c.sql, got, c.want)
+		}
+	}
+}
+
+// parseCorpus 可解析的语句，用于TestRestore、TestJSON及FuzzParse的种子
+var parseCorpus = []string{
+	"",
+	"select * from t where a = 1 and b like 'x%' or c between 1 and 2",
+	"select a, count(*) from t1 t join (select * from t2) t2 on t.id = t2.id limit all",
+	"select 1 union select 2 intersect select 3",
+	"update t set a = a + 1 where current of c",
+	"delete from t using t2 where t.id = t2.id",
+	"insert into s.t values (1, 'a') returning *",
+	"COPY t FROM stdin;\n1\t2\n\\.\n\\connect db",
+	"create function f() returns int as $$ begin return 1; end $$ language plpgsql",
+}
+
+// invalidCorpus 语法错误的语句，仅用于FuzzParse的种子
+var invalidCorpus = []string{
+	";;",
+	"select 1;;select 2",
+	"select $$unterminated",
+}
+
+func FuzzParse(f *testing.F) {
+	for _, sql := range append(parseCorpus, invalidCorpus...) {
+		f.Add(sql)
+	}
+
+	parser := new(PgsqlParser)
+	f.Fuzz(func(t *testing.T, sql string) {
+		// 任意输入都不能panic
+		_, _ = parser.Parse(sql)
+		_, _ = ParseEach(sql)
+		_ = CheckSyntax(sql)
+		_, _ = SplitSQL(sql)
+	})
+}
+
+func TestPaginate(t *testing.T) {
+	for _, c := range []struct {
+		sql           string
+		offset, count int
+		want          string
+	}{
+		{"select * from t1 where a = 1 order by id", 20, 10, "select * from t1 where a = 1 order by id LIMIT 10 OFFSET 20"},
+		{"select * from t1 limit 100 offset 10", 20, 10, "select * from t1 LIMIT 10 OFFSET 30"},
+		{"select * from t1 offset 10 limit 25", 20, 10, "select * from t1 LIMIT 5 OFFSET 30"},
+		{"select * from t1 limit all offset 5", 0, 10, "select * from t1 LIMIT 10 OFFSET 5"},
+		{"select * from t1 offset 5 rows fetch first 8 rows only", 5, 10, "select * from t1 LIMIT 3 OFFSET 10"},
+		{"select * from t1 fetch first row only", 0, 10, "select * from t1 LIMIT 1 OFFSET 0"},
+		{"select * from t1 where id = 1 for update", 0, 10, "select * from t1 where id = 1 LIMIT 10 OFFSET 0 for update"},
+		{"select * from t1 for update limit 5", 0, 10, "select * from t1 for update LIMIT 5 OFFSET 0"},
+		{"select a from t1 union select a from t2 order by a", 0, 10, "select a from t1 union select a from t2 order by a LIMIT 10 OFFSET 0"},
+		{"(select a from t1 limit 5) union all select a from t2", 0, 10, "(select a from t1 limit 5) union all select a from t2 LIMIT 10 OFFSET 0"},
+		{"(select * from t1 limit 30) order by id", 20, 20, "(select * from t1 limit 30) order by id LIMIT 20 OFFSET 20"},
+		{"(select * from t1 limit 30)", 20, 20, "(select * from t1 limit 30) LIMIT 20 OFFSET 20"},
+		{"with c as (select id from t1) select * from c", 10, 10, "with c as (select id from t1) select * from c LIMIT 10 OFFSET 10"},
+	} {
+		stmts, err := new(PgsqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		got, err := sqlparser.Paginate(sqlparser.Pgsql, stmts[0], c.offset, c.count)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: Paginate() = %s, want %s", c.sql, got, c.want)
+		}
+	}
+
+	for _, sql := range []string{"select * from t1 limit $1", "select * from t1 fetch first 5 rows with ties", "delete from t1"} {
+		stmts, err := new(PgsqlParser).Parse(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		if _, err := Paginate(stmts[0], 0, 10); !errors.Is(err, sqlparser.ErrNotPaginable) {
+			t.Errorf("%s: err = %v, want ErrNotPaginable", sql, err)
+		}
+	}
+}
+
+func TestCountQuery(t *testing.T) {
+	for _, c := range []struct {
+		sql  string
+		want string
+	}{
+		{"select * from t1 where a = 1 order by id desc", "select COUNT(*) from t1 where a = 1"},
+		{"select a, b.name from t1 join t2 b on t1.id = b.aid order by a", "select COUNT(*) from t1 join t2 b on t1.id = b.aid"},
+		{"select a + 1 from t1", "SELECT COUNT(*) FROM (select a + 1 from t1) AS t"},
+		{"select distinct on (a) a, b from t1 order by a, b", "SELECT COUNT(*) FROM (select distinct on (a) a, b from t1) AS t"},
+		{"select a, count(*) from t1 group by a", "SELECT COUNT(*) FROM (select a, count(*) from t1 group by a) AS t"},
+		{"select * from t1 order by a fetch first 5 rows with ties", "SELECT COUNT(*) FROM (select * from t1 order by a fetch first 5 rows with ties) AS t"},
+		{"select * from t1 order by a limit 10 offset 20", "SELECT COUNT(*) FROM (select * from t1 limit 10 offset 20) AS t"},
+		{"select a from t1 union select a from t2 order by a", "SELECT COUNT(*) FROM (select a from t1 union select a from t2) AS t"},
+		{"with c as (select id from t1) select id from c order by id", "with c as (select id from t1) select COUNT(*) from c"},
+	} {
+		stmts, err := new(PgsqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		got, err := sqlstmt.CountQuery(stmts[0])
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: CountQuery() = %s, want %s", c.sql, got, c.want)
+		}
+	}
+
+	for _, sql := range []string{"select * into t2 from t1", "delete from t1", "select * from t1 for update"} {
+		stmts, err := new(PgsqlParser).Parse(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		if _, err := sqlstmt.CountQuery(stmts[0]); !errors.Is(err, sqlstmt.ErrNotCountable) {
+			t.Errorf("%s: err = %v, want ErrNotCountable", sql, err)
+		}
+	}
+}
+
+func TestInjectPredicate(t *testing.T) {
+	// 仅为t1、t2注入租户条件
+	tenant := func(table *sqlstmt.TableName, alias string) string {
+		if name := table.Identifier.Value; name == "t1" || name == "t2" {
+			if alias == "" {
+				alias = name
+			}
+			return alias + ".tenant_id = 42"
+		}
+		return ""
+	}
+	for _, c := range []struct {
+		sql  string
+		want string
+	}{
+		{"select * from t1 where a = 1 or b = 2 order by id",
+			"select * from t1 where (a = 1 or b = 2) AND t1.tenant_id = 42 order by id"},
+		{"select * from t1 a left join t2 b on a.id = b.aid join t3 on t3.id = a.id",
+			"select * from t1 a left join t2 b on (a.id = b.aid) AND b.tenant_id = 42 join t3 on t3.id = a.id WHERE a.tenant_id = 42"},
+		{"select * from t1 a right join t3 on a.id = t3.aid",
+			"select * from t1 a right join t3 on (a.id = t3.aid) AND a.tenant_id = 42"},
+		{"select * from t3 where id in (select aid from t2) and exists (select 1 from t1 where x = 1)",
+			"select * from t3 where id in (select aid from t2 WHERE t2.tenant_id = 42) and exists (select 1 from t1 where (x = 1) AND t1.tenant_id = 42)"},
+		{"with c as (select * from t1) select * from c, t2 group by c.id",
+			"with c as (select * from t1 WHERE t1.tenant_id = 42) select * from c, t2 WHERE t2.tenant_id = 42 group by c.id"},
+		{"select a from t1 union select a from t2 order by a",
+			"select a from t1 WHERE t1.tenant_id = 42 union select a from t2 WHERE t2.tenant_id = 42 order by a"},
+		{"update t1 set a = t2.a from t2 where t1.id = t2.id",
+			"update t1 set a = t2.a from t2 where (t1.id = t2.id) AND t1.tenant_id = 42 AND t2.tenant_id = 42"},
+		{"update t1 set a = 1 returning id", "update t1 set a = 1 WHERE t1.tenant_id = 42 returning id"},
+		{"delete from t1 a", "delete from t1 a WHERE a.tenant_id = 42"},
+		{"delete from t1 using t3 where t1.id = t3.id", "delete from t1 using t3 where (t1.id = t3.id) AND t1.tenant_id = 42"},
+		{"insert into t3 values ((select max(id) from t1))", "insert into t3 values ((select max(id) from t1 WHERE t1.tenant_id = 42))"},
+		{"insert into t3 values (1) on conflict do update set a = (select 1 from t2)",
+			"insert into t3 values (1) on conflict do update set a = (select 1 from t2 WHERE t2.tenant_id = 42)"},
+	} {
+		stmts, err := new(PgsqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		got, err := sqlstmt.InjectPredicate(stmts[0], tenant)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: InjectPredicate() = %s, want %s", c.sql, got, c.want)
+		}
+	}
+
+	for _, sql := range []string{
+		"select * from t1 full join t3 on t1.id = t3.id",
+		"table t1",
+		"select * from t3 natural left join t1",
+		"select * from t3 natural full join t1",
+		"select * from unnest(array(select id from t1)) u",
+	} {
+		stmts, err := new(PgsqlParser).Parse(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		if _, err := sqlstmt.InjectPredicate(stmts[0], tenant); !errors.Is(err, sqlstmt.ErrNotInjectable) {
+			t.Errorf("%s: err = %v, want ErrNotInjectable", sql, err)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	for _, c := range []struct {
+		sqls []string
+		want string
+	}{
+		{[]string{
+			"select * from t1 where id = 1 and name = 'a'",
+			"SELECT *\n  FROM T1 /* c */ WHERE Id=-2 AND name = E'b\\n'; -- end",
+			"select * from t1 where id = 3.5 and name = $tag$c$tag$",
+		}, "select * from t1 where id = ? and name = ?"},
+		{[]string{
+			"select a from t1 where id in (1, 2, 3) and \"B\" in ('x')",
+			"select a from t1 where id IN (4) and \"B\" in ('y', 'z')",
+		}, "select a from t1 where id in ( ? ) and \"B\" in ( ? )"},
+		{[]string{
+			"insert into t1 (a, b) values (1, 'a'), (2, 'b') returning id",
+			"insert into t1 (a, b) values (3, 'c') RETURNING id",
+		}, "insert into t1 ( a , b ) values ( ? ) returning id"},
+		{[]string{
+			"select a - 1 from t1 where b = $1 limit 10 offset 20",
+		}, "select a - ? from t1 where b = $1 limit ? offset ?"},
+	} {
+		var digest string
+		for _, sql := range c.sqls {
+			got, err := sqlparser.Fingerprint(sqlparser.Pgsql, sql)
+			if err != nil {
+				t.Fatalf("%s: %v", sql, err)
+			}
+			if got.Text != c.want {
+				t.Errorf("%s: Fingerprint() = %s, want %s", sql, got.Text, c.want)
+			}
+			if digest != "" && got.Digest != digest {
+				t.Errorf("%s: digest %s differs from %s", sql, got.Digest, digest)
+			}
+			digest = got.Digest
+		}
+	}
+
+	if _, err := Fingerprint("select * frm t1"); err == nil {
+		t.Error("Fingerprint() of invalid sql should fail")
+	}
+}
+
+func TestParameterize(t *testing.T) {
+	for _, c := range []struct {
+		sql  string
+		want string
+		args []any
+	}{
+		{"select * from t1 where id = 1 and name = 'a''b\\n' limit 10 offset 20",
+			"select * from t1 where id = $1 and name = $2 limit $3 offset $4", []any{int64(1), "a'b\\n", int64(10), int64(20)}},
+		{"select a, b::text from t1 where b > -2 and c < - 1.5 and d in (9223372036854775808, 1e3) and e = E'x\\ty\\u00e9' order by 1, a desc",
+			"select a, b::text from t1 where b > $1 and c < $2 and d in ($3, $4) and e = $5 order by 1, a desc",
+			[]any{int64(-2), float64(-1.5), uint64(9223372036854775808), float64(1000), "x\tyé"}},
+		{"insert into t1 (a, b, c, d) values (1, null, true, $$it's$$), (2, date '2024-01-01', B'101', 'x'::text) returning id",
+			"insert into t1 (a, b, c, d) values ($1, null, true, $2), ($3, date '2024-01-01', B'101', $4::text) returning id",
+			[]any{int64(1), "it's", int64(2), "x"}},
+		{"update t1 set a = a || '-' where id in (select b from t2 where c = 'z' group by 1)",
+			"update t1 set a = a || $1 where id in (select b from t2 where c = $2 group by 1)", []any{"-", "z"}},
+		{"delete from t1 where created_at < now() - interval '7 days'",
+			"delete from t1 where created_at < now() - interval '7 days'", nil},
+	} {
+		stmts, err := new(PgsqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		got, args, err := sqlparser.Parameterize(sqlparser.Pgsql, stmts[0])
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if got != c.want || fmt.Sprintf("%#v", args) != fmt.Sprintf("%#v", c.args) {
+			t.Errorf("%s: Parameterize() = %s %#v, want %s %#v", c.sql, got, args, c.want, c.args)
+		}
+		if _, err := new(PgsqlParser).Parse(got); err != nil {
+			t.Errorf("%s: parse parameterized sql: %v", got, err)
+		}
+	}
+
+	for _, sql := range []string{"select * from t1 where id = $1 and name = 'a'", "create table t (a int default 1)"} {
+		stmts, err := new(PgsqlParser).Parse(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		if _, _, err := Parameterize(stmts[0]); !errors.Is(err, sqlparser.ErrNotParameterizable) {
+			t.Errorf("%s: Parameterize() = %v, want ErrNotParameterizable", sql, err)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, c := range []struct {
+		sql     string
+		options base.FormatOptions
+		want    string
+	}{
+		{"select a, count(*) as c from t1 join t2 using (id) where a = 1 group by a order by c desc limit 10 offset 5;",
+			base.FormatOptions{},
+			"SELECT a, count(*) AS c\nFROM\n  t1\n  JOIN t2 USING (id)\nWHERE a = 1\nGROUP BY a\nORDER BY c DESC\nLIMIT 10\nOFFSET 5;"},
+		{"select \"Name\", UserId -- columns\nfrom Users where id in (select uid from orders where amount > 100)",
+			base.FormatOptions{KeywordCase: base.KeywordLower},
+			"select \"Name\", UserId -- columns\nfrom Users\nwhere id in (select uid from orders where amount > 100)"},
+		{"insert into t1 (a, b) values (1, $$select$$) on conflict (a) do update set b = excluded.b returning id",
+			base.FormatOptions{},
+			"INSERT INTO t1 (a, b)\nVALUES (1, $$select$$)\nON CONFLICT (a) DO UPDATE\nSET b = excluded.b\nRETURNING id"},
+		{"select column_one, column_two from t1 where column_one = 'a' or column_two = 'b'",
+			base.FormatOptions{CommaPlacement: base.CommaLeading, IndentWidth: 4, LineWidth: 24},
+			"SELECT\n    column_one\n    , column_two\nFROM t1\nWHERE\n    column_one = 'a'\n    OR column_two = 'b'"},
+		{"select a, concat(b, c) from t1 where x in (1, 2)",
+			base.FormatOptions{CommaPlacement: base.CommaLeading},
+			"SELECT a, CONCAT(b, c)\nFROM t1\nWHERE x IN (1, 2)"},
+		{"create table t1 (id serial primary key, price numeric(10, 2))",
+			base.FormatOptions{CommaPlacement: base.CommaLeading, LineWidth: 20},
+			"CREATE TABLE t1 (\n  id serial PRIMARY KEY\n  , price NUMERIC(10, 2)\n)"},
+		{"create table t1 (id serial primary key, name text not null default 'x')",
+			base.FormatOptions{},
+			"CREATE TABLE t1 (\n  id serial PRIMARY KEY,\n  name text NOT NULL DEFAULT 'x'\n)"},
+	} {
+		got, err := sqlparser.Format(sqlparser.Pgsql, c.sql, c.options)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: Format() = %q, want %q", c.sql, got, c.want)
+		}
+		// 格式化前后的token相同
+		if !equalTokens(c.sql, got) {
+			t.Errorf("%s: tokens of %s differ", c.sql, got)
+		}
+	}
+
+	// COPY的数据块保持原样
+	got, err := Format("copy t1 (a) from stdin;\n1\n2\n\\.\nselect 1;", base.FormatOptions{})
+	if want := "COPY t1 (a) FROM STDIN;\n1\n2\n\\.\nSELECT 1;"; err != nil || got != want {
+		t.Errorf("Format() = %q, %v, want %q", got, err, want)
+	}
+	if _, err := Format("select * frm t1", base.FormatOptions{}); err == nil {
+		t.Error("Format() of invalid sql should fail")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	parser := new(PgsqlParser)
+	// 生成的sql与原sql的token相同，再次解析后生成的sql不变
+	corpus := append([]string{
+		"select distinct on (a) a, b as c from s.t1 x left join \"T2\" y on x.id = y.id natural join t3\n" +
+			"where x.b in (1, 2) and not exists (select 1 from t4 where t4.id = x.id) group by a having count(*) > 1 order by a desc limit 10 offset 5 for update",
+		"select * from t1 where id = 1 union all (select * from t2) order by id fetch first 5 rows with ties",
+		"(select 1) order by 1 limit 1",
+		"with recursive c (n) as (select 1 union all select n + 1 from c where n < 3) select * from c",
+		"update t1 as a set x = 1, y = b.y from t2 b where a.id = b.id returning *",
+		"delete from t1 using t2 where t1.id = t2.id",
+		"insert into t1 as a (x) select x from t2 on conflict (x) do update set x = excluded.x",
+		"insert into t1 (a, b) values (1, default), (2, (select 1 from t2)) on conflict (a) do update set b = 3 where t1.a > (select 1 from t3)",
+		"call p((select 1 from t1), 2)",
+		"create table t1 (like t2)",
+		"create view v as select * from t1",
+		"drop table t1, t2",
+		"truncate table t1",
+		"explain analyze select * from t1",
+		"set search_path = public",
+	}, parseCorpus...)
+	for _, sql := range corpus {
+		stmts, err := parser.Parse(sql)
+		if err != nil {
+			t.Errorf("%s: %v", sql, err)
+			continue
+		}
+		for _, stmt := range stmts {
+			got, err := sqlstmt.SQL(stmt, sqlstmt.DialectPgsql)
+			if err != nil {
+				t.Errorf("%s: %v", stmt.GetText(), err)
+				continue
+			}
+			if !equalTokens(got, stmt.GetText()) {
+				t.Errorf("SQL() = %s, want %s", got, stmt.GetText())
+			}
+			again, err := parser.Parse(got)
+			if err != nil {
+				t.Errorf("%s: %v", got, err)
+				continue
+			}
+			if restored, _ := sqlstmt.SQL(again[0], sqlstmt.DialectPgsql); restored != got {
+				t.Errorf("SQL() = %s after parsing %s", restored, got)
+			}
+		}
+	}
+
+	// 修改表名及limit，注释、for update等字段未表示的部分保留
+	stmts, err := parser.Parse("select * from t1 /* keep */ where id = 1 limit 10 for update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs := stmts[0].(*sqlstmt.SimpleSelectStmt).QuerySpecification
+	qs.Limit.RowCount = 20
+	qs.From.TableSources[0].(*sqlstmt.TableSourceBase).TableSourceItem.(*sqlstmt.AtomTableItem).TableName.Identifier.Value = "t2"
+	if got, err := sqlstmt.SQL(stmts[0], sqlstmt.DialectPgsql); err != nil || got != "select * from t2 /* keep */ where id = 1 LIMIT 20 for update" {
+		t.Errorf("SQL() = %s, %v", got, err)
+	}
+
+	// 替换为手动构造的节点时按字段生成，pgsql中limit不在query的范围内，整条语句按字段生成，
+	// 其中的for update未以字段表示，无法生成；单独生成query时不包含for update
+	qs.Where = &sqlstmt.LogicalExpr{Operator: "or", Exprs: []sqlstmt.IExpr{qs.Where, &sqlstmt.LogicalExpr{Operator: "and", Exprs: []sqlstmt.IExpr{
+		&sqlstmt.PredicateExpr{Predicate: &sqlstmt.ExprAtomPredicate{ExprAtom: &sqlstmt.ExprAtomColumnName{ColumnName: &sqlstmt.ColumnName{
+			Identifier: &sqlstmt.IdentifierValue{Value: "deleted"}}}}},
+		&sqlstmt.PredicateExpr{Predicate: &sqlstmt.BinaryComparisonPredicate{
+			Left: &sqlstmt.ExprAtomPredicate{ExprAtom: &sqlstmt.ExprAtomColumnName{ColumnName: &sqlstmt.ColumnName{
+				Owner: "t2", Identifier: &sqlstmt.IdentifierValue{Value: "order id"}}}},
+			ComparisonOperator: "=",
+			Right:              &sqlstmt.ExprAtomPredicate{ExprAtom: &sqlstmt.ExprAtomConstant{Constant: &sqlstmt.Constant{Value: "2"}}},
+		}},
+	}}}}
+	if got, err := sqlstmt.SQL(stmts[0], sqlstmt.DialectPgsql); !errors.Is(err, sqlstmt.ErrNotRestorable) {
+		t.Errorf("SQL() = %s, %v, want ErrNotRestorable", got, err)
+	}
+	if got, err := sqlstmt.SQL(qs, sqlstmt.DialectPgsql); err != nil || got != `SELECT * FROM t2 WHERE id = 1 OR (deleted AND t2."order id" = 2) LIMIT 20` {
+		t.Errorf("SQL() = %s, %v", got, err)
+	}
+
+	// 增加手动构造的行时按字段生成，on conflict未以字段表示，无法生成
+	stmts, err = parser.Parse("insert into t1 (a) values (1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	is := stmts[0].(*sqlstmt.InsertStmt)
+	is.Values = append(is.Values, &sqlstmt.ValuesRow{Exprs: []sqlstmt.IExpr{nil}})
+	if got, err := sqlstmt.SQL(is, sqlstmt.DialectPgsql); err != nil || got != "INSERT INTO t1 (a) VALUES (1), (DEFAULT)" {
+		t.Errorf("SQL() = %s, %v", got, err)
+	}
+	is.DuplicatedElements = []*sqlstmt.UpdatedElement{{ColumnName: is.Columns[0]}}
+	if _, err := sqlstmt.SQL(is, sqlstmt.DialectPgsql); !errors.Is(err, sqlstmt.ErrNotRestorable) {
+		t.Errorf("err = %v, want ErrNotRestorable", err)
+	}
+
+	if _, err := sqlstmt.SQL(&sqlstmt.Expr{}, sqlstmt.DialectPgsql); !errors.Is(err, sqlstmt.ErrNotRestorable) {
+		t.Errorf("err = %v, want ErrNotRestorable", err)
+	}
+}
+
+// firstNode 语句中第一个T类型的节点
+func firstNode[T sqlstmt.INode](stmt sqlstmt.INode) T {
+	var found T
+	var ok bool
+	sqlstmt.Inspect(stmt, func(node sqlstmt.INode) bool {
+		if !ok {
+			found, ok = node.(T)
+		}
+		return !ok
+	})
+	return found
+}
+
+func TestRestoreModified(t *testing.T) {
+	// 修改解析得到的节点的字段后，该节点按字段生成，不能生成的返回ErrNotRestorable
+	for _, c := range []struct {
+		sql    string
+		modify func(stmt sqlstmt.Stmt)
+		want   string
+	}{
+		{"select * from t1 /* keep */ x where id = 1", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.AtomTableItem](stmt).Alias = "y"
+		}, "select * from t1 AS y where id = 1"},
+		{"select * from (select 1) x", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.SubqueryTableItem](stmt).Alias = "y"
+		}, "select * from (select 1) AS y"},
+		{"select a b, c from t1", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.SelectExpressionElement](stmt).Alias = "d"
+		}, "select a AS d, c from t1"},
+		{"select distinct a from t1", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.QuerySpecification](stmt).Distinct = false
+		}, "SELECT a FROM t1"},
+		{"select a from t1 order by a desc, b", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.OrderByExpr](stmt).Desc = false
+		}, "select a from t1 order by a, b"},
+		{"update t1 set a = 1, /* keep */ b = 2 where id = 1", func(stmt sqlstmt.Stmt) {
+			u := stmt.(*sqlstmt.UpdateStmt)
+			u.UpdatedElements = u.UpdatedElements[:1]
+		}, "UPDATE t1 SET a = 1 WHERE id = 1"},
+		{"insert into t1 (a, b) values (1, 2)", func(stmt sqlstmt.Stmt) {
+			r := stmt.(*sqlstmt.InsertStmt).Values[0]
+			r.Exprs = r.Exprs[:1]
+		}, "insert into t1 (a, b) values (1)"},
+		{"select * from t1 where a = 1 and /* keep */ b = 2", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.LogicalExpr](stmt).Operator = "or"
+		}, "select * from t1 where a = 1 OR b = 2"},
+		{"select * from t1 where a = /* keep */ 1", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.BinaryComparisonPredicate](stmt).ComparisonOperator = "<>"
+		}, "select * from t1 where a <> 1"},
+		// 保留字作为标识符时加引号
+		{"select x.b from t1 x", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.ColumnName](stmt).Identifier.Value = "order"
+			firstNode[*sqlstmt.TableName](stmt).Identifier.Value = "select"
+		}, `select x."order" from "select" x`},
+		// limit all及仅有offset时不限制行数
+		{"select * from t1 limit all offset 10", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.Limit](stmt).Offset = 20
+		}, "select * from t1 OFFSET 20"},
+		{"select * from t1 offset 10", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.Limit](stmt).Offset = 0
+		}, "select * from t1 LIMIT ALL"},
+		{"select * from t1 offset 10", func(stmt sqlstmt.Stmt) {
+			l := firstNode[*sqlstmt.Limit](stmt)
+			l.Unlimited, l.RowCount = false, 5
+		}, "select * from t1 LIMIT 5 OFFSET 10"},
+		// 字段未表示的语法无法保留
+		{"select * from t1 limit 10", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.Limit](stmt).RowCount = -1
+		}, ""},
+		{"update t1 set a = 1, /* keep */ b = 2 where id = 1 returning id", func(stmt sqlstmt.Stmt) {
+			u := stmt.(*sqlstmt.UpdateStmt)
+			u.UpdatedElements = u.UpdatedElements[:1]
+		}, ""},
+		{"select * from t1 where a = 1", func(stmt sqlstmt.Stmt) {
+			firstNode[*sqlstmt.QuerySpecification](stmt).GroupBy = []sqlstmt.IExpr{&sqlstmt.Expr{}}
+		}, ""},
+	} {
+		stmts, err := new(PgsqlParser).Parse(c.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		c.modify(stmts[0])
+		// 编码后被修改的节点同样按字段生成
+		data, err := sqlstmt.Marshal(stmts[0])
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		node, err := sqlstmt.Unmarshal(data)
+		if err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		for _, node := range []sqlstmt.INode{stmts[0], node} {
+			got, err := sqlstmt.SQL(node, sqlstmt.DialectPgsql)
+			if c.want == "" {
+				if !errors.Is(err, sqlstmt.ErrNotRestorable) {
+					t.Errorf("%s: SQL() = %s, %v, want ErrNotRestorable", c.sql, got, err)
+				}
+			} else if err != nil || got != c.want {
+				t.Errorf("%s: SQL() = %s, %v, want %s", c.sql, got, err, c.want)
+			}
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	stmts, err := new(PgsqlParser).Parse("select t1.a, (select max(b) from t2 where t2.id = t1.id) + c from t1 join t3 on t1.id = t3.id where d in (select e from t4) union select f from t5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 列按在sql中出现的顺序访问，包括子查询及union各分支中的列
+	var columns []string
+	sqlstmt.Inspect(stmts[0], func(node sqlstmt.INode) bool {
+		if c, ok := node.(*sqlstmt.ColumnName); ok {
+			columns = append(columns, c.GetText())
+		}
+		return true
+	})
+	if want := "t1.a b t2.id t1.id c t1.id t3.id d e f"; strings.Join(columns, " ") != want {
+		t.Errorf("columns = %v, want %s", columns, want)
+	}
+
+	// 表所在的查询
+	var tables []string
+	sqlstmt.InspectStack(stmts[0], func(node sqlstmt.INode, stack []sqlstmt.INode) bool {
+		if table, ok := node.(*sqlstmt.TableName); ok {
+			for i := len(stack) - 1; i >= 0; i-- {
+				if qs, ok := stack[i].(*sqlstmt.QuerySpecification); ok {
+					tables = append(tables, table.GetText()+":"+qs.SelectElements.GetText())
+					break
+				}
+			}
+		}
+		// 不进入子查询
+		_, ok := node.(sqlstmt.ISelectStmt)
+		return !ok || len(stack) == 0
+	})
+	if want := "t1:t1.a, (select max(b) from t2 where t2.id = t1.id) + c t3:t1.a, (select max(b) from t2 where t2.id = t1.id) + c t5:f"; strings.Join(tables, " ") != want {
+		t.Errorf("tables = %v, want %s", tables, want)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	text := "select 1;\n-- 注释\nselect '😀', a from t1 where b =\n  '中文' and c = 1; select 2"
+	results, err := ParseEach(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := results[1].Stmt
+	if pos, end := stmt.Pos(), stmt.End(); pos != (sqlstmt.Position{Offset: 20, Line: 3, Column: 0}) || end != (sqlstmt.Position{Offset: 75, Line: 4, Column: 16}) {
+		t.Errorf("Pos() = %+v, End() = %+v", pos, end)
+	}
+
+	// 第二行的条件，位置为在原sql中的位置
+	where := stmt.(*sqlstmt.SimpleSelectStmt).QuerySpecification.Where
+	if got := text[where.GetStartIndex() : where.GetStopIndex()+1]; got != "b =\n  '中文' and c = 1" {
+		t.Errorf("where = %q", got)
+	}
+	if pos, end := where.Pos(), where.End(); pos.Line != 3 || pos.Column != 28 || end.Line != 4 || end.Column != 16 {
+		t.Errorf("Pos() = %v, End() = %v", pos, end)
+	}
+	// 😀在UTF-16中为2个码元
+	if got := base.UTF16Column(text, where.GetStartIndex()); got != 29 {
+		t.Errorf("UTF16Column() = %d, want 29", got)
+	}
+	if got := base.UTF16Offset(text, where.GetStartIndex()); got != 45 {
+		t.Errorf("UTF16Offset() = %d, want 45", got)
+	}
+
+	// 同一行中的第二条语句
+	if pos := results[2].Stmt.Pos(); pos != (sqlstmt.Position{Offset: 77, Line: 4, Column: 18}) {
+		t.Errorf("Pos() = %+v", pos)
+	}
+	if pos := (&sqlstmt.Limit{}).Pos(); pos.IsValid() {
+		t.Errorf("Pos() = %v, want invalid", pos)
+	}
+}
+
+func TestDetach(t *testing.T) {
+	results, err := ParseEach("select 1;\nselect a, (select max(b) from t2) + c from t1 /* keep */ where id = 1 limit 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := results[1].Stmt
+	columns := func() string {
+		var names []string
+		sqlstmt.Inspect(stmt, func(node sqlstmt.INode) bool {
+			if c, ok := node.(*sqlstmt.ColumnName); ok {
+				names = append(names, c.GetText()+"@"+c.Pos().String())
+			}
+			return true
+		})
+		return strings.Join(names, " ")
+	}
+	text, pos, end, wantColumns := stmt.GetText(), stmt.Pos(), stmt.End(), columns()
+
+	sqlstmt.Detach(stmt)
+	sqlstmt.Inspect(stmt, func(node sqlstmt.INode) bool {
+		if n, ok := node.(interface{ GetParser() antlr.Parser }); ok && n.GetParser() != nil {
+			t.Errorf("%T references the parser", node)
+		}
+		return true
+	})
+	if stmt.GetText() != text || stmt.Pos() != pos || stmt.End() != end {
+		t.Errorf("GetText() = %s, Pos() = %v, End() = %v", stmt.GetText(), stmt.Pos(), stmt.End())
+	}
+	if got := columns(); got != wantColumns {
+		t.Errorf("columns = %s, want %s", got, wantColumns)
+	}
+
+	// 生成sql不依赖parser
+	stmt.(*sqlstmt.SimpleSelectStmt).QuerySpecification.Limit.RowCount = 20
+	if got, err := sqlstmt.SQL(stmt, sqlstmt.DialectPgsql); err != nil || got != "select a, (select max(b) from t2) + c from t1 /* keep */ where id = 1 LIMIT 20" {
+		t.Errorf("SQL() = %s, %v", got, err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	parser := new(PgsqlParser)
+	corpus := append([]string{
+		"select distinct on (a) a, b as c from s.t1 x left join \"T2\" y on x.id = y.id\n" +
+			"where x.b in (1, 2.0, 'x') and not exists (select 1 from t4 where t4.id = x.id) order by a desc limit 10 offset 5 for update",
+		"with c (n) as (select 1) select * from c union all select 2",
+		"update t1 set a = 1 where id = -1 returning *",
+		"explain analyze select * from t1",
+		"insert into t1 (a) values (1), (2) on conflict (a) do update set a = 3",
+		"call p(1, a => (select 1 from t1))",
+	}, parseCorpus...)
+	for _, sql := range corpus {
+		stmts, err := parser.Parse(sql)
+		if err != nil {
+			t.Errorf("%s: %v", sql, err)
+			continue
+		}
+		for _, stmt := range stmts {
+			data, err := sqlstmt.Marshal(stmt)
+			if err != nil {
+				t.Errorf("%s: %v", stmt.GetText(), err)
+				continue
+			}
+			node, err := sqlstmt.Unmarshal(data)
+			if err != nil {
+				t.Errorf("%s: %v", data, err)
+				continue
+			}
+			if again, err := sqlstmt.Marshal(node); err != nil || string(again) != string(data) {
+				t.Errorf("Marshal(Unmarshal()) = %s, %v, want %s", again, err, data)
+			}
+			want, _ := sqlstmt.SQL(stmt, sqlstmt.DialectPgsql)
+			if got, err := sqlstmt.SQL(node, sqlstmt.DialectPgsql); err != nil || got != want {
+				t.Errorf("SQL() = %s, %v, want %s", got, err, want)
+			}
+		}
+	}
+
+	stmt, err := parser.Parse("select * from t1 /* keep */ where id = 1 and b > 1.0 limit 10 for update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(stmt[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"type":"SimpleSelectStmt"`, `"type":"LogicalExpr"`, `"type":"BinaryComparisonPredicate"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s does not contain %s", data, want)
+		}
+	}
+	var decoded sqlstmt.SimpleSelectStmt
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	decoded.QuerySpecification.Limit.RowCount = 20
+	if got, err := sqlstmt.SQL(&decoded, sqlstmt.DialectPgsql); err != nil || got != "select * from t1 /* keep */ where id = 1 and b > 1.0 LIMIT 20 for update" {
+		t.Errorf("SQL() = %s, %v", got, err)
+	}
+
+	if _, err := sqlstmt.Unmarshal([]byte(`{"version":0,"node":null}`)); err == nil {
+		t.Error("Unmarshal() with an unsupported version succeeded")
+	}
+	if err := json.Unmarshal([]byte(`{"type":"UpdateStmt"}`), &decoded); err == nil {
+		t.Error("json.Unmarshal() into a different node type succeeded")
+	}
+}
+
+// equalTokens a与b的token除关键字大小写外相同
+func equalTokens(a, b string) bool {
+	tokens := func(sql string) []string {
+		var texts []string
+		lexer := pgparser.NewPostgreSQLLexer(antlr.NewInputStream(sql))
+		for token := lexer.NextToken(); token.GetTokenType() != antlr.TokenEOF; token = lexer.NextToken() {
+			if token.GetChannel() == antlr.TokenDefaultChannel {
+				texts = append(texts, token.GetText())
+			}
+		}
+		return texts
+	}
+	ta, tb := tokens(a), tokens(b)
+	if len(ta) != len(tb) {
+		return false
+	}
+	for i := range ta {
+		if !strings.EqualFold(ta[i], tb[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -3,21 +3,25 @@ package pgsql
 import (
 	"strings"
 
+	"github.com/may-fly/go-sqlparser/base"
 	pgparser "github.com/may-fly/go-sqlparser/pgsql/antlr4"
 	"github.com/may-fly/go-sqlparser/sqlstmt"
 
+	"github.com/antlr4-go/antlr/v4"
 	"github.com/may-fly/cast"
 )
 
 type PgsqlVisitor struct {
 	*pgparser.BasePostgreSQLParserVisitor
+
+	sideEffects map[antlr.Tree]sqlstmt.SelectSideEffects // 已遍历的查询的副作用，见selectSideEffects
 }
 
 func (v *PgsqlVisitor) VisitRoot(ctx *pgparser.RootContext) interface{} {
 	if sbc := ctx.Stmtblock(); sbc != nil {
 		return sbc.Accept(v)
 	}
-	return sqlstmt.NewNode(ctx.GetParser(), ctx)
+	return make([]sqlstmt.Stmt, 0)
 }
 
 func (v *PgsqlVisitor) VisitPlsqlroot(ctx *pgparser.PlsqlrootContext) interface{} {
@@ -28,14 +32,16 @@ func (v *PgsqlVisitor) VisitStmtblock(ctx *pgparser.StmtblockContext) interface{
 	if smc := ctx.Stmtmulti(); smc != nil {
 		return smc.Accept(v)
 	}
-	return sqlstmt.NewNode(ctx.GetParser(), ctx)
+	return make([]sqlstmt.Stmt, 0)
 }
 
 func (v *PgsqlVisitor) VisitStmtmulti(ctx *pgparser.StmtmultiContext) interface{} {
 	allSqlStatement := ctx.AllStmt()
 	stmts := make([]sqlstmt.Stmt, 0)
 	for _, sqlStatement := range allSqlStatement {
-		stmts = append(stmts, sqlStatement.Accept(v).(sqlstmt.Stmt))
+		if stmt := base.Accept[sqlstmt.Stmt](v, sqlStatement); stmt != nil {
+			stmts = append(stmts, stmt)
+		}
 	}
 	return stmts
 }
@@ -66,8 +72,66 @@ func (v *PgsqlVisitor) VisitStmt(ctx *pgparser.StmtContext) interface{} {
 	if c := ctx.Altertablestmt(); c != nil {
 		cds := new(sqlstmt.AlterTable)
 		cds.Node = sqlstmt.NewNode(c.GetParser(), c)
+		if rc := c.Relation_expr(); rc != nil {
+			cds.TableName = v.getTableName(rc.Qualified_name())
+		}
 		return cds
 	}
+	if c := ctx.Createstmt(); c != nil {
+		ct := new(sqlstmt.CreateTable)
+		ct.Node = sqlstmt.NewNode(c.GetParser(), c)
+		ct.TableName = v.getTableName(c.Qualified_name(0))
+		return ct
+	}
+	if c := ctx.Createasstmt(); c != nil {
+		ct := new(sqlstmt.CreateTable)
+		ct.Node = sqlstmt.NewNode(c.GetParser(), c)
+		ct.TableName = v.getTableName(c.Create_as_target().Qualified_name())
+		ct.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, c.Selectstmt())
+		return ct
+	}
+	if c := ctx.Viewstmt(); c != nil {
+		cv := new(sqlstmt.CreateView)
+		cv.Node = sqlstmt.NewNode(c.GetParser(), c)
+		cv.TableName = v.getTableName(c.Qualified_name())
+		cv.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, c.Selectstmt())
+		return cv
+	}
+	if c := ctx.Indexstmt(); c != nil {
+		ci := new(sqlstmt.CreateIndex)
+		ci.Node = sqlstmt.NewNode(c.GetParser(), c)
+		ci.TableName = v.getTableName(c.Relation_expr().Qualified_name())
+		return ci
+	}
+	if c := ctx.Truncatestmt(); c != nil {
+		tt := new(sqlstmt.TruncateTable)
+		tt.Node = sqlstmt.NewNode(c.GetParser(), c)
+		for _, rc := range c.Relation_expr_list().AllRelation_expr() {
+			tt.TableNames = append(tt.TableNames, v.getTableName(rc.Qualified_name()))
+		}
+		return tt
+	}
+	if c := ctx.Dropstmt(); c != nil {
+		// 仅drop table及drop view解析表名，其余按规则归类
+		if otc, alc := c.Object_type_any_name(), c.Any_name_list(); otc != nil && alc != nil {
+			var tableNames []*sqlstmt.TableName
+			for _, anc := range alc.AllAny_name() {
+				tableNames = append(tableNames, v.getTableNameByAnyName(anc))
+			}
+			if otc.TABLE() != nil && otc.FOREIGN() == nil {
+				dt := new(sqlstmt.DropTable)
+				dt.Node = sqlstmt.NewNode(c.GetParser(), c)
+				dt.TableNames = tableNames
+				return dt
+			}
+			if otc.VIEW() != nil {
+				dv := new(sqlstmt.DropView)
+				dv.Node = sqlstmt.NewNode(c.GetParser(), c)
+				dv.TableNames = tableNames
+				return dv
+			}
+		}
+	}
 	if c := ctx.Dropdbstmt(); c != nil {
 		cds := new(sqlstmt.DropDatabase)
 		cds.Node = sqlstmt.NewNode(c.GetParser(), c)
@@ -78,30 +142,148 @@ func (v *PgsqlVisitor) VisitStmt(ctx *pgparser.StmtContext) interface{} {
 		cds.Node = sqlstmt.NewNode(c.GetParser(), c)
 		return cds
 	}
-	if explain := ctx.Explainstmt(); explain != nil {
-		otherRead := new(sqlstmt.OtherReadStmt)
-		otherRead.Node = sqlstmt.NewNode(explain.GetParser(), explain)
-		return otherRead
+	if c := ctx.Mergestmt(); c != nil {
+		merge := new(sqlstmt.MergeStmt)
+		merge.Node = sqlstmt.NewNode(c.GetParser(), c)
+		return merge
 	}
-	if c := ctx.Variableshowstmt(); c != nil {
-		otherRead := new(sqlstmt.OtherReadStmt)
-		otherRead.Node = sqlstmt.NewNode(c.GetParser(), c)
-		return otherRead
+	if c := ctx.Explainstmt(); c != nil {
+		return c.Accept(v)
 	}
-	return sqlstmt.NewNode(ctx.GetParser(), ctx)
+	if c := ctx.Callstmt(); c != nil {
+		return c.Accept(v)
+	}
+
+	// 其余语句按规则归类
+	var node *sqlstmt.Node
+	var rule int
+	if c, ok := ctx.GetChild(0).(antlr.ParserRuleContext); ok {
+		node = sqlstmt.NewNode(ctx.GetParser(), c)
+		rule = c.GetRuleIndex()
+	} else {
+		node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	}
+	switch rule {
+	case pgparser.PostgreSQLParserRULE_variableshowstmt:
+		return &sqlstmt.ShowStmt{Node: node}
+	case pgparser.PostgreSQLParserRULE_variablesetstmt, pgparser.PostgreSQLParserRULE_variableresetstmt,
+		pgparser.PostgreSQLParserRULE_constraintssetstmt:
+		return &sqlstmt.SetStmt{Node: node}
+	case pgparser.PostgreSQLParserRULE_transactionstmt, pgparser.PostgreSQLParserRULE_lockstmt:
+		return &sqlstmt.TclStmt{Node: node}
+	case pgparser.PostgreSQLParserRULE_grantstmt, pgparser.PostgreSQLParserRULE_grantrolestmt,
+		pgparser.PostgreSQLParserRULE_revokestmt, pgparser.PostgreSQLParserRULE_revokerolestmt,
+		pgparser.PostgreSQLParserRULE_alterdefaultprivilegesstmt,
+		pgparser.PostgreSQLParserRULE_createrolestmt, pgparser.PostgreSQLParserRULE_createuserstmt,
+		pgparser.PostgreSQLParserRULE_creategroupstmt, pgparser.PostgreSQLParserRULE_alterrolestmt,
+		pgparser.PostgreSQLParserRULE_alterrolesetstmt, pgparser.PostgreSQLParserRULE_altergroupstmt,
+		pgparser.PostgreSQLParserRULE_droprolestmt, pgparser.PostgreSQLParserRULE_reassignownedstmt,
+		pgparser.PostgreSQLParserRULE_dropownedstmt:
+		return &sqlstmt.DclStmt{Node: node}
+	case pgparser.PostgreSQLParserRULE_altereventtrigstmt, pgparser.PostgreSQLParserRULE_altercollationstmt,
+		pgparser.PostgreSQLParserRULE_alterdatabasestmt, pgparser.PostgreSQLParserRULE_alterdatabasesetstmt,
+		pgparser.PostgreSQLParserRULE_alterdomainstmt, pgparser.PostgreSQLParserRULE_alterenumstmt,
+		pgparser.PostgreSQLParserRULE_alterextensionstmt, pgparser.PostgreSQLParserRULE_alterextensioncontentsstmt,
+		pgparser.PostgreSQLParserRULE_alterfdwstmt, pgparser.PostgreSQLParserRULE_alterforeignserverstmt,
+		pgparser.PostgreSQLParserRULE_alterfunctionstmt, pgparser.PostgreSQLParserRULE_alterobjectdependsstmt,
+		pgparser.PostgreSQLParserRULE_alterobjectschemastmt, pgparser.PostgreSQLParserRULE_alterownerstmt,
+		pgparser.PostgreSQLParserRULE_alteroperatorstmt, pgparser.PostgreSQLParserRULE_altertypestmt,
+		pgparser.PostgreSQLParserRULE_alterpolicystmt, pgparser.PostgreSQLParserRULE_alterseqstmt,
+		pgparser.PostgreSQLParserRULE_altertblspcstmt, pgparser.PostgreSQLParserRULE_altercompositetypestmt,
+		pgparser.PostgreSQLParserRULE_alterpublicationstmt, pgparser.PostgreSQLParserRULE_altersubscriptionstmt,
+		pgparser.PostgreSQLParserRULE_alterstatsstmt, pgparser.PostgreSQLParserRULE_altertsconfigurationstmt,
+		pgparser.PostgreSQLParserRULE_altertsdictionarystmt, pgparser.PostgreSQLParserRULE_alterusermappingstmt,
+		pgparser.PostgreSQLParserRULE_alteropfamilystmt, pgparser.PostgreSQLParserRULE_commentstmt,
+		pgparser.PostgreSQLParserRULE_createamstmt, pgparser.PostgreSQLParserRULE_createasstmt,
+		pgparser.PostgreSQLParserRULE_createassertionstmt, pgparser.PostgreSQLParserRULE_createcaststmt,
+		pgparser.PostgreSQLParserRULE_createconversionstmt, pgparser.PostgreSQLParserRULE_createdomainstmt,
+		pgparser.PostgreSQLParserRULE_createextensionstmt, pgparser.PostgreSQLParserRULE_createfdwstmt,
+		pgparser.PostgreSQLParserRULE_createforeignserverstmt, pgparser.PostgreSQLParserRULE_createforeigntablestmt,
+		pgparser.PostgreSQLParserRULE_createfunctionstmt, pgparser.PostgreSQLParserRULE_creatematviewstmt,
+		pgparser.PostgreSQLParserRULE_createopclassstmt, pgparser.PostgreSQLParserRULE_createopfamilystmt,
+		pgparser.PostgreSQLParserRULE_createpublicationstmt, pgparser.PostgreSQLParserRULE_createpolicystmt,
+		pgparser.PostgreSQLParserRULE_createplangstmt, pgparser.PostgreSQLParserRULE_createschemastmt,
+		pgparser.PostgreSQLParserRULE_createseqstmt, pgparser.PostgreSQLParserRULE_createstmt,
+		pgparser.PostgreSQLParserRULE_createsubscriptionstmt, pgparser.PostgreSQLParserRULE_createstatsstmt,
+		pgparser.PostgreSQLParserRULE_createtransformstmt, pgparser.PostgreSQLParserRULE_createtrigstmt,
+		pgparser.PostgreSQLParserRULE_createeventtrigstmt, pgparser.PostgreSQLParserRULE_createusermappingstmt,
+		pgparser.PostgreSQLParserRULE_definestmt, pgparser.PostgreSQLParserRULE_dropcaststmt,
+		pgparser.PostgreSQLParserRULE_dropopclassstmt, pgparser.PostgreSQLParserRULE_dropopfamilystmt,
+		pgparser.PostgreSQLParserRULE_dropstmt, pgparser.PostgreSQLParserRULE_dropsubscriptionstmt,
+		pgparser.PostgreSQLParserRULE_droptransformstmt, pgparser.PostgreSQLParserRULE_dropusermappingstmt,
+		pgparser.PostgreSQLParserRULE_importforeignschemastmt, pgparser.PostgreSQLParserRULE_indexstmt,
+		pgparser.PostgreSQLParserRULE_removeaggrstmt, pgparser.PostgreSQLParserRULE_removefuncstmt,
+		pgparser.PostgreSQLParserRULE_removeoperstmt, pgparser.PostgreSQLParserRULE_renamestmt,
+		pgparser.PostgreSQLParserRULE_rulestmt, pgparser.PostgreSQLParserRULE_seclabelstmt,
+		pgparser.PostgreSQLParserRULE_truncatestmt, pgparser.PostgreSQLParserRULE_viewstmt:
+		return &sqlstmt.DdlStmt{Node: node}
+	}
+	return &sqlstmt.OtherStmt{Node: node}
+}
+
+func (v *PgsqlVisitor) VisitExplainstmt(ctx *pgparser.ExplainstmtContext) interface{} {
+	explain := new(sqlstmt.ExplainStmt)
+	explain.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	explain.Analyze = ctx.Analyze_keyword() != nil
+	if olc := ctx.Explain_option_list(); olc != nil {
+		for _, oec := range olc.AllExplain_option_elem() {
+			if oec.Explain_option_name().Analyze_keyword() == nil {
+				continue
+			}
+			// explain (analyze false) 不会执行语句
+			switch arg := oec.Explain_option_arg(); strings.ToLower(arg.GetText()) {
+			case "false", "off", "0":
+				explain.Analyze = false
+			default:
+				explain.Analyze = true
+			}
+		}
+	}
+	if esc := ctx.Explainablestmt(); esc != nil {
+		explain.Stmt = base.Accept[sqlstmt.Stmt](v, esc)
+	}
+	return explain
+}
+
+func (v *PgsqlVisitor) VisitExplainablestmt(ctx *pgparser.ExplainablestmtContext) interface{} {
+	if c := ctx.Selectstmt(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.Insertstmt(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.Updatestmt(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.Deletestmt(); c != nil {
+		return c.Accept(v)
+	}
+	os := new(sqlstmt.OtherStmt)
+	os.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	return os
 }
 
 func (v *PgsqlVisitor) VisitSelectstmt(ctx *pgparser.SelectstmtContext) interface{} {
 	if spnc := ctx.Select_no_parens(); spnc != nil {
 		return spnc.Accept(v)
 	}
+	if swpc := ctx.Select_with_parens(); swpc != nil {
+		return swpc.Accept(v)
+	}
 	selectstmt := new(sqlstmt.SelectStmt)
 	selectstmt.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	selectstmt.SideEffects = v.selectSideEffects(ctx)
 	return selectstmt
 }
 
 func (v *PgsqlVisitor) VisitSelect_with_parens(ctx *pgparser.Select_with_parensContext) interface{} {
-	return v.VisitChildren(ctx)
+	if spnc := ctx.Select_no_parens(); spnc != nil {
+		return spnc.Accept(v)
+	}
+	if swpc := ctx.Select_with_parens(); swpc != nil {
+		return swpc.Accept(v)
+	}
+	return nil
 }
 
 func (v *PgsqlVisitor) VisitSelect_no_parens(ctx *pgparser.Select_no_parensContext) interface{} {
@@ -111,40 +293,201 @@ func (v *PgsqlVisitor) VisitSelect_no_parens(ctx *pgparser.Select_no_parensConte
 
 	var limit *sqlstmt.Limit
 	if limitC := ctx.Select_limit(); limitC != nil {
-		limit = limitC.Accept(v).(*sqlstmt.Limit)
+		limit = base.Accept[*sqlstmt.Limit](v, limitC)
 	}
 	if limitC := ctx.Opt_select_limit(); limitC != nil {
-		limit = limitC.Accept(v).(*sqlstmt.Limit)
+		limit = base.Accept[*sqlstmt.Limit](v, limitC)
 	}
 
-	selectClause := ctx.Select_clause()
-	asis := selectClause.AllSimple_select_intersect()
+	var orderBy []*sqlstmt.OrderByExpr
+	if osc := ctx.Opt_sort_clause(); osc != nil && osc.Sort_clause() != nil {
+		orderBy = base.Accept[[]*sqlstmt.OrderByExpr](v, osc.Sort_clause())
+	}
+
+	var with *sqlstmt.WithClause
+	if wc := ctx.With_clause(); wc != nil {
+		with = base.Accept[*sqlstmt.WithClause](v, wc)
+	}
+
+	unionStmts := v.getUnionStmts(ctx.Select_clause())
+	head := unionStmts[0]
 	// 简单查询
-	if len(asis) == 1 {
+	if len(unionStmts) == 1 && head.QuerySpecification != nil {
 		sss := new(sqlstmt.SimpleSelectStmt)
 		sss.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-		sss.QuerySpecification = ctx.Select_clause().Accept(v).([]*sqlstmt.QuerySpecification)[0]
+		sss.SideEffects = v.selectSideEffects(ctx)
+		sss.With = with
+		sss.QuerySpecification = head.QuerySpecification
+		sss.QuerySpecification.OrderBy = orderBy
 		sss.QuerySpecification.Limit = limit
 		return sss
 	}
 
+	// 圆括号查询后跟order by、limit等，圆括号中未指定时等同于在其中指定
+	if len(unionStmts) == 1 {
+		ps := new(sqlstmt.ParenthesisSelect)
+		ps.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+		ps.SideEffects = v.selectSideEffects(ctx)
+		ps.With = with
+		ps.QueryExpr = head.QueryExpr
+		if qe := ps.QueryExpr; qe != nil && qe.QuerySpecification != nil {
+			if qe.QuerySpecification.OrderBy == nil {
+				qe.QuerySpecification.OrderBy = orderBy
+			}
+			if qe.QuerySpecification.Limit == nil {
+				qe.QuerySpecification.Limit = limit
+			}
+		}
+		return ps
+	}
+
 	uss := new(sqlstmt.UnionSelectStmt)
 	uss.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-
-	allUnion := selectClause.AllUNION()
-	// todo 赋值union信息
-	for _, union := range allUnion {
-		uss.UnionType = union.GetText()
-	}
-	// uss.QuerySpecifications = ctx.Select_clause().Accept(v).([]*sqlstmt.QuerySpecification)
+	uss.SideEffects = v.selectSideEffects(ctx)
+	uss.With = with
+	uss.QuerySpecification = head.QuerySpecification
+	uss.QueryExpr = head.QueryExpr
+	uss.UnionStmts = unionStmts[1:]
+	uss.UnionType = unionStmts[len(unionStmts)-1].UnionType
+	uss.OrderBy = orderBy
 	uss.Limit = limit
 	return uss
 }
 
+// getUnionStmts 按出现顺序展开union、except、intersect的各分支，第一个分支的UnionType为空
+// 圆括号中的集合运算同样展开，运算的优先级不保留
+func (v *PgsqlVisitor) getUnionStmts(ctx pgparser.ISelect_clauseContext) []*sqlstmt.UnionStmt {
+	unionStmts := make([]*sqlstmt.UnionStmt, 0)
+	var unionType string
+	var walk func(antlr.Tree)
+	walk = func(t antlr.Tree) {
+		for _, child := range t.GetChildren() {
+			switch c := child.(type) {
+			case *pgparser.Simple_select_intersectContext:
+				walk(c)
+			case *pgparser.Simple_select_pramaryContext:
+				unionStmts = v.appendUnionStmts(unionStmts, unionType, c)
+			case antlr.TerminalNode:
+				unionType = c.GetText()
+			}
+		}
+	}
+	walk(ctx)
+	return unionStmts
+}
+
+func (v *PgsqlVisitor) appendUnionStmts(unionStmts []*sqlstmt.UnionStmt, unionType string, ctx *pgparser.Simple_select_pramaryContext) []*sqlstmt.UnionStmt {
+	us := new(sqlstmt.UnionStmt)
+	us.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	us.UnionType = unionType
+
+	swpc := ctx.Select_with_parens()
+	if swpc == nil {
+		us.QuerySpecification = base.Accept[*sqlstmt.QuerySpecification](v, ctx)
+		return append(unionStmts, us)
+	}
+
+	switch s := base.Accept[sqlstmt.ISelectStmt](v, swpc).(type) {
+	case *sqlstmt.SimpleSelectStmt:
+		us.QueryExpr = &sqlstmt.QueryExpr{Node: s.Node, QuerySpecification: s.QuerySpecification}
+	case *sqlstmt.ParenthesisSelect:
+		us.QueryExpr = s.QueryExpr
+	case *sqlstmt.UnionSelectStmt:
+		us.QuerySpecification = s.QuerySpecification
+		us.QueryExpr = s.QueryExpr
+		unionStmts = append(unionStmts, us)
+		return append(unionStmts, s.UnionStmts...)
+	}
+	return append(unionStmts, us)
+}
+
+func (v *PgsqlVisitor) VisitWith_clause(ctx *pgparser.With_clauseContext) interface{} {
+	with := new(sqlstmt.WithClause)
+	with.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	with.Recursive = ctx.RECURSIVE() != nil
+	for _, ctec := range ctx.Cte_list().AllCommon_table_expr() {
+		with.CTEs = append(with.CTEs, base.Accept[*sqlstmt.CommonTableExpr](v, ctec))
+	}
+	return with
+}
+
+func (v *PgsqlVisitor) VisitCommon_table_expr(ctx *pgparser.Common_table_exprContext) interface{} {
+	cte := new(sqlstmt.CommonTableExpr)
+	cte.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	cte.Name = ctx.Name().GetText()
+	if onlc := ctx.Opt_name_list(); onlc != nil && onlc.Name_list() != nil {
+		cte.Columns = getNames(onlc.Name_list())
+	}
+	cte.Stmt = base.Accept[sqlstmt.Stmt](v, ctx.Preparablestmt())
+	return cte
+}
+
+func (v *PgsqlVisitor) VisitPreparablestmt(ctx *pgparser.PreparablestmtContext) interface{} {
+	if c := ctx.Selectstmt(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.Insertstmt(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.Updatestmt(); c != nil {
+		return c.Accept(v)
+	}
+	if c := ctx.Deletestmt(); c != nil {
+		return c.Accept(v)
+	}
+	return nil
+}
+
+// getWith 获取insert、update、delete中的with子句
+func (v *PgsqlVisitor) getWith(ctx pgparser.IOpt_with_clauseContext) *sqlstmt.WithClause {
+	if ctx == nil {
+		return nil
+	}
+	return base.Accept[*sqlstmt.WithClause](v, ctx.With_clause())
+}
+
+// selectSideEffects 查询的语法树(包含子查询及with中的语句)中select into、加锁等副作用
+// 首次调用时遍历一次语法树并记录其中各查询的副作用，之后访问嵌套的查询时不再重复遍历
+func (v *PgsqlVisitor) selectSideEffects(ctx antlr.Tree) sqlstmt.SelectSideEffects {
+	if effects, ok := v.sideEffects[ctx]; ok {
+		return effects
+	}
+	if v.sideEffects == nil {
+		v.sideEffects = make(map[antlr.Tree]sqlstmt.SelectSideEffects)
+	}
+	var walk func(antlr.Tree) sqlstmt.SelectSideEffects
+	walk = func(t antlr.Tree) sqlstmt.SelectSideEffects {
+		var effects sqlstmt.SelectSideEffects
+		switch ctx := t.(type) {
+		case *pgparser.Into_clauseContext:
+			if ctx.OpttempTableName() != nil {
+				effects.IntoTable = true
+			} else if ctx.Into_target() != nil {
+				effects.IntoVariables = true
+			}
+		case *pgparser.For_locking_itemContext:
+			effects.Locking = true
+		case *pgparser.Common_table_exprContext:
+			if psc := ctx.Preparablestmt(); psc != nil && psc.Selectstmt() == nil {
+				effects.ModifyingCTE = true
+			}
+		}
+		for _, child := range t.GetChildren() {
+			effects = effects.Merge(walk(child))
+		}
+		switch t.(type) {
+		case *pgparser.SelectstmtContext, *pgparser.Select_no_parensContext:
+			v.sideEffects[t] = effects
+		}
+		return effects
+	}
+	return walk(ctx)
+}
+
 func (v *PgsqlVisitor) VisitSelect_clause(ctx *pgparser.Select_clauseContext) interface{} {
 	qs := make([]*sqlstmt.QuerySpecification, 0)
 	for _, ssi := range ctx.AllSimple_select_intersect() {
-		qs = append(qs, ssi.Accept(v).(*sqlstmt.QuerySpecification))
+		qs = append(qs, base.Accept[*sqlstmt.QuerySpecification](v, ssi))
 	}
 
 	return qs
@@ -161,30 +504,121 @@ func (v *PgsqlVisitor) VisitSimple_select_intersect(ctx *pgparser.Simple_select_
 func (v *PgsqlVisitor) VisitSimple_select_pramary(ctx *pgparser.Simple_select_pramaryContext) interface{} {
 	qs := new(sqlstmt.QuerySpecification)
 	qs.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	qs.Distinct = ctx.Distinct_clause() != nil
+
+	if c := ctx.Opt_target_list(); c != nil {
+		qs.SelectElements = base.Accept[*sqlstmt.SelectElements](v, c.Target_list())
+	}
+	if c := ctx.Target_list(); c != nil {
+		qs.SelectElements = base.Accept[*sqlstmt.SelectElements](v, c)
+	}
 
 	if c := ctx.From_clause(); c != nil {
-		qs.From = c.Accept(v).(*sqlstmt.TableSources)
+		qs.From = base.Accept[*sqlstmt.TableSources](v, c)
+	}
+	// table t 等同于 select * from t
+	if c := ctx.Relation_expr(); c != nil {
+		atomTable := new(sqlstmt.AtomTableItem)
+		atomTable.Node = sqlstmt.NewNode(c.GetParser(), c)
+		atomTable.TableName = v.getTableName(c.Qualified_name())
+		qs.From = newTableSources(c.GetParser(), c, atomTable)
 	}
 
 	if c := ctx.Where_clause(); c != nil {
-		qs.Where = c.A_expr().Accept(v).(sqlstmt.IExpr)
+		qs.Where = base.Accept[sqlstmt.IExpr](v, c.A_expr())
+	}
+	if c := ctx.Group_clause(); c != nil && c.Group_by_list() != nil {
+		for _, gbic := range c.Group_by_list().AllGroup_by_item() {
+			if ac := gbic.A_expr(); ac != nil {
+				qs.GroupBy = append(qs.GroupBy, base.Accept[sqlstmt.IExpr](v, ac))
+				continue
+			}
+			// cube、rollup、grouping sets
+			expr := new(sqlstmt.Expr)
+			expr.Node = sqlstmt.NewNode(gbic.GetParser(), gbic)
+			expr.Subqueries = v.subqueries(gbic)
+			expr.Columns = v.columns(gbic)
+			qs.GroupBy = append(qs.GroupBy, expr)
+		}
+	}
+	if c := ctx.Having_clause(); c != nil {
+		qs.Having = base.Accept[sqlstmt.IExpr](v, c.A_expr())
 	}
 
 	return qs
 }
 
+func (v *PgsqlVisitor) VisitTarget_list(ctx *pgparser.Target_listContext) interface{} {
+	ses := new(sqlstmt.SelectElements)
+	ses.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+
+	eles := make([]sqlstmt.ISelectElement, 0)
+	for _, tec := range ctx.AllTarget_el() {
+		if tsc, ok := tec.(*pgparser.Target_starContext); ok {
+			ses.Star = tsc.STAR().GetText()
+			continue
+		}
+		eles = append(eles, base.Accept[sqlstmt.ISelectElement](v, tec))
+	}
+	ses.Elements = eles
+
+	return ses
+}
+
+func (v *PgsqlVisitor) VisitTarget_label(ctx *pgparser.Target_labelContext) interface{} {
+	see := new(sqlstmt.SelectExpressionElement)
+	see.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	see.Expr = base.Accept[sqlstmt.IExpr](v, ctx.A_expr())
+	if c := ctx.Collabel(); c != nil {
+		see.Alias = c.GetText()
+	}
+	if c := ctx.Identifier(); c != nil {
+		see.Alias = c.GetText()
+	}
+	return see
+}
+
+func (v *PgsqlVisitor) VisitSort_clause(ctx *pgparser.Sort_clauseContext) interface{} {
+	orderBy := make([]*sqlstmt.OrderByExpr, 0)
+	for _, sc := range ctx.Sortby_list().AllSortby() {
+		orderBy = append(orderBy, base.Accept[*sqlstmt.OrderByExpr](v, sc))
+	}
+	return orderBy
+}
+
+func (v *PgsqlVisitor) VisitSortby(ctx *pgparser.SortbyContext) interface{} {
+	obe := new(sqlstmt.OrderByExpr)
+	obe.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	obe.Expr = base.Accept[sqlstmt.IExpr](v, ctx.A_expr())
+	if oadc := ctx.Opt_asc_desc(); oadc != nil {
+		obe.Desc = oadc.DESC() != nil
+	}
+	return obe
+}
+
 func (v *PgsqlVisitor) VisitSelect_limit(ctx *pgparser.Select_limitContext) interface{} {
 	limit := new(sqlstmt.Limit)
 	limit.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
-	if lc := ctx.Limit_clause(); lc != nil {
+	lc := ctx.Limit_clause()
+	limit.Unlimited = lc == nil
+	if lc != nil {
+		limit.WithTies = lc.TIES() != nil
 		if lv := lc.Select_limit_value(); lv != nil {
+			limit.Unlimited = lv.ALL() != nil
 			limit.RowCount = cast.ToInt(lv.GetText())
+		} else if fv := lc.Select_fetch_first_value(); fv != nil {
+			limit.RowCount = cast.ToInt(fv.GetText())
+		} else if lc.FETCH() != nil {
+			// fetch first row only
+			limit.RowCount = 1
 		}
 	}
 	if oc := ctx.Offset_clause(); oc != nil {
 		if ov := oc.Select_offset_value(); ov != nil {
 			limit.Offset = cast.ToInt(ov.GetText())
+		} else if fv := oc.Select_fetch_first_value(); fv != nil {
+			limit.Offset = cast.ToInt(fv.GetText())
 		}
 	}
 	return limit
@@ -201,7 +635,7 @@ func (v *PgsqlVisitor) VisitFrom_clause(ctx *pgparser.From_clauseContext) interf
 	if c := ctx.From_list(); c != nil {
 		return c.Accept(v)
 	}
-	return sqlstmt.NewNode(ctx.GetParser(), ctx)
+	return nil
 }
 
 func (v *PgsqlVisitor) VisitFrom_list(ctx *pgparser.From_listContext) interface{} {
@@ -213,8 +647,12 @@ func (v *PgsqlVisitor) VisitFrom_list(ctx *pgparser.From_listContext) interface{
 
 	tableSources := make([]sqlstmt.ITableSource, 0)
 	allTableRefCtx := ctx.AllTable_ref()
+	// 逗号分隔的多个表解析为non_ansi_join
+	if c := ctx.Non_ansi_join(); c != nil {
+		allTableRefCtx = c.AllTable_ref()
+	}
 	for _, trc := range allTableRefCtx {
-		tableSources = append(tableSources, trc.Accept(v).(sqlstmt.ITableSource))
+		tableSources = append(tableSources, base.Accept[sqlstmt.ITableSource](v, trc))
 	}
 
 	ts.TableSources = tableSources
@@ -229,30 +667,191 @@ func (v *PgsqlVisitor) VisitTable_ref(ctx *pgparser.Table_refContext) interface{
 	tableSourceBase := new(sqlstmt.TableSourceBase)
 	tableSourceBase.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
-	atomTable := new(sqlstmt.AtomTableItem)
+	alias := getAlias(ctx.Opt_alias_clause())
+	children := ctx.GetChildren()
+	switch {
+	case ctx.Relation_expr() != nil:
+		c := ctx.Relation_expr()
+		atomTable := new(sqlstmt.AtomTableItem)
+		atomTable.Node = sqlstmt.NewNode(c.GetParser(), aliasedContext(c, ctx.Opt_alias_clause()))
+		atomTable.TableName = v.getTableName(c.Qualified_name())
+		atomTable.Alias = alias
+		tableSourceBase.TableSourceItem = atomTable
+	case ctx.Select_with_parens() != nil:
+		c := ctx.Select_with_parens()
+		sti := new(sqlstmt.SubqueryTableItem)
+		sti.Node = sqlstmt.NewNode(c.GetParser(), aliasedContext(c, ctx.Opt_alias_clause()))
+		sti.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, c)
+		sti.Alias = alias
+		tableSourceBase.TableSourceItem = sti
+	case ctx.OPEN_PAREN() != nil:
+		// 圆括号中的表及join，圆括号后为外层的join
+		closeIndex := len(children) - 1
+		for i, child := range children {
+			if tn, ok := child.(antlr.TerminalNode); ok && tn.GetSymbol().GetTokenType() == pgparser.PostgreSQLParserCLOSE_PAREN {
+				closeIndex = i
+				break
+			}
+		}
+		trc := ctx.Table_ref(0)
+		tsb := base.Accept[*sqlstmt.TableSourceBase](v, trc)
+		tsb.JoinParts = append(tsb.JoinParts, v.getJoinParts(children[2:closeIndex])...)
+		tsi := new(sqlstmt.TableSourcesItem)
+		tsi.Node = sqlstmt.NewNode(trc.GetParser(), trc)
+		tsi.TableSources = &sqlstmt.TableSources{Node: tsi.Node, TableSources: []sqlstmt.ITableSource{tsb}}
+		tableSourceBase.TableSourceItem = tsi
+		children = children[closeIndex+1:]
+	default:
+		// 函数、xmltable等
+		tsi := new(sqlstmt.TableSourceItem)
+		tsi.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+		tableSourceBase.TableSourceItem = tsi
+	}
 
-	if c := ctx.Relation_expr(); c != nil {
-		tableName := new(sqlstmt.TableName)
-		if qn := c.Qualified_name(); qn != nil {
-			if qc := qn.Colid(); qc != nil {
-				if c := qn.Indirection(); c != nil {
-					tableName.Owner = qc.GetText()
-					tableName.Identifier = sqlstmt.NewIdentifierValue(c.GetText())
-				} else {
-					tableName.Identifier = sqlstmt.NewIdentifierValue(qc.Identifier().GetText())
-				}
+	tableSourceBase.JoinParts = v.getJoinParts(children)
+	return tableSourceBase
+}
+
+// getJoinParts 按顺序解析table_ref中的join，join的表包含join时作为圆括号中的表
+func (v *PgsqlVisitor) getJoinParts(children []antlr.Tree) []sqlstmt.IJoinPart {
+	joinParts := make([]sqlstmt.IJoinPart, 0)
+	var natural bool
+	var outerType string
+	var joinPart *sqlstmt.JoinPart
+	for _, child := range children {
+		switch c := child.(type) {
+		case antlr.TerminalNode:
+			if c.GetSymbol().GetTokenType() == pgparser.PostgreSQLParserNATURAL {
+				natural = true
+			}
+		case *pgparser.Join_typeContext:
+			if c.INNER_P() == nil {
+				outerType = strings.ToUpper(c.GetStart().GetText())
+			}
+		case *pgparser.Table_refContext:
+			var jp sqlstmt.IJoinPart
+			switch {
+			case natural:
+				nj := new(sqlstmt.NaturalJoin)
+				nj.Type = outerType
+				jp, joinPart = nj, &nj.JoinPart
+			case outerType != "":
+				oj := new(sqlstmt.OuterJoin)
+				oj.Type = outerType
+				jp, joinPart = oj, &oj.JoinPart
+			default:
+				ij := new(sqlstmt.InnerJoin)
+				jp, joinPart = ij, &ij.JoinPart
+			}
+			joinPart.Node = sqlstmt.NewNode(c.GetParser(), c)
+			joinPart.TableSourceItem = v.getJoinTableSourceItem(c)
+			joinParts = append(joinParts, jp)
+			natural, outerType = false, ""
+		case *pgparser.Join_qualContext:
+			if joinPart == nil {
+				continue
+			}
+			if ac := c.A_expr(); ac != nil {
+				joinPart.On = base.Accept[sqlstmt.IExpr](v, ac)
+			}
+			if nlc := c.Name_list(); nlc != nil {
+				joinPart.Using = getNames(nlc)
 			}
 		}
-		atomTable.TableName = tableName
 	}
-	if c := ctx.Opt_alias_clause(); c != nil {
-		if aliasC := c.Table_alias_clause(); aliasC != nil {
-			atomTable.Alias = aliasC.Table_alias().GetText()
+	return joinParts
+}
+
+func (v *PgsqlVisitor) getJoinTableSourceItem(ctx *pgparser.Table_refContext) sqlstmt.ITableSourceItem {
+	tsb := base.Accept[*sqlstmt.TableSourceBase](v, ctx)
+	if len(tsb.JoinParts) == 0 {
+		return tsb.TableSourceItem
+	}
+	tsi := new(sqlstmt.TableSourcesItem)
+	tsi.Node = tsb.Node
+	tsi.TableSources = &sqlstmt.TableSources{Node: tsb.Node, TableSources: []sqlstmt.ITableSource{tsb}}
+	return tsi
+}
+
+// spanContext 以start、stop为起止的语法树节点，用于语法中无对应规则的片段，如values中的行
+func spanContext(parent antlr.ParserRuleContext, start, stop antlr.Token) antlr.ParserRuleContext {
+	span := antlr.NewBaseParserRuleContext(parent, -1)
+	span.SetStart(start)
+	span.SetStop(stop)
+	return span
+}
+
+// newTableSources 由单个表构造TableSources
+func newTableSources(parser antlr.Parser, ctx antlr.ParserRuleContext, item sqlstmt.ITableSourceItem) *sqlstmt.TableSources {
+	tableSources := new(sqlstmt.TableSources)
+	tableSources.Node = sqlstmt.NewNode(parser, ctx)
+
+	tableSourceBase := new(sqlstmt.TableSourceBase)
+	tableSourceBase.Node = sqlstmt.NewNode(parser, ctx)
+	tableSourceBase.TableSourceItem = item
+
+	tableSources.TableSources = []sqlstmt.ITableSource{tableSourceBase}
+	return tableSources
+}
+
+// getTableName qualified_name为[catalog.]schema.name时，Owner为name前的部分
+func (v *PgsqlVisitor) getTableName(ctx pgparser.IQualified_nameContext) *sqlstmt.TableName {
+	if ctx == nil {
+		return nil
+	}
+	tableName := new(sqlstmt.TableName)
+	tableName.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	name := ctx.Colid().GetText()
+	if ic := ctx.Indirection(); ic != nil {
+		els := ic.AllIndirection_el()
+		tableName.Owner = name
+		for _, el := range els[:len(els)-1] {
+			tableName.Owner += el.GetText()
 		}
+		name = els[len(els)-1].GetText()
 	}
+	tableName.Identifier = sqlstmt.NewIdentifierValue(name)
+	return tableName
+}
 
-	tableSourceBase.TableSourceItem = atomTable
-	return tableSourceBase
+// getTableNameByAnyName any_name与qualified_name相同，为以点分隔的名称
+func (v *PgsqlVisitor) getTableNameByAnyName(ctx pgparser.IAny_nameContext) *sqlstmt.TableName {
+	tableName := new(sqlstmt.TableName)
+	tableName.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	name := ctx.Colid().GetText()
+	if ac := ctx.Attrs(); ac != nil {
+		ans := ac.AllAttr_name()
+		tableName.Owner = name
+		for _, an := range ans[:len(ans)-1] {
+			tableName.Owner += "." + an.GetText()
+		}
+		name = ans[len(ans)-1].GetText()
+	}
+	tableName.Identifier = sqlstmt.NewIdentifierValue(name)
+	return tableName
+}
+
+// aliasedContext 表及其后的别名，使表的节点包含别名
+func aliasedContext(ctx antlr.ParserRuleContext, alias pgparser.IOpt_alias_clauseContext) antlr.ParserRuleContext {
+	if alias == nil || alias.Table_alias_clause() == nil {
+		return ctx
+	}
+	return spanContext(ctx, ctx.GetStart(), alias.GetStop())
+}
+
+func getAlias(ctx pgparser.IOpt_alias_clauseContext) string {
+	if ctx == nil || ctx.Table_alias_clause() == nil {
+		return ""
+	}
+	return ctx.Table_alias_clause().Table_alias().GetText()
+}
+
+func getNames(ctx pgparser.IName_listContext) []string {
+	names := make([]string, 0)
+	for _, nc := range ctx.AllName() {
+		names = append(names, nc.GetText())
+	}
+	return names
 }
 
 func (v *PgsqlVisitor) VisitAlias_clause(ctx *pgparser.Alias_clauseContext) interface{} {
@@ -283,10 +882,12 @@ func (v *PgsqlVisitor) VisitUpdatestmt(ctx *pgparser.UpdatestmtContext) interfac
 	updateStmt := new(sqlstmt.UpdateStmt)
 	updateStmt.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
+	updateStmt.With = v.getWith(ctx.Opt_with_clause())
 	updateStmt.TableSources = v.GetTableSourcesByrelation_expr_opt_alias(ctx.Relation_expr_opt_alias())
-	updateStmt.UpdatedElements = ctx.Set_clause_list().Accept(v).([]*sqlstmt.UpdatedElement)
+	updateStmt.UpdatedElements = base.Accept[[]*sqlstmt.UpdatedElement](v, ctx.Set_clause_list())
+	updateStmt.From = base.Accept[*sqlstmt.TableSources](v, ctx.From_clause())
 	if ec := ctx.Where_or_current_clause().A_expr(); ec != nil {
-		updateStmt.Where = ec.Accept(v).(sqlstmt.IExpr)
+		updateStmt.Where = base.Accept[sqlstmt.IExpr](v, ec)
 	}
 
 	return updateStmt
@@ -296,7 +897,7 @@ func (v *PgsqlVisitor) VisitSet_clause_list(ctx *pgparser.Set_clause_listContext
 	ues := make([]*sqlstmt.UpdatedElement, 0)
 	aucs := ctx.AllSet_clause()
 	for _, auc := range aucs {
-		ues = append(ues, auc.Accept(v).(*sqlstmt.UpdatedElement))
+		ues = append(ues, base.Accept[*sqlstmt.UpdatedElement](v, auc))
 	}
 	return ues
 }
@@ -305,13 +906,21 @@ func (v *PgsqlVisitor) VisitSet_clause(ctx *pgparser.Set_clauseContext) interfac
 	updateEle := new(sqlstmt.UpdatedElement)
 	updateEle.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
-	updateEle.ColumnName = ctx.Set_target().Accept(v).(*sqlstmt.ColumnName)
+	updateEle.ColumnName = base.Accept[*sqlstmt.ColumnName](v, ctx.Set_target())
 	if ac := ctx.A_expr(); ac != nil {
-		updateEle.Value = ac.Accept(v).(sqlstmt.IExpr)
+		updateEle.Value = v.exprOrDefault(ac)
 	}
 	return updateEle
 }
 
+// exprOrDefault values及赋值中的default被解析为表达式，为default时返回nil
+func (v *PgsqlVisitor) exprOrDefault(ctx pgparser.IA_exprContext) sqlstmt.IExpr {
+	if strings.EqualFold(ctx.GetText(), "default") {
+		return nil
+	}
+	return base.Accept[sqlstmt.IExpr](v, ctx)
+}
+
 func (v *PgsqlVisitor) VisitSet_target(ctx *pgparser.Set_targetContext) interface{} {
 	columnName := new(sqlstmt.ColumnName)
 	columnName.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
@@ -334,25 +943,131 @@ func (v *PgsqlVisitor) VisitSet_target_list(ctx *pgparser.Set_target_listContext
 }
 
 func (v *PgsqlVisitor) VisitA_expr(ctx *pgparser.A_exprContext) interface{} {
-	expr := new(sqlstmt.Expr)
-	expr.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	return expr
+	return v.expr(ctx.GetParser(), ctx)
 }
 
 func (v *PgsqlVisitor) VisitA_expr_qual(ctx *pgparser.A_expr_qualContext) interface{} {
+	return v.expr(ctx.GetParser(), ctx)
+}
+
+// expr and、or连接的条件解析为LogicalExpr，两侧为简单表达式的比较解析为BinaryComparisonPredicate，其他表达式仅收集其中的子查询及列
+// 表达式的各层语法规则只有一个子节点时没有对应的运算符，向下查找第一个有运算符的规则
+func (v *PgsqlVisitor) expr(parser antlr.Parser, ctx antlr.ParserRuleContext) sqlstmt.IExpr {
+	var tree antlr.Tree = ctx
+	for tree.GetChildCount() == 1 {
+		tree = tree.GetChild(0)
+	}
+	switch c := tree.(type) {
+	case *pgparser.A_expr_orContext:
+		le := new(sqlstmt.LogicalExpr)
+		le.Node = sqlstmt.NewNode(parser, ctx)
+		le.Operator = c.OR(0).GetText()
+		for _, ac := range c.AllA_expr_and() {
+			le.Exprs = append(le.Exprs, v.expr(parser, ac))
+		}
+		return le
+	case *pgparser.A_expr_andContext:
+		le := new(sqlstmt.LogicalExpr)
+		le.Node = sqlstmt.NewNode(parser, ctx)
+		le.Operator = c.AND(0).GetText()
+		for _, bc := range c.AllA_expr_between() {
+			le.Exprs = append(le.Exprs, v.expr(parser, bc))
+		}
+		return le
+	case *pgparser.A_expr_compareContext:
+		if op, ok := c.GetChild(1).(antlr.TerminalNode); ok && len(c.AllA_expr_like()) == 2 {
+			bcp := new(sqlstmt.BinaryComparisonPredicate)
+			bcp.Node = sqlstmt.NewNode(parser, c)
+			bcp.Left = v.predicate(parser, c.A_expr_like(0))
+			bcp.Right = v.predicate(parser, c.A_expr_like(1))
+			bcp.ComparisonOperator = op.GetText()
+			pe := new(sqlstmt.PredicateExpr)
+			pe.Node = sqlstmt.NewNode(parser, ctx)
+			pe.Predicate = bcp
+			return pe
+		}
+	}
 	expr := new(sqlstmt.Expr)
-	expr.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	expr.Node = sqlstmt.NewNode(parser, ctx)
+	expr.Subqueries = v.subqueries(ctx)
+	expr.Columns = v.columns(ctx)
 	return expr
 }
 
+// predicate 比较两侧的表达式，仅收集其中的子查询及列
+func (v *PgsqlVisitor) predicate(parser antlr.Parser, ctx antlr.ParserRuleContext) sqlstmt.IPredicate {
+	predicate := new(sqlstmt.Predicate)
+	predicate.Node = sqlstmt.NewNode(parser, ctx)
+	predicate.Subqueries = v.subqueries(ctx)
+	predicate.Columns = v.columns(ctx)
+	return predicate
+}
+
+// columns 收集表达式中引用的列，不包含子查询中的列
+func (v *PgsqlVisitor) columns(tree antlr.Tree) []*sqlstmt.ColumnName {
+	var columns []*sqlstmt.ColumnName
+	for _, child := range tree.GetChildren() {
+		switch c := child.(type) {
+		case *pgparser.Select_with_parensContext:
+			continue
+		case *pgparser.ColumnrefContext:
+			if column := base.Accept[*sqlstmt.ColumnName](v, c); column != nil {
+				columns = append(columns, column)
+			}
+			continue
+		}
+		columns = append(columns, v.columns(child)...)
+	}
+	return columns
+}
+
+// VisitColumnref 列名后可跟.字段或下标，t.*不视为列
+func (v *PgsqlVisitor) VisitColumnref(ctx *pgparser.ColumnrefContext) interface{} {
+	columnName := new(sqlstmt.ColumnName)
+	columnName.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+
+	names := []string{ctx.Colid().GetText()}
+	if ic := ctx.Indirection(); ic != nil {
+		for _, iec := range ic.AllIndirection_el() {
+			if iec.STAR() != nil {
+				return nil
+			}
+			if anc := iec.Attr_name(); anc != nil {
+				names = append(names, anc.GetText())
+			}
+		}
+	}
+	// 最后一个为列名，之前的为[schema.]表名
+	columnName.Owner = strings.Join(names[:len(names)-1], ".")
+	columnName.Identifier = sqlstmt.NewIdentifierValue(names[len(names)-1])
+	return columnName
+}
+
+// subqueries 收集表达式中的子查询，子查询中嵌套的子查询由其自身收集
+func (v *PgsqlVisitor) subqueries(tree antlr.Tree) []sqlstmt.ISelectStmt {
+	var selects []sqlstmt.ISelectStmt
+	for _, child := range tree.GetChildren() {
+		if swpc, ok := child.(*pgparser.Select_with_parensContext); ok {
+			selects = append(selects, base.Accept[sqlstmt.ISelectStmt](v, swpc))
+			continue
+		}
+		selects = append(selects, v.subqueries(child)...)
+	}
+	return selects
+}
+
 func (v *PgsqlVisitor) VisitDeletestmt(ctx *pgparser.DeletestmtContext) interface{} {
 	deletestmt := new(sqlstmt.DeleteStmt)
 	deletestmt.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
+	deletestmt.With = v.getWith(ctx.Opt_with_clause())
 	deletestmt.TableSources = v.GetTableSourcesByrelation_expr_opt_alias(ctx.Relation_expr_opt_alias())
+	if uc := ctx.Using_clause(); uc != nil {
+		deletestmt.Using = base.Accept[*sqlstmt.TableSources](v, uc.From_list())
+	}
 
 	if ec := ctx.Where_or_current_clause().A_expr(); ec != nil {
-		deletestmt.Where = ec.Accept(v).(sqlstmt.IExpr)
+		deletestmt.Where = base.Accept[sqlstmt.IExpr](v, ec)
 	}
 	return deletestmt
 }
@@ -360,22 +1075,103 @@ func (v *PgsqlVisitor) VisitDeletestmt(ctx *pgparser.DeletestmtContext) interfac
 func (v *PgsqlVisitor) VisitInsertstmt(ctx *pgparser.InsertstmtContext) interface{} {
 	insertstmt := new(sqlstmt.InsertStmt)
 	insertstmt.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	insertstmt.TableName = ctx.Insert_target().Accept(v).(*sqlstmt.TableName)
+	insertstmt.With = v.getWith(ctx.Opt_with_clause())
+	insertstmt.TableName = base.Accept[*sqlstmt.TableName](v, ctx.Insert_target())
+	irc := ctx.Insert_rest()
+	if clc := irc.Insert_column_list(); clc != nil {
+		for _, cic := range clc.AllInsert_column_item() {
+			insertstmt.Columns = append(insertstmt.Columns, base.Accept[*sqlstmt.ColumnName](v, cic))
+		}
+	}
+	if vc := valuesClause(irc.Selectstmt()); vc != nil {
+		insertstmt.Values = v.valuesRows(vc)
+	} else {
+		insertstmt.SelectStmt = base.Accept[sqlstmt.ISelectStmt](v, irc.Selectstmt())
+	}
+	if occ := ctx.Opt_on_conflict(); occ != nil {
+		if sclc := occ.Set_clause_list(); sclc != nil {
+			insertstmt.DuplicatedElements = base.Accept[[]*sqlstmt.UpdatedElement](v, sclc)
+		}
+		if wc := occ.Where_clause(); wc != nil && wc.A_expr() != nil {
+			insertstmt.ConflictWhere = base.Accept[sqlstmt.IExpr](v, wc.A_expr())
+		}
+	}
 	return insertstmt
 }
 
-func (v *PgsqlVisitor) VisitInsert_target(ctx *pgparser.Insert_targetContext) interface{} {
-	tableName := new(sqlstmt.TableName)
-	tableName.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	table := ctx.GetText()
-	if strings.Contains(table, ".") {
-		tableAndOwner := strings.Split(table, ".")
-		tableName.Identifier = sqlstmt.NewIdentifierValue(tableAndOwner[1])
-		tableName.Owner = tableAndOwner[0]
+// valuesClause insert的数据仅为values时返回values子句，否则返回nil
+func valuesClause(ctx pgparser.ISelectstmtContext) pgparser.IValues_clauseContext {
+	if ctx == nil || ctx.Select_no_parens() == nil {
+		return nil
+	}
+	snpc := ctx.Select_no_parens()
+	if snpc.With_clause() != nil || snpc.Select_limit() != nil || snpc.For_locking_clause() != nil ||
+		(snpc.Opt_sort_clause() != nil && snpc.Opt_sort_clause().GetText() != "") {
+		return nil
+	}
+	ssics := snpc.Select_clause().AllSimple_select_intersect()
+	if len(ssics) != 1 {
+		return nil
+	}
+	sspcs := ssics[0].AllSimple_select_pramary()
+	if len(sspcs) != 1 {
+		return nil
+	}
+	return sspcs[0].Values_clause()
+}
+
+// valuesRows values子句中的各行，行在语法中无对应的规则，以两侧的括号为起止
+func (v *PgsqlVisitor) valuesRows(ctx pgparser.IValues_clauseContext) []*sqlstmt.ValuesRow {
+	rows := make([]*sqlstmt.ValuesRow, 0)
+	lbs, rbs := ctx.AllOPEN_PAREN(), ctx.AllCLOSE_PAREN()
+	for i, elc := range ctx.AllExpr_list() {
+		if i >= len(lbs) || i >= len(rbs) {
+			break
+		}
+		row := new(sqlstmt.ValuesRow)
+		row.Node = sqlstmt.NewNode(ctx.GetParser(), spanContext(ctx, lbs[i].GetSymbol(), rbs[i].GetSymbol()))
+		for _, ec := range elc.AllA_expr() {
+			row.Exprs = append(row.Exprs, v.exprOrDefault(ec))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (v *PgsqlVisitor) VisitInsert_column_item(ctx *pgparser.Insert_column_itemContext) interface{} {
+	columnName := new(sqlstmt.ColumnName)
+	columnName.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	// 列名后的字段或下标，如 a.b、a[1]
+	if ic := ctx.Opt_indirection(); ic != nil && ic.GetText() != "" {
+		columnName.Owner = ctx.Colid().GetText()
+		columnName.Identifier = sqlstmt.NewIdentifierValue(ic.GetText())
 	} else {
-		tableName.Identifier = sqlstmt.NewIdentifierValue(table)
+		columnName.Identifier = sqlstmt.NewIdentifierValue(ctx.Colid().GetText())
 	}
-	return tableName
+	return columnName
+}
+
+func (v *PgsqlVisitor) VisitCallstmt(ctx *pgparser.CallstmtContext) interface{} {
+	call := new(sqlstmt.CallStmt)
+	call.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
+	fac := ctx.Func_application()
+	var faecs []pgparser.IFunc_arg_exprContext
+	if falc := fac.Func_arg_list(); falc != nil {
+		faecs = falc.AllFunc_arg_expr()
+	}
+	// variadic的参数
+	if faec := fac.Func_arg_expr(); faec != nil {
+		faecs = append(faecs, faec)
+	}
+	for _, faec := range faecs {
+		call.Args = append(call.Args, base.Accept[sqlstmt.IExpr](v, faec.A_expr()))
+	}
+	return call
+}
+
+// VisitInsert_target 表名不包含as指定的别名
+func (v *PgsqlVisitor) VisitInsert_target(ctx *pgparser.Insert_targetContext) interface{} {
+	return v.getTableName(ctx.Qualified_name())
 }
 
 func (v *PgsqlVisitor) VisitInsert_rest(ctx *pgparser.Insert_restContext) interface{} {
@@ -383,30 +1179,16 @@ func (v *PgsqlVisitor) VisitInsert_rest(ctx *pgparser.Insert_restContext) interf
 }
 
 func (v *PgsqlVisitor) GetTableSourcesByrelation_expr_opt_alias(ctx pgparser.IRelation_expr_opt_aliasContext) *sqlstmt.TableSources {
-	tableSources := new(sqlstmt.TableSources)
-	tableSources.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-
 	atomTable := new(sqlstmt.AtomTableItem)
 	atomTable.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
 
 	if c := ctx.Relation_expr(); c != nil {
-		tableName := new(sqlstmt.TableName)
-		if qn := c.Qualified_name(); qn != nil {
-			if qc := qn.Colid(); qc != nil {
-				tableName.Identifier = sqlstmt.NewIdentifierValue(qc.Identifier().GetText())
-			}
-		}
-		atomTable.TableName = tableName
+		atomTable.TableName = v.getTableName(c.Qualified_name())
 	}
 
 	if c := ctx.Colid(); c != nil {
 		atomTable.Alias = c.GetText()
 	}
 
-	tableSourceBase := new(sqlstmt.TableSourceBase)
-	tableSourceBase.Node = sqlstmt.NewNode(ctx.GetParser(), ctx)
-	tableSourceBase.TableSourceItem = atomTable
-
-	tableSources.TableSources = []sqlstmt.ITableSource{tableSourceBase}
-	return tableSources
+	return newTableSources(ctx.GetParser(), ctx, atomTable)
 }
@@ -0,0 +1,896 @@
+package sqlparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/may-fly/go-sqlparser/base"
+)
+
+// DefaultMaxStatementSize StatementReader默认的单条语句最大字节数
+const DefaultMaxStatementSize = 64 << 20
+
+const readChunkSize = 64 << 10
+
+// ErrStatementTooLong 单条语句超过StatementReader的最大字节数
+var ErrStatementTooLong = errors.New("sqlparser: statement too long")
+
+// lexRules 流式切割时各方言的词法差异
+type lexRules struct {
+	backslashEscape  bool     // '...'及"..."中反斜杠为转义符
+	backtick         bool     // `...`引用标识符
+	hashComment      bool     // #单行注释
+	delimiterCommand bool     // 支持mysql客户端的DELIMITER命令
+	versionComment   bool     // /*! ... */为可执行的注释
+	dashCommentSpace bool     // --后需跟空白字符才为注释
+	dollarQuote      bool     // $tag$...$tag$字符串
+	nestedComment    bool     // 块注释可嵌套
+	escapeString     bool     // E'...'中反斜杠为转义符
+	psqlCommand      bool     // 支持psql的元命令(\connect等)及COPY ... FROM STDIN后的数据块
+	endSuffixes      []string // END IF、END LOOP等不关闭BEGIN块的END后缀
+}
+
+var dialectLexRules = map[DbDialect]*lexRules{
+	Mysql: {
+		backslashEscape:  true,
+		backtick:         true,
+		hashComment:      true,
+		delimiterCommand: true,
+		versionComment:   true,
+		dashCommentSpace: true,
+		endSuffixes:      []string{"IF", "LOOP", "WHILE", "REPEAT"},
+	},
+	Pgsql: {
+		dollarQuote:   true,
+		nestedComment: true,
+		escapeString:  true,
+		psqlCommand:   true,
+	},
+}
+
+type scanState int
+
+const (
+	stateNormal scanState = iota
+	stateDash
+	stateDashDash
+	stateSlash
+	stateLineComment
+	stateBlockCommentOpen
+	stateBlockComment
+	stateBlockCommentStar
+	stateBlockCommentSlash
+	stateSingleQuote
+	stateDoubleQuote
+	stateEscapeString
+	stateBacktick
+	stateEscaped
+	stateDollarOpen
+	stateDollarBody
+	stateDollarClose
+	stateDelimiterCommand
+	stateMetaCommand
+	stateCopyLineRest
+	stateCopyLineStart
+	stateCopyData
+	stateCopyBackslash
+	stateCopyBackslashDot
+	stateCopyBackslashDotCR
+)
+
+type scanStatus int
+
+const (
+	scanExhausted  scanStatus = iota // data扫描完毕
+	scanTerminated                   // 遇到语句结束符
+	scanNeedMore                     // 需读取更多数据才能判断是否为自定义结束符
+	scanCommand                      // 遇到DELIMITER等客户端命令，命令本身不作为语句输出
+)
+
+// maxKeywordLen 超过该长度的单词不可能是关键字，无需保留
+const maxKeywordLen = 9
+
+// StatementReader 按方言词法流式切割多条sql
+// 内存中只保留当前语句，适用于mysqldump、pg_dump等大文件，语句的行列及字节偏移与Split一致
+// mysql支持客户端的DELIMITER命令，命令本身不会返回，自定义结束符不包含在语句文本中
+// pgsql支持psql的元命令及COPY ... FROM STDIN后的数据块，以独立的片段返回(base.SegmentMetaCommand、base.SegmentCopyData)，
+// 数据块不包含结束行(\.)
+//
+//	reader, err := sqlparser.NewStatementReader(sqlparser.Mysql, file)
+//	for reader.Next() {
+//		sql := reader.Statement()
+//	}
+//	if err := reader.Err(); err != nil {
+//	}
+type StatementReader struct {
+	r       io.Reader
+	rules   *lexRules
+	maxSize int
+
+	chunk    []byte
+	pos      int
+	end      int
+	segStart int
+	eof      bool
+	err      error
+
+	// 当前语句
+	buf        []byte
+	trimEnd    int // 需从buf末尾去除的自定义结束符、数据块结束符(\.)或回车的长度
+	current    base.SingleSQL
+	started    bool
+	meaningful bool
+	commented  bool
+	statement  base.SingleSQL
+
+	// 当前位置，行列从0开始，列按字符计数
+	offset int
+	line   int
+	column int
+
+	// 词法状态
+	state        scanState
+	pendingLine  int
+	pendingCol   int
+	commentDepth int
+	execComment  bool
+	escapedState scanState
+	delimiter    []byte // DELIMITER命令设置的结束符，为空时以分号结束
+	command      []byte
+	tag          []byte
+	tagIndex     int
+
+	// 关键字状态，用于识别CREATE语句中的BEGIN ... END块
+	word       []byte
+	wordLen    int
+	firstWord  string
+	depth      int
+	pendingEnd bool
+	copyFrom   bool // COPY语句中的上一个单词为FROM
+	copyStdin  bool // COPY ... FROM STDIN，语句之后为数据块
+}
+
+// NewStatementReader 创建流式sql读取器
+// @param dialect 方言
+// @param r 多条sql
+func NewStatementReader(dialect DbDialect, r io.Reader) (*StatementReader, error) {
+	rules, ok := dialectLexRules[dialect]
+	if !ok {
+		return nil, &UnknownDialectError{Dialect: dialect}
+	}
+	return &StatementReader{
+		r:       r,
+		rules:   rules,
+		maxSize: DefaultMaxStatementSize,
+		word:    make([]byte, 0, maxKeywordLen),
+	}, nil
+}
+
+// SetMaxStatementSize 设置单条语句最大字节数，超过时Err返回ErrStatementTooLong，需在Next之前调用
+func (sr *StatementReader) SetMaxStatementSize(size int) {
+	sr.maxSize = size
+}
+
+// Statement 返回Next读取到的语句
+func (sr *StatementReader) Statement() base.SingleSQL {
+	return sr.statement
+}
+
+// Err 返回读取过程中的首个非EOF错误
+func (sr *StatementReader) Err() error {
+	if sr.err == io.EOF {
+		return nil
+	}
+	return sr.err
+}
+
+// Next 读取下一条语句，读取完毕或出错时返回false
+func (sr *StatementReader) Next() bool {
+	if sr.err != nil {
+		return false
+	}
+	if sr.chunk == nil {
+		sr.chunk = make([]byte, readChunkSize)
+	}
+
+	for {
+		if sr.pos < sr.end {
+			n, status := sr.scan(sr.chunk[sr.pos:sr.end])
+			sr.pos += n
+			switch status {
+			case scanTerminated:
+				sr.buf = append(sr.buf, sr.chunk[sr.segStart:sr.pos]...)
+				sr.segStart = sr.pos
+				sr.buf = sr.buf[:len(sr.buf)-sr.trimEnd]
+				if sr.checkSize() {
+					sr.emit()
+					return true
+				}
+				return false
+			case scanCommand:
+				sr.segStart = sr.pos
+				sr.buf = sr.buf[:0]
+				sr.reset()
+				continue
+			}
+		}
+
+		sr.buf = append(sr.buf, sr.chunk[sr.segStart:sr.pos]...)
+		if !sr.checkSize() {
+			return false
+		}
+		if sr.eof && sr.pos == sr.end {
+			return sr.finish()
+		}
+		sr.fill()
+	}
+}
+
+// fill 将未扫描的字节移至块首，并读取更多数据
+func (sr *StatementReader) fill() {
+	sr.end = copy(sr.chunk, sr.chunk[sr.pos:sr.end])
+	sr.pos, sr.segStart = 0, 0
+	for n := 0; n == 0 && !sr.eof; {
+		var err error
+		n, err = sr.r.Read(sr.chunk[sr.end:])
+		sr.end += n
+		if err == io.EOF {
+			sr.eof = true
+		} else if err != nil {
+			sr.err = err
+			sr.eof = true
+		}
+	}
+}
+
+func (sr *StatementReader) checkSize() bool {
+	if len(sr.buf) > sr.maxSize {
+		sr.err = fmt.Errorf("%w: statement at byte offset %d exceeds %d bytes", ErrStatementTooLong, sr.current.ByteOffsetStart, sr.maxSize)
+		return false
+	}
+	return true
+}
+
+// finish 输入结束，处理末尾未以分号结束的语句
+func (sr *StatementReader) finish() bool {
+	if sr.err != nil {
+		return false
+	}
+	switch sr.state {
+	case stateDash, stateSlash:
+		sr.pendingMeaningful()
+	case stateDashDash:
+		sr.commented = true
+	case stateDelimiterCommand:
+		sr.setDelimiter()
+		sr.buf = sr.buf[:0]
+		sr.reset()
+	case stateCopyBackslashDot:
+		// 数据块以\.结束
+		sr.trimEnd = 2
+		sr.buf = sr.buf[:len(sr.buf)-sr.trimEnd]
+	}
+	sr.state = stateNormal
+	sr.endPending()
+	if !sr.started || (!sr.meaningful && !sr.commented) {
+		sr.err = io.EOF
+		return false
+	}
+	sr.emit()
+	return true
+}
+
+// emit 以buf作为当前语句输出，并重置语句状态
+func (sr *StatementReader) emit() {
+	if !sr.meaningful {
+		sr.current.Empty = true
+		sr.current.FirstStatementLine, sr.current.FirstStatementColumn = sr.current.LastLine, sr.current.LastColumn
+	}
+	sr.current.Text = string(sr.buf)
+	sr.current.ByteOffsetEnd = sr.current.ByteOffsetStart + len(sr.buf)
+	sr.statement = sr.current
+
+	if cap(sr.buf) > 4*readChunkSize {
+		// 不保留超大语句的缓冲区
+		sr.buf = nil
+	} else {
+		sr.buf = sr.buf[:0]
+	}
+	sr.reset()
+}
+
+// reset 重置当前语句的状态
+func (sr *StatementReader) reset() {
+	sr.trimEnd = 0
+	sr.current = base.SingleSQL{}
+	sr.started = false
+	sr.meaningful = false
+	sr.commented = false
+	sr.firstWord = ""
+	sr.depth = 0
+	sr.pendingEnd = false
+	sr.copyFrom = false
+	sr.copyStdin = false
+}
+
+// scan 扫描data直至语句结束符或客户端命令，返回已扫描的字节数及扫描结果
+// 字符串、注释、单词等连续内容在内层循环中批量跳过
+func (sr *StatementReader) scan(data []byte) (int, scanStatus) {
+	rules := sr.rules
+	state := sr.state
+	delimiter := sr.delimiter
+	line, column := sr.line, sr.column
+	i := 0
+	status := scanExhausted
+
+	if !sr.started && len(data) > 0 {
+		sr.started = true
+		sr.current.BaseLine = line
+		sr.current.ByteOffsetStart = sr.offset
+		if state == stateCopyLineStart {
+			sr.current.Kind = base.SegmentCopyData
+		}
+	}
+	// plain 语句已有内容且无未决的END时，非关键字的单词及符号无需更新语句状态
+	plain := sr.meaningful && sr.firstWord != "" && !sr.pendingEnd
+
+loop:
+	for i < len(data) {
+		b := data[i]
+
+		switch state {
+		case stateNormal:
+			if len(delimiter) > 0 && b == delimiter[0] {
+				if i+len(delimiter) > len(data) && !sr.eof {
+					status = scanNeedMore
+					break loop
+				}
+				if bytes.HasPrefix(data[i:], delimiter) {
+					sr.endWord()
+					for _, c := range delimiter {
+						if c&0xC0 != 0x80 {
+							column++
+						}
+					}
+					i += len(delimiter)
+					sr.trimEnd = len(delimiter)
+					status = scanTerminated
+					break loop
+				}
+			}
+			if isWordByte(b) && !(b == '$' && rules.dollarQuote && sr.wordLen == 0) {
+				if sr.wordLen == 0 {
+					sr.markMeaningful(line, column)
+				}
+				for {
+					if sr.wordLen < maxKeywordLen {
+						sr.word = append(sr.word, b)
+					}
+					sr.wordLen++
+					sr.current.LastLine, sr.current.LastColumn = line, column
+					if b&0xC0 != 0x80 {
+						column++
+					}
+					i++
+					if i == len(data) {
+						break loop
+					}
+					b = data[i]
+					if !isWordByte(b) || len(delimiter) > 0 && b == delimiter[0] {
+						continue loop
+					}
+				}
+			}
+			word := ""
+			if sr.wordLen > 0 {
+				if plain && (sr.wordLen > maxKeywordLen || !isKeywordStart(sr.word[0])) {
+					sr.word = sr.word[:0]
+					sr.wordLen = 0
+				} else {
+					word = sr.endWord()
+					plain = sr.meaningful && sr.firstWord != "" && !sr.pendingEnd
+				}
+			}
+			if word == "DELIMITER" && sr.firstWord == word && rules.delimiterCommand {
+				// DELIMITER命令的参数至行尾
+				state = stateDelimiterCommand
+				sr.command = sr.command[:0]
+				continue
+			}
+
+			switch b {
+			case ' ', '\t', '\r', '\n', '\f', '\v':
+			case '-':
+				state, sr.pendingLine, sr.pendingCol = stateDash, line, column
+			case '/':
+				state, sr.pendingLine, sr.pendingCol = stateSlash, line, column
+			case ';':
+				sr.markMeaningfulToken()
+				sr.markLast(line, column)
+				if len(delimiter) == 0 && sr.depth == 0 {
+					status = scanTerminated
+					if sr.copyStdin {
+						// 数据块从下一行开始
+						state = stateCopyLineRest
+					}
+				}
+			default:
+				switch {
+				case b == '#' && rules.hashComment:
+					state = stateLineComment
+					sr.commented = true
+				case b == '\'':
+					if rules.escapeString && word == "E" {
+						state = stateEscapeString
+					} else {
+						state = stateSingleQuote
+					}
+				case b == '"':
+					state = stateDoubleQuote
+				case b == '`' && rules.backtick:
+					state = stateBacktick
+				case b == '$' && rules.dollarQuote:
+					state = stateDollarOpen
+					sr.tag = sr.tag[:0]
+				case b == '\\' && rules.psqlCommand && !sr.meaningful:
+					// psql元命令至行尾
+					state = stateMetaCommand
+					sr.current.Kind = base.SegmentMetaCommand
+				}
+				if state != stateLineComment {
+					if !plain {
+						sr.markMeaningful(line, column)
+						sr.markMeaningfulToken()
+					}
+					sr.current.LastLine, sr.current.LastColumn = line, column
+				}
+			}
+
+		case stateDash:
+			if b != '-' {
+				state = stateNormal
+				sr.pendingMeaningful()
+				continue
+			}
+			if rules.dashCommentSpace {
+				state = stateDashDash
+			} else {
+				state = stateLineComment
+				sr.commented = true
+			}
+
+		case stateDashDash:
+			state = stateNormal
+			// mysql中--后需跟空白或控制字符才为注释
+			if b > ' ' {
+				sr.pendingMeaningful()
+				continue
+			}
+			sr.commented = true
+			if b != '\n' {
+				state = stateLineComment
+			}
+
+		case stateSlash:
+			if b != '*' {
+				state = stateNormal
+				sr.pendingMeaningful()
+				continue
+			}
+			state = stateBlockCommentOpen
+			sr.commentDepth = 1
+			sr.execComment = false
+
+		case stateBlockCommentOpen:
+			state = stateBlockComment
+			if b == '!' && rules.versionComment {
+				// mysql的/*!50003 ... */会被服务端执行，属于语句内容
+				sr.execComment = true
+				sr.pendingMeaningful()
+			} else {
+				sr.commented = true
+			}
+			continue
+
+		case stateLineComment:
+			for b != '\n' {
+				if !sr.meaningful {
+					sr.markLast(line, column)
+				}
+				if b&0xC0 != 0x80 {
+					column++
+				}
+				i++
+				if i == len(data) {
+					break loop
+				}
+				b = data[i]
+			}
+			state = stateNormal
+
+		case stateBlockComment:
+			for b != '*' && !(b == '/' && rules.nestedComment) {
+				if b == '\n' {
+					line++
+					column = 0
+				} else if b&0xC0 != 0x80 {
+					column++
+				}
+				i++
+				if i == len(data) {
+					break loop
+				}
+				b = data[i]
+			}
+			sr.markComment(line, column)
+			if b == '*' {
+				state = stateBlockCommentStar
+			} else {
+				state = stateBlockCommentSlash
+			}
+
+		case stateBlockCommentStar:
+			sr.markComment(line, column)
+			switch b {
+			case '/':
+				sr.commentDepth--
+				if sr.commentDepth == 0 {
+					state = stateNormal
+				} else {
+					state = stateBlockComment
+				}
+			case '*':
+			default:
+				state = stateBlockComment
+				continue
+			}
+
+		case stateBlockCommentSlash:
+			state = stateBlockComment
+			if b != '*' {
+				continue
+			}
+			sr.commentDepth++
+
+		case stateSingleQuote, stateDoubleQuote, stateBacktick, stateEscapeString:
+			quote, escape := byte('\''), rules.backslashEscape
+			switch state {
+			case stateDoubleQuote:
+				quote = '"'
+			case stateBacktick:
+				quote, escape = '`', false
+			case stateEscapeString:
+				escape = true
+			}
+			for b != quote && !(b == '\\' && escape) {
+				lastLine, lastColumn := line, column
+				if b == '\n' {
+					line++
+					column = 0
+				} else if b&0xC0 != 0x80 {
+					column++
+				}
+				i++
+				if i == len(data) {
+					sr.markLast(lastLine, lastColumn)
+					break loop
+				}
+				b = data[i]
+			}
+			sr.markLast(line, column)
+			if b == quote {
+				state = stateNormal
+			} else {
+				sr.escapedState, state = state, stateEscaped
+			}
+
+		case stateEscaped:
+			sr.markLast(line, column)
+			state = sr.escapedState
+
+		case stateDollarOpen:
+			sr.markLast(line, column)
+			if b == '$' {
+				state = stateDollarBody
+			} else if isTagByte(b, len(sr.tag) == 0) {
+				sr.tag = append(sr.tag, b)
+			} else {
+				// 非美元符引用，如$1参数
+				state = stateNormal
+				sr.wordLen = len(sr.tag)
+				continue
+			}
+
+		case stateDollarBody:
+			for b != '$' {
+				lastLine, lastColumn := line, column
+				if b == '\n' {
+					line++
+					column = 0
+				} else if b&0xC0 != 0x80 {
+					column++
+				}
+				i++
+				if i == len(data) {
+					sr.markLast(lastLine, lastColumn)
+					break loop
+				}
+				b = data[i]
+			}
+			sr.markLast(line, column)
+			state = stateDollarClose
+			sr.tagIndex = 0
+
+		case stateDollarClose:
+			if sr.tagIndex < len(sr.tag) && b == sr.tag[sr.tagIndex] {
+				sr.tagIndex++
+			} else if sr.tagIndex == len(sr.tag) && b == '$' {
+				state = stateNormal
+			} else {
+				state = stateDollarBody
+				continue
+			}
+			sr.markLast(line, column)
+
+		case stateMetaCommand:
+			for b != '\n' {
+				if b != '\r' {
+					sr.markLast(line, column)
+				}
+				if b&0xC0 != 0x80 {
+					column++
+				}
+				i++
+				if i == len(data) {
+					break loop
+				}
+				b = data[i]
+			}
+			// 换行符属于下一条语句
+			if i > 0 && data[i-1] == '\r' {
+				sr.trimEnd = 1
+			}
+			state = stateNormal
+			status = scanTerminated
+			break loop
+
+		case stateCopyLineRest:
+			if b == '\n' {
+				state = stateCopyLineStart
+				status = scanCommand
+			}
+
+		case stateCopyLineStart:
+			sr.markMeaningful(line, column)
+			if b == '\\' {
+				state, sr.pendingLine, sr.pendingCol = stateCopyBackslash, line, column
+			} else {
+				state = stateCopyData
+				continue
+			}
+
+		case stateCopyData:
+			j := bytes.IndexByte(data[i:], '\n')
+			if j < 0 {
+				column += utf8.RuneCount(data[i:])
+				sr.markLast(line, column-1)
+				i = len(data)
+				break loop
+			}
+			column += utf8.RuneCount(data[i : i+j])
+			i += j
+			b = '\n'
+			sr.markLast(line, column)
+			state = stateCopyLineStart
+
+		case stateCopyBackslash:
+			if b != '.' {
+				state = stateCopyData
+				continue
+			}
+			state = stateCopyBackslashDot
+
+		case stateCopyBackslashDot:
+			switch b {
+			case '\n':
+				sr.trimEnd = 3
+			case '\r':
+				state = stateCopyBackslashDotCR
+			default:
+				state = stateCopyData
+				continue
+			}
+			if state == stateCopyBackslashDot {
+				state = stateNormal
+				status = scanTerminated
+			}
+
+		case stateCopyBackslashDotCR:
+			if b != '\n' {
+				state = stateCopyData
+				continue
+			}
+			sr.trimEnd = 4
+			state = stateNormal
+			status = scanTerminated
+
+		case stateDelimiterCommand:
+			if b == '\n' {
+				sr.setDelimiter()
+				state = stateNormal
+				status = scanCommand
+			} else {
+				sr.command = append(sr.command, b)
+			}
+		}
+
+		if b == '\n' {
+			line++
+			column = 0
+		} else if b&0xC0 != 0x80 {
+			column++
+		}
+		i++
+		if status != scanExhausted {
+			break
+		}
+		plain = sr.meaningful && sr.firstWord != "" && !sr.pendingEnd
+	}
+
+	sr.state = state
+	sr.line, sr.column = line, column
+	sr.offset += i
+	return i, status
+}
+
+// setDelimiter 执行DELIMITER命令，参数为空白分隔的首个单词，为分号时恢复默认
+func (sr *StatementReader) setDelimiter() {
+	fields := bytes.Fields(sr.command)
+	if len(fields) == 0 {
+		return
+	}
+	if string(fields[0]) == ";" {
+		sr.delimiter = nil
+	} else {
+		sr.delimiter = append(sr.delimiter[:0], fields[0]...)
+	}
+}
+
+// pendingMeaningful 暂存的'-'或'/'不是注释的开始，而是语句内容
+func (sr *StatementReader) pendingMeaningful() {
+	sr.markMeaningful(sr.pendingLine, sr.pendingCol)
+	sr.markMeaningfulToken()
+	sr.markLast(sr.pendingLine, sr.pendingCol)
+}
+
+func (sr *StatementReader) markMeaningful(line, column int) {
+	if !sr.meaningful {
+		sr.meaningful = true
+		sr.current.FirstStatementLine, sr.current.FirstStatementColumn = line, column
+	}
+}
+
+func (sr *StatementReader) markLast(line, column int) {
+	sr.current.LastLine, sr.current.LastColumn = line, column
+}
+
+// markComment 空语句的末尾位置为注释的末尾，可执行注释的末尾为语句内容的末尾
+func (sr *StatementReader) markComment(line, column int) {
+	if sr.execComment || !sr.meaningful {
+		sr.markLast(line, column)
+	}
+}
+
+// markMeaningfulToken 遇到非单词的符号，处理未决的END
+func (sr *StatementReader) markMeaningfulToken() {
+	if sr.firstWord == "" {
+		sr.firstWord = " "
+	}
+	sr.endPending()
+}
+
+func (sr *StatementReader) endPending() {
+	if sr.pendingEnd {
+		sr.pendingEnd = false
+		if sr.depth > 0 {
+			sr.depth--
+		}
+	}
+}
+
+// splitKeywords 切割时关注的关键字
+var splitKeywords = []string{"BEGIN", "CASE", "CREATE", "END", "IF", "LOOP", "WHILE", "REPEAT", "DELIMITER", "COPY", "FROM", "STDIN", "E"}
+
+// keywordOf 返回单词对应的关键字(忽略大小写)，非关键字返回空串，避免为每个单词分配内存
+func keywordOf(word []byte) string {
+	for _, keyword := range splitKeywords {
+		if len(word) != len(keyword) {
+			continue
+		}
+		i := 0
+		for ; i < len(word); i++ {
+			if word[i]&^0x20 != keyword[i] {
+				break
+			}
+		}
+		if i == len(word) {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// endWord 结束当前单词并根据关键字维护BEGIN ... END块深度，返回单词对应的关键字
+func (sr *StatementReader) endWord() string {
+	if sr.wordLen == 0 {
+		return ""
+	}
+	keyword := ""
+	if sr.wordLen <= maxKeywordLen {
+		keyword = keywordOf(sr.word)
+	}
+	sr.word = sr.word[:0]
+	sr.wordLen = 0
+
+	if sr.firstWord == "" {
+		sr.firstWord = keyword
+		if keyword == "" {
+			sr.firstWord = " "
+		}
+	}
+
+	if sr.firstWord == "COPY" {
+		sr.copyStdin = sr.copyStdin || sr.copyFrom && keyword == "STDIN"
+		sr.copyFrom = keyword == "FROM"
+	}
+
+	if sr.pendingEnd {
+		sr.pendingEnd = false
+		for _, suffix := range sr.rules.endSuffixes {
+			if keyword == suffix {
+				return keyword
+			}
+		}
+		if sr.depth > 0 {
+			sr.depth--
+		}
+		if keyword == "CASE" {
+			return keyword
+		}
+	}
+
+	switch keyword {
+	case "CASE":
+		sr.depth++
+	case "BEGIN":
+		if sr.firstWord == "CREATE" {
+			sr.depth++
+		}
+	case "END":
+		sr.pendingEnd = true
+	}
+	return keyword
+}
+
+// isKeywordStart 单词首字母是否可能为splitKeywords中的关键字
+func isKeywordStart(b byte) bool {
+	switch b &^ 0x20 {
+	case 'B', 'C', 'D', 'E', 'F', 'I', 'L', 'R', 'S', 'W':
+		return true
+	}
+	return false
+}
+
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '_' || b == '$' || b >= 0x80
+}
+
+func isTagByte(b byte, first bool) bool {
+	if b >= '0' && b <= '9' {
+		return !first
+	}
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b == '_' || b >= 0x80
+}
@@ -0,0 +1,202 @@
+package sqlparser_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/may-fly/go-sqlparser"
+	"github.com/may-fly/go-sqlparser/base"
+	"github.com/may-fly/go-sqlparser/mysql"
+)
+
+func readAll(t *testing.T, dialect sqlparser.DbDialect, sql string) []base.SingleSQL {
+	reader, err := sqlparser.NewStatementReader(dialect, strings.NewReader(sql))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sqls []base.SingleSQL
+	for reader.Next() {
+		sqls = append(sqls, reader.Statement())
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return sqls
+}
+
+func TestStatementReaderMysql(t *testing.T) {
+	sql := `select 'a;b\';c', "x;\"y", ` + "`co;l`" + ` from t_db; -- trailing; comment
+/* c1; */ update t_db set name = 'x' where id = 1 # hash; comment
+;
+/*!40101 SET NAMES utf8 */;
+CREATE PROCEDURE p_test()
+BEGIN
+  DECLARE i INT DEFAULT 0;
+  IF i = 0 THEN
+    SELECT CASE WHEN i > 0 THEN 1 ELSE 0 END;
+  END IF;
+END;
+;
+select '中文', 2
+-- end`
+
+	sqls := readAll(t, sqlparser.Mysql, sql)
+	want, err := mysql.SplitSQL(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqls) != len(want) {
+		t.Fatalf("sqls len = %d, want %d", len(sqls), len(want))
+	}
+	for i := range sqls {
+		if sqls[i] != want[i] {
+			t.Fatalf("sqls[%d] = %+v, want %+v", i, sqls[i], want[i])
+		}
+	}
+}
+
+func TestStatementReaderPgsql(t *testing.T) {
+	sql := `select 'a;b''c', E'x\';y', "co;l" from t_db; /* outer /* nested; */ still; */
+CREATE FUNCTION f_test() RETURNS int AS $body$
+BEGIN
+  RETURN 1; -- $$ is not the tag
+END;
+$body$ LANGUAGE plpgsql;
+select $1, a$b from t;
+`
+	sqls := readAll(t, sqlparser.Pgsql, sql)
+	if len(sqls) != 3 {
+		t.Fatalf("sqls len = %d, want 3", len(sqls))
+	}
+	if sqls[1].FirstStatementLine != 1 || sqls[1].LastLine != 5 || sqls[1].LastColumn != 23 {
+		t.Fatalf("unexpected function sql: %+v", sqls[1])
+	}
+	if sqls[2].FirstStatementLine != 6 || sqls[2].Text != "\nselect $1, a$b from t;" {
+		t.Fatalf("unexpected last sql: %+v", sqls[2])
+	}
+	if sql[sqls[1].ByteOffsetStart:sqls[1].ByteOffsetEnd] != sqls[1].Text {
+		t.Fatal("byte offsets should match the text")
+	}
+}
+
+func TestStatementReaderMaxSize(t *testing.T) {
+	sql := "select 1;\ninsert into t values ('" + strings.Repeat("x", 1024) + "');\nselect 2;"
+	reader, err := sqlparser.NewStatementReader(sqlparser.Mysql, strings.NewReader(sql))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader.SetMaxStatementSize(512)
+	count := 0
+	for reader.Next() {
+		count++
+	}
+	if count != 1 || !errors.Is(reader.Err(), sqlparser.ErrStatementTooLong) {
+		t.Fatalf("count = %d, err = %v", count, reader.Err())
+	}
+}
+
+// oneByteReader 每次只返回一个字节，用于验证跨读取块的词法状态
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (o *oneByteReader) Read(p []byte) (int, error) {
+	return o.r.Read(p[:1])
+}
+
+func TestStatementReaderSmallReads(t *testing.T) {
+	sql := "select 'a;b' /* c; */ from t; select $$x;y$$; -- c;\nselect 2"
+	reader, err := sqlparser.NewStatementReader(sqlparser.Pgsql, &oneByteReader{strings.NewReader(sql)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for reader.Next() {
+		texts = append(texts, reader.Statement().Text)
+	}
+	if reader.Err() != nil || len(texts) != 3 || texts[1] != " select $$x;y$$;" {
+		t.Fatalf("texts = %q, err = %v", texts, reader.Err())
+	}
+}
+
+func BenchmarkStatementReader(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO `t_db` VALUES ")
+	for i := 0; i < 20000; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString("(1,'name \\'quoted\\' ;',NULL,3.14,'2024-08-26 15:36:27')")
+	}
+	sb.WriteString(";\n")
+	sql := sb.String()
+
+	b.SetBytes(int64(len(sql)))
+	for i := 0; i < b.N; i++ {
+		reader, _ := sqlparser.NewStatementReader(sqlparser.Mysql, strings.NewReader(sql))
+		for reader.Next() {
+		}
+	}
+}
+
+func TestStatementReaderDelimiter(t *testing.T) {
+	sql := "DELIMITER ;;\nCREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW BEGIN SET NEW.a = ';;'; END;;\nDELIMITER ;\nselect 1;"
+	reader, err := sqlparser.NewStatementReader(sqlparser.Mysql, &oneByteReader{strings.NewReader(sql)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sqls []base.SingleSQL
+	for reader.Next() {
+		sqls = append(sqls, reader.Statement())
+	}
+	if reader.Err() != nil || len(sqls) != 2 {
+		t.Fatalf("sqls = %+v, err = %v", sqls, reader.Err())
+	}
+	if sqls[0].Text != "CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW BEGIN SET NEW.a = ';;'; END" || sqls[0].BaseLine != 1 {
+		t.Fatalf("unexpected trigger sql: %+v", sqls[0])
+	}
+	if sqls[1].Text != "select 1;" || sqls[1].FirstStatementLine != 3 {
+		t.Fatalf("unexpected last sql: %+v", sqls[1])
+	}
+}
+
+func TestStatementReaderPgsqlCopy(t *testing.T) {
+	sql := "\\connect db1\nCOPY t (a, b) FROM stdin;\n1\tx;y\n\\N\t\\\\\n\\.\nselect 1;\n\\q"
+	sqls := readAll(t, sqlparser.Pgsql, sql)
+	if len(sqls) != 5 {
+		t.Fatalf("sqls = %+v", sqls)
+	}
+	kinds := []base.SegmentKind{base.SegmentMetaCommand, base.SegmentStatement, base.SegmentCopyData, base.SegmentStatement, base.SegmentMetaCommand}
+	for i, kind := range kinds {
+		if sqls[i].Kind != kind {
+			t.Fatalf("sqls[%d].Kind = %v, want %v", i, sqls[i].Kind, kind)
+		}
+	}
+	if sqls[0].Text != "\\connect db1" {
+		t.Fatalf("unexpected meta command: %q", sqls[0].Text)
+	}
+	if sqls[2].Text != "1\tx;y\n\\N\t\\\\\n" || sqls[2].BaseLine != 2 {
+		t.Fatalf("unexpected copy data: %+v", sqls[2])
+	}
+	if sqls[3].Text != "select 1;" || sqls[3].FirstStatementLine != 5 {
+		t.Fatalf("unexpected sql: %+v", sqls[3])
+	}
+	if sqls[4].Text != "\n\\q" {
+		t.Fatalf("unexpected meta command: %q", sqls[4].Text)
+	}
+
+	reader, err := sqlparser.NewStatementReader(sqlparser.Pgsql, &oneByteReader{strings.NewReader(sql)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; reader.Next(); i++ {
+		if i >= len(sqls) || reader.Statement() != sqls[i] {
+			t.Fatalf("small reads: sqls[%d] = %+v", i, reader.Statement())
+		}
+	}
+	if reader.Err() != nil {
+		t.Fatal(reader.Err())
+	}
+}
@@ -2,18 +2,24 @@ package sqlparser
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
 	"io"
 	"regexp"
+	"slices"
+	"sync"
 
+	"github.com/may-fly/go-sqlparser/base"
 	"github.com/may-fly/go-sqlparser/sqlstmt"
 )
 
+// DbDialect sql方言
 type DbDialect string
 
-// const (
-// 	mysql DbDialect = "mysql"
-// 	pgsql DbDialect = "pgsql"
-// )
+const (
+	Mysql DbDialect = "mysql"
+	Pgsql DbDialect = "pgsql"
+)
 
 type SqlParser interface {
 
@@ -22,37 +28,230 @@ type SqlParser interface {
 	Parse(stmt string) ([]sqlstmt.Stmt, error)
 }
 
-// var (
-// 	parsers = make(map[string]SqlParser)
-// )
+// Splitter 支持根据方言词法切割多条sql的parser
+type Splitter interface {
+	Split(text string) ([]base.SingleSQL, error)
+}
+
+// ParseResult 单条语句的解析结果，Stmt与Err有且仅有一个非空(psql元命令等非语句片段二者皆为空)
+type ParseResult struct {
+	// SQL 语句的文本及在原sql中的位置
+	SQL base.SingleSQL
+	// Stmt 解析成功的语句
+	Stmt sqlstmt.Stmt
+	// Err 该语句的语法错误，行列为原sql中的位置
+	Err error
+}
+
+// EachParser 支持逐条解析的parser，单条语句的语法错误不影响其他语句
+type EachParser interface {
+	ParseEach(text string) ([]ParseResult, error)
+}
+
+// SyntaxChecker 支持收集全部语法错误的parser
+type SyntaxChecker interface {
+	// CheckSyntax 存在语法错误时返回base.SyntaxErrors
+	CheckSyntax(text string) error
+}
+
+// Paginator 支持分页改写的parser
+type Paginator interface {
+	// Paginate 返回查询第offset行起的count行的sql，已有limit时取二者的交集
+	Paginate(stmt sqlstmt.Stmt, offset, count int) (string, error)
+}
+
+// Fingerprinter 支持计算语句指纹的parser
+type Fingerprinter interface {
+	// Fingerprint 返回规范化的sql及其摘要，仅字面量、格式及注释不同的sql指纹相同
+	Fingerprint(sql string) (*base.Fingerprint, error)
+}
+
+// Parameterizer 支持将字面量提取为参数的parser
+type Parameterizer interface {
+	// Parameterize 返回字面量替换为占位符的sql及按占位符顺序排列的参数值
+	Parameterize(stmt sqlstmt.Stmt) (string, []any, error)
+}
+
+// Formatter 支持格式化sql的parser
+type Formatter interface {
+	// Format 返回格式化后的sql，仅改变token之间的空白及关键字的大小写
+	Format(sql string, options base.FormatOptions) (string, error)
+}
+
+// ErrNotPaginable 语句无法分页改写，如非查询语句、limit为参数或表达式等
+var ErrNotPaginable = errors.New("sqlparser: statement cannot be paginated")
+
+// ErrNotParameterizable 语句无法参数化，如ddl、已包含参数的语句等
+var ErrNotParameterizable = errors.New("sqlparser: statement cannot be parameterized")
+
+// UnknownDialectError 方言未注册对应的parser时返回该错误
+type UnknownDialectError struct {
+	Dialect DbDialect
+}
+
+func (e *UnknownDialectError) Error() string {
+	return fmt.Sprintf("sqlparser: unknown dialect %q (forgotten import?)", string(e.Dialect))
+}
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = make(map[DbDialect]SqlParser)
+)
+
+// Register 注册方言与parser，一般在各方言包的init中调用
+// 重复注册或parser为nil时panic
+func Register(dialect DbDialect, parser SqlParser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	if parser == nil {
+		panic("sqlparser: Register parser is nil")
+	}
+	if _, dup := parsers[dialect]; dup {
+		panic("sqlparser: Register called twice for dialect " + string(dialect))
+	}
+	parsers[dialect] = parser
+}
 
-// // 注册数据库类型与dbmeta
-// func Register(dialect string, parser SqlParser) {
-// 	parsers[dialect] = parser
-// }
+// Dialects 返回已注册的方言列表
+func Dialects() []DbDialect {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	list := make([]DbDialect, 0, len(parsers))
+	for dialect := range parsers {
+		list = append(list, dialect)
+	}
+	slices.Sort(list)
+	return list
+}
 
-// func getParser(dialect string) (SqlParser, error) {
-// 	parser, ok := parsers[dialect]
-// 	if !ok {
-// 		return nil, errors.New("不存在该parser")
-// 	}
-// 	return parser, nil
-// }
+// GetParser 获取方言对应的parser
+func GetParser(dialect DbDialect) (SqlParser, error) {
+	parsersMu.RLock()
+	parser, ok := parsers[dialect]
+	parsersMu.RUnlock()
+	if !ok {
+		return nil, &UnknownDialectError{Dialect: dialect}
+	}
+	return parser, nil
+}
 
-// 解析sql
+// Parse 解析sql
 // @param dialect 方言
 // @param stmt sql语句
-// func Parse(dialect string, stmt string) ([]sqlstmt.Stmt, error) {
-// 	if parser, err := getParser(dialect); err != nil {
-// 		return nil, err
-// 	} else {
-// 		return parser.Parse(stmt)
-// 	}
-// }
+func Parse(dialect DbDialect, stmt string) ([]sqlstmt.Stmt, error) {
+	parser, err := GetParser(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return parser.Parse(stmt)
+}
+
+// Split 根据方言词法切割多条sql，并返回每条sql在原文本中的行列及字节偏移
+// @param dialect 方言
+// @param text 多条sql文本
+func Split(dialect DbDialect, text string) ([]base.SingleSQL, error) {
+	parser, err := GetParser(dialect)
+	if err != nil {
+		return nil, err
+	}
+	splitter, ok := parser.(Splitter)
+	if !ok {
+		return nil, fmt.Errorf("sqlparser: dialect %q does not support splitting", string(dialect))
+	}
+	return splitter.Split(text)
+}
+
+// ParseEach 使用对应方言切割后逐条解析，每条非空语句对应一个结果
+// 仅在无法切割时(如字符串未闭合)返回error
+func ParseEach(dialect DbDialect, text string) ([]ParseResult, error) {
+	parser, err := GetParser(dialect)
+	if err != nil {
+		return nil, err
+	}
+	eachParser, ok := parser.(EachParser)
+	if !ok {
+		return nil, fmt.Errorf("sqlparser: dialect %q does not support parsing each statement", string(dialect))
+	}
+	return eachParser.ParseEach(text)
+}
+
+// CheckSyntax 使用对应方言检查sql语法，存在错误时返回base.SyntaxErrors，包含全部语法错误而不仅是第一个
+func CheckSyntax(dialect DbDialect, text string) error {
+	parser, err := GetParser(dialect)
+	if err != nil {
+		return err
+	}
+	checker, ok := parser.(SyntaxChecker)
+	if !ok {
+		return fmt.Errorf("sqlparser: dialect %q does not support syntax checking", string(dialect))
+	}
+	return checker.CheckSyntax(text)
+}
+
+// Paginate 使用对应方言改写查询的limit，返回查询第offset行起的count行的sql
+// 语句已有limit时取二者的交集而不是覆盖，无法改写时返回的error包含ErrNotPaginable
+// @param stmt 该方言解析出的查询语句
+func Paginate(dialect DbDialect, stmt sqlstmt.Stmt, offset, count int) (string, error) {
+	parser, err := GetParser(dialect)
+	if err != nil {
+		return "", err
+	}
+	paginator, ok := parser.(Paginator)
+	if !ok {
+		return "", fmt.Errorf("sqlparser: dialect %q does not support pagination", string(dialect))
+	}
+	return paginator.Paginate(stmt, offset, count)
+}
+
+// Parameterize 使用对应方言将语句中的字面量替换为占位符(mysql为?，pgsql为$1..$n)，返回替换后的sql及按占位符顺序排列的参数值，
+// 用于将拼接的sql转换为预编译语句；无法参数化时返回的error包含ErrNotParameterizable
+// @param stmt 该方言解析出的select、insert、replace、update或delete语句
+func Parameterize(dialect DbDialect, stmt sqlstmt.Stmt) (string, []any, error) {
+	parser, err := GetParser(dialect)
+	if err != nil {
+		return "", nil, err
+	}
+	parameterizer, ok := parser.(Parameterizer)
+	if !ok {
+		return "", nil, fmt.Errorf("sqlparser: dialect %q does not support parameterization", string(dialect))
+	}
+	return parameterizer.Parameterize(stmt)
+}
+
+// Fingerprint 使用对应方言计算sql的指纹，用于按语句结构聚合执行过的sql
+// 字面量替换为?，仅含字面量的in列表及values行替换为( ? )，去除注释、关键字小写、空白统一为单个空格后计算SHA-256摘要
+// sql存在语法错误时返回error
+func Fingerprint(dialect DbDialect, sql string) (*base.Fingerprint, error) {
+	parser, err := GetParser(dialect)
+	if err != nil {
+		return nil, err
+	}
+	fingerprinter, ok := parser.(Fingerprinter)
+	if !ok {
+		return nil, fmt.Errorf("sqlparser: dialect %q does not support fingerprinting", string(dialect))
+	}
+	return fingerprinter.Fingerprint(sql)
+}
+
+// Format 使用对应方言格式化sql，用于sql编辑器的格式化
+// 关键字大小写、缩进宽度、逗号位置及行宽见base.FormatOptions，零值为关键字大写、缩进2个空格、逗号在行尾、行宽80
+// dml及ddl每个子句独占一行，保留注释，多条语句逐条格式化；基于词法的token格式化，不改变语义，sql存在语法错误时返回error
+func Format(dialect DbDialect, sql string, options base.FormatOptions) (string, error) {
+	parser, err := GetParser(dialect)
+	if err != nil {
+		return "", err
+	}
+	formatter, ok := parser.(Formatter)
+	if !ok {
+		return "", fmt.Errorf("sqlparser: dialect %q does not support formatting", string(dialect))
+	}
+	return formatter.Format(sql, options)
+}
 
 var sqlSplitRegexp = regexp.MustCompile(`\s*;\s*\n`)
 
 // SplitSqls 根据;\n切割sql
+// 字符串、注释中的分号也会被切割，需按方言准确切割时请使用Split或NewStatementReader
 func SplitSqls(r io.Reader) *bufio.Scanner {
 	scanner := bufio.NewScanner(r)
 
@@ -0,0 +1,565 @@
+package sqlstmt
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ColumnClause 列所在的子句
+type ColumnClause int
+
+const (
+	ClauseSelect  ColumnClause = iota // select列表
+	ClauseWhere                       // where条件
+	ClauseJoin                        // join的on条件
+	ClauseGroupBy                     // group by
+	ClauseHaving                      // having
+	ClauseOrderBy                     // order by
+	ClauseSet                         // update及insert的set、on duplicate key update，包含被更新的列及赋值表达式中的列
+	ClauseInsert                      // insert指定的列及values中的列
+)
+
+var columnClauseNames = [...]string{
+	ClauseSelect:  "Select",
+	ClauseWhere:   "Where",
+	ClauseJoin:    "Join",
+	ClauseGroupBy: "GroupBy",
+	ClauseHaving:  "Having",
+	ClauseOrderBy: "OrderBy",
+	ClauseSet:     "Set",
+	ClauseInsert:  "Insert",
+}
+
+func (c ColumnClause) String() string {
+	if c >= 0 && int(c) < len(columnClauseNames) {
+		return columnClauseNames[c]
+	}
+	return "ColumnClause(" + strconv.Itoa(int(c)) + ")"
+}
+
+// ColumnResolution 列的解析结果
+type ColumnResolution int
+
+const (
+	ColumnResolved    ColumnResolution = iota // 解析到唯一的表，Table为该表
+	ColumnDerived                             // 属于派生表或with定义的公共表表达式，Source为派生表或引用公共表表达式的表
+	ColumnJoined                              // 未指定表的join using中的列，属于join本身，Candidates为using两侧的表
+	ColumnSelectAlias                         // group by、having、order by中引用的select列表中的别名
+	ColumnAmbiguous                           // 未指定表且存在多个表，或多个表均可通过指定的名称引用，Candidates为可能的表
+	ColumnUnresolved                          // 不存在可通过指定的名称引用的表
+)
+
+var columnResolutionNames = [...]string{
+	ColumnResolved:    "Resolved",
+	ColumnDerived:     "Derived",
+	ColumnJoined:      "Joined",
+	ColumnSelectAlias: "SelectAlias",
+	ColumnAmbiguous:   "Ambiguous",
+	ColumnUnresolved:  "Unresolved",
+}
+
+func (r ColumnResolution) String() string {
+	if r >= 0 && int(r) < len(columnResolutionNames) {
+		return columnResolutionNames[r]
+	}
+	return "ColumnResolution(" + strconv.Itoa(int(r)) + ")"
+}
+
+// ColumnRef 语句中引用的列
+type ColumnRef struct {
+	ColumnName *ColumnName
+	Clause     ColumnClause
+	Resolution ColumnResolution
+	Table      *AtomTableItem     // 列所属的表，仅ColumnResolved时非空
+	Source     ITableSourceItem   // 列所属的表或派生表，ColumnResolved及ColumnDerived时非空
+	Candidates []ITableSourceItem // 可能所属的表，仅ColumnJoined及ColumnAmbiguous时非空
+}
+
+// ReferencedColumns 返回语句中select列表、where、join on、group by、having、order by、update set及insert中引用的列，
+// 并通过别名解析列所属的表，子查询中的列可解析到外层查询的表
+// 仅依据语句本身解析，不知道表中有哪些列，因此未指定表且存在多个表时为ColumnAmbiguous
+func ReferencedColumns(stmt Stmt) []*ColumnRef {
+	c := new(columnCollector)
+	c.stmt(stmt)
+	return c.refs
+}
+
+// columnScope 一层查询中可引用的表、join using及select列表中的别名
+type columnScope struct {
+	parent  *columnScope
+	items   []ITableSourceItem
+	usings  []usingJoin
+	aliases []string
+}
+
+// usingJoin join using，items为using两侧的表，即join的表及其左侧的全部表
+type usingJoin struct {
+	columns []string
+	items   []ITableSourceItem
+}
+
+type columnCollector struct {
+	refs  []*ColumnRef
+	ctes  cteScope
+	scope *columnScope
+}
+
+// push 进入新的一层查询，返回恢复外层查询的函数
+func (c *columnCollector) push(items []ITableSourceItem, aliases []string) func() {
+	parent := c.scope
+	c.scope = &columnScope{parent: parent, items: items, aliases: aliases}
+	return func() { c.scope = parent }
+}
+
+func (c *columnCollector) stmt(stmt Stmt) {
+	switch s := stmt.(type) {
+	case ISelectStmt:
+		c.selectStmt(s)
+	case *InsertStmt:
+		c.insert(s)
+	case *ReplaceStmt:
+		c.insert(&s.InsertStmt)
+	case *UpdateStmt:
+		c.update(s)
+	case *DeleteStmt:
+		c.delete(s)
+	case *ExplainStmt:
+		c.stmt(s.Stmt)
+	case *CallStmt:
+		c.subqueries(s.Args)
+	case *SetStmt:
+		c.subqueries(s.Values)
+	case *DoStmt:
+		c.subqueries(s.Exprs)
+	case *CreateTable:
+		c.selectStmt(s.SelectStmt)
+	case *CreateView:
+		c.selectStmt(s.SelectStmt)
+	case *LoadDataStmt:
+		c.loadData(s)
+	case *HandlerStmt:
+		c.handler(s)
+	}
+}
+
+func (c *columnCollector) selectStmt(stmt ISelectStmt) {
+	switch s := stmt.(type) {
+	case *SimpleSelectStmt:
+		defer c.ctes.with(s.With, c.stmt)()
+		c.querySpecification(s.QuerySpecification)
+	case *UnionSelectStmt:
+		defer c.ctes.with(s.With, c.stmt)()
+		c.querySpecification(s.QuerySpecification)
+		c.queryExpr(s.QueryExpr)
+		for _, union := range s.UnionStmts {
+			c.querySpecification(union.QuerySpecification)
+			c.queryExpr(union.QueryExpr)
+		}
+		// union的order by只能引用结果中的列
+		var aliases []string
+		if s.QuerySpecification != nil {
+			aliases = outputNames(s.QuerySpecification.SelectElements)
+		} else if s.QueryExpr != nil && s.QueryExpr.QuerySpecification != nil {
+			aliases = outputNames(s.QueryExpr.QuerySpecification.SelectElements)
+		}
+		scope := c.scope
+		c.scope = &columnScope{aliases: aliases}
+		c.orderBy(s.OrderBy)
+		c.scope = scope
+	case *ParenthesisSelect:
+		defer c.ctes.with(s.With, c.stmt)()
+		c.queryExpr(s.QueryExpr)
+	case *LateralSelectStmt:
+		laterals := make([]ITableSourceItem, 0, len(s.Laterals))
+		for _, lateral := range s.Laterals {
+			laterals = append(laterals, lateral)
+		}
+		c.querySpecification(s.QuerySpecification, laterals...)
+	case *TableStmt:
+		var items []ITableSourceItem
+		if s.TableName != nil {
+			items = append(items, &AtomTableItem{TableName: s.TableName})
+		}
+		defer c.push(items, nil)()
+		c.orderBy(s.OrderBy)
+	case *ValuesStmt:
+		for _, row := range s.Rows {
+			for _, expr := range row.Exprs {
+				c.expr(expr, ClauseSelect)
+			}
+		}
+	case *SelectStmt:
+		defer c.ctes.with(s.With, c.stmt)()
+	}
+}
+
+func (c *columnCollector) queryExpr(qe *QueryExpr) {
+	for ; qe != nil; qe = qe.QueryExpr {
+		c.querySpecification(qe.QuerySpecification)
+	}
+}
+
+// querySpecification laterals为mysql的from后以逗号连接的lateral派生表，可被查询中的列引用
+func (c *columnCollector) querySpecification(qs *QuerySpecification, laterals ...ITableSourceItem) {
+	if qs == nil {
+		return
+	}
+	defer c.push(append(tableSourceItems(qs.From), laterals...), selectAliases(qs.SelectElements))()
+	c.scope.usings = usingJoins(qs.From)
+
+	c.expr(qs.SelectElements, ClauseSelect)
+	c.tableSources(qs.From)
+	for _, lateral := range laterals {
+		c.tableSourceItem(lateral)
+	}
+	c.expr(qs.Where, ClauseWhere)
+	for _, expr := range qs.GroupBy {
+		c.expr(expr, ClauseGroupBy)
+	}
+	c.expr(qs.Having, ClauseHaving)
+	c.orderBy(qs.OrderBy)
+}
+
+func (c *columnCollector) orderBy(orderBy []*OrderByExpr) {
+	for _, obe := range orderBy {
+		c.expr(obe.Expr, ClauseOrderBy)
+	}
+}
+
+func (c *columnCollector) insert(s *InsertStmt) {
+	defer c.ctes.with(s.With, c.stmt)()
+	var targets []ITableSourceItem
+	if s.TableName != nil {
+		targets = append(targets, &AtomTableItem{TableName: s.TableName})
+	}
+
+	// 指定的列及values、赋值中的列按insert的目标表解析，insert ... select中的查询不能引用目标表
+	restore := c.push(targets, nil)
+	for _, column := range s.Columns {
+		c.add(column, ClauseInsert)
+	}
+	for _, row := range s.Values {
+		for _, expr := range row.Exprs {
+			c.expr(expr, ClauseInsert)
+		}
+	}
+	restore()
+	c.selectStmt(s.SelectStmt)
+
+	defer c.push(targets, nil)()
+	for _, ues := range [][]*UpdatedElement{s.SetElements, s.DuplicatedElements} {
+		for _, ue := range ues {
+			c.add(ue.ColumnName, ClauseSet)
+			c.expr(ue.Value, ClauseSet)
+		}
+	}
+	c.expr(s.ConflictWhere, ClauseWhere)
+}
+
+func (c *columnCollector) loadData(s *LoadDataStmt) {
+	var targets []ITableSourceItem
+	if s.TableName != nil {
+		targets = append(targets, &AtomTableItem{TableName: s.TableName})
+	}
+	defer c.push(targets, nil)()
+	for _, ue := range s.SetElements {
+		c.add(ue.ColumnName, ClauseSet)
+		c.expr(ue.Value, ClauseSet)
+	}
+}
+
+func (c *columnCollector) handler(s *HandlerStmt) {
+	var items []ITableSourceItem
+	if s.TableName != nil {
+		items = append(items, &AtomTableItem{TableName: s.TableName, Alias: s.Alias})
+	}
+	defer c.push(items, nil)()
+	c.expr(s.Where, ClauseWhere)
+}
+
+// subqueries 调用、set、do中的表达式不属于任何查询，仅收集其中子查询的列
+func (c *columnCollector) subqueries(exprs []IExpr) {
+	for _, expr := range exprs {
+		eachSubquery(expr, c.selectStmt)
+	}
+}
+
+func (c *columnCollector) update(s *UpdateStmt) {
+	defer c.ctes.with(s.With, c.stmt)()
+	targets := tableSourceItems(s.TableSources)
+	defer c.push(append(targets, tableSourceItems(s.From)...), nil)()
+	c.scope.usings = append(usingJoins(s.TableSources), usingJoins(s.From)...)
+
+	for _, ue := range s.UpdatedElements {
+		// 被更新的列只能属于update的目标表，不能属于pgsql的update ... from中的表
+		restore := c.push(targets, nil)
+		c.add(ue.ColumnName, ClauseSet)
+		restore()
+		c.expr(ue.Value, ClauseSet)
+	}
+	c.tableSources(s.TableSources)
+	c.tableSources(s.From)
+	c.expr(s.Where, ClauseWhere)
+}
+
+func (c *columnCollector) delete(s *DeleteStmt) {
+	defer c.ctes.with(s.With, c.stmt)()
+	defer c.push(append(tableSourceItems(s.TableSources), tableSourceItems(s.Using)...), nil)()
+	c.scope.usings = append(usingJoins(s.TableSources), usingJoins(s.Using)...)
+
+	c.tableSources(s.TableSources)
+	c.tableSources(s.Using)
+	c.expr(s.Where, ClauseWhere)
+}
+
+// tableSources 收集join的on条件及派生表中的列
+func (c *columnCollector) tableSources(tableSources *TableSources) {
+	if tableSources == nil {
+		return
+	}
+	for _, ts := range tableSources.TableSources {
+		tsb, ok := ts.(*TableSourceBase)
+		if !ok {
+			continue
+		}
+		c.tableSourceItem(tsb.TableSourceItem)
+		for _, jp := range tsb.JoinParts {
+			if joinPart := getJoinPart(jp); joinPart != nil {
+				c.tableSourceItem(joinPart.TableSourceItem)
+				c.expr(joinPart.On, ClauseJoin)
+			}
+		}
+	}
+}
+
+func (c *columnCollector) tableSourceItem(tsi ITableSourceItem) {
+	switch i := tsi.(type) {
+	case *SubqueryTableItem:
+		c.selectStmt(i.SelectStmt)
+	case *TableSourcesItem:
+		c.tableSources(i.TableSources)
+	}
+}
+
+// expr 收集节点中的列，子查询中的列在子查询的范围内收集
+func (c *columnCollector) expr(node INode, clause ColumnClause) {
+	Inspect(node, func(n INode) bool {
+		switch n := n.(type) {
+		case *ColumnName:
+			c.add(n, clause)
+			return false
+		case ISelectStmt:
+			c.selectStmt(n)
+			return false
+		}
+		return true
+	})
+}
+
+func (c *columnCollector) add(column *ColumnName, clause ColumnClause) {
+	if column == nil || column.Identifier == nil {
+		return
+	}
+	ref := &ColumnRef{ColumnName: column, Clause: clause, Resolution: ColumnUnresolved}
+	c.resolve(ref)
+	c.refs = append(c.refs, ref)
+}
+
+// resolve 由内向外逐层查找列所属的表
+func (c *columnCollector) resolve(ref *ColumnRef) {
+	column := ref.ColumnName
+	if column.Owner == "" && c.scope != nil && (ref.Clause == ClauseGroupBy || ref.Clause == ClauseHaving || ref.Clause == ClauseOrderBy) {
+		for _, alias := range c.scope.aliases {
+			if strings.EqualFold(alias, column.Identifier.Value) {
+				ref.Resolution = ColumnSelectAlias
+				return
+			}
+		}
+	}
+
+	for scope := c.scope; scope != nil; scope = scope.parent {
+		matched := scope.items
+		if column.Owner != "" {
+			matched = nil
+			for _, item := range scope.items {
+				if isItemReferencedBy(item, column.Owner) {
+					matched = append(matched, item)
+				}
+			}
+		}
+		if column.Owner == "" {
+			if joined := scope.joined(column.Identifier.Value); len(joined) > 0 && len(joined) == len(matched) {
+				ref.Resolution = ColumnJoined
+				ref.Candidates = joined
+				return
+			}
+		}
+		switch {
+		case len(matched) == 1:
+			ref.Source = matched[0]
+			ref.Resolution = ColumnDerived
+			if atom, ok := matched[0].(*AtomTableItem); ok && !c.isCTE(atom) {
+				ref.Table = atom
+				ref.Resolution = ColumnResolved
+			}
+			return
+		case len(matched) > 1:
+			ref.Resolution = ColumnAmbiguous
+			ref.Candidates = matched
+			return
+		}
+	}
+}
+
+// joined 返回通过join using合并了名为column的列的表，using两侧的同名列合并为一列，未指定表时不会因此产生歧义
+// 返回的表之外还有其他表时，列仍可能属于其他表
+func (s *columnScope) joined(column string) []ITableSourceItem {
+	var joined []ITableSourceItem
+	for _, using := range s.usings {
+		if !slices.ContainsFunc(using.columns, func(name string) bool {
+			return strings.EqualFold(NewIdentifierValue(name).Value, column)
+		}) {
+			continue
+		}
+		for _, item := range using.items {
+			if !slices.Contains(joined, item) {
+				joined = append(joined, item)
+			}
+		}
+	}
+	return joined
+}
+
+func (c *columnCollector) isCTE(atom *AtomTableItem) bool {
+	return atom.TableName != nil && atom.TableName.Owner == "" && atom.TableName.Identifier != nil &&
+		c.ctes.contains(atom.TableName.Identifier.Value)
+}
+
+// isItemReferencedBy 列的表名部分是否引用该表，owner可为库名.表名
+func isItemReferencedBy(item ITableSourceItem, owner string) bool {
+	switch i := item.(type) {
+	case *AtomTableItem:
+		schema, name := "", owner
+		if index := strings.LastIndex(owner, "."); index >= 0 {
+			schema, name = owner[:index], owner[index+1:]
+		}
+		if schema == "" {
+			return i.isReferencedBy(name)
+		}
+		return i.Alias == "" && i.TableName != nil && i.isReferencedBy(name) &&
+			strings.EqualFold(NewIdentifierValue(i.TableName.Owner).Value, NewIdentifierValue(schema).Value)
+	case *SubqueryTableItem:
+		return i.Alias != "" && strings.EqualFold(NewIdentifierValue(i.Alias).Value, NewIdentifierValue(owner).Value)
+	}
+	return false
+}
+
+// tableSourceItems 返回tableSources中可被列引用的表及派生表
+func tableSourceItems(tableSources *TableSources) []ITableSourceItem {
+	var items []ITableSourceItem
+	var item func(ITableSourceItem)
+	var sources func(*TableSources)
+	item = func(tsi ITableSourceItem) {
+		switch i := tsi.(type) {
+		case *AtomTableItem, *SubqueryTableItem:
+			items = append(items, i)
+		case *TableSourcesItem:
+			sources(i.TableSources)
+		}
+	}
+	sources = func(tss *TableSources) {
+		if tss == nil {
+			return
+		}
+		for _, ts := range tss.TableSources {
+			if tsb, ok := ts.(*TableSourceBase); ok {
+				item(tsb.TableSourceItem)
+				for _, jp := range tsb.JoinParts {
+					if joinPart := getJoinPart(jp); joinPart != nil {
+						item(joinPart.TableSourceItem)
+					}
+				}
+			}
+		}
+	}
+	sources(tableSources)
+	return items
+}
+
+// usingJoins 返回tableSources中的join using，包含圆括号中的join
+func usingJoins(tableSources *TableSources) []usingJoin {
+	var joins []usingJoin
+	var sources func(*TableSources)
+	sources = func(tss *TableSources) {
+		if tss == nil {
+			return
+		}
+		for _, ts := range tss.TableSources {
+			tsb, ok := ts.(*TableSourceBase)
+			if !ok {
+				continue
+			}
+			var items []ITableSourceItem
+			item := func(tsi ITableSourceItem) {
+				switch i := tsi.(type) {
+				case *AtomTableItem, *SubqueryTableItem:
+					items = append(items, i)
+				case *TableSourcesItem:
+					sources(i.TableSources)
+					items = append(items, tableSourceItems(i.TableSources)...)
+				}
+			}
+			item(tsb.TableSourceItem)
+			for _, jp := range tsb.JoinParts {
+				joinPart := getJoinPart(jp)
+				if joinPart == nil {
+					continue
+				}
+				item(joinPart.TableSourceItem)
+				if len(joinPart.Using) > 0 {
+					joins = append(joins, usingJoin{columns: joinPart.Using, items: items[:len(items):len(items)]})
+				}
+			}
+		}
+	}
+	sources(tableSources)
+	return joins
+}
+
+// selectAliases 返回select列表中的别名
+func selectAliases(selectElements *SelectElements) []string {
+	var aliases []string
+	if selectElements == nil {
+		return aliases
+	}
+	for _, element := range selectElements.Elements {
+		var alias string
+		switch e := element.(type) {
+		case *SelectColumnElement:
+			alias = e.Alias
+		case *SelectFunctionElement:
+			alias = e.Alias
+		case *SelectExpressionElement:
+			alias = e.Alias
+		}
+		if alias != "" {
+			aliases = append(aliases, NewIdentifierValue(alias).Value)
+		}
+	}
+	return aliases
+}
+
+// outputNames 返回查询结果中的列名，即别名或未指定别名时的列名
+func outputNames(selectElements *SelectElements) []string {
+	names := selectAliases(selectElements)
+	if selectElements == nil {
+		return names
+	}
+	for _, element := range selectElements.Elements {
+		if e, ok := element.(*SelectColumnElement); ok && e.Alias == "" && e.FullColumnName != nil && e.FullColumnName.Identifier != nil {
+			names = append(names, e.FullColumnName.Identifier.Value)
+		}
+	}
+	return names
+}
@@ -9,6 +9,9 @@ type (
 
 	Expr struct {
 		*Node
+
+		Subqueries []ISelectStmt // 未解析为具体类型的表达式中包含的子查询
+		Columns    []*ColumnName // 未解析为具体类型的表达式中引用的列，不包含子查询中的列
 	}
 
 	LogicalExpr struct {
@@ -20,6 +23,24 @@ type (
 
 	PredicateExpr struct {
 		Expr
+
+		Predicate IPredicate
+	}
+
+	// NotExpr not或!取反的条件，如 not a = 1
+	NotExpr struct {
+		Expr
+
+		Operand IExpr
+	}
+
+	// IsExpr 判断真值，如 a > 1 is not true
+	IsExpr struct {
+		Expr
+
+		Predicate IPredicate
+		Not       bool
+		TestValue string // TRUE、FALSE或UNKNOWN
 	}
 )
 
@@ -34,6 +55,9 @@ type (
 
 	Predicate struct {
 		*Node
+
+		Subqueries []ISelectStmt // 未解析为具体类型的谓词中包含的子查询
+		Columns    []*ColumnName // 未解析为具体类型的谓词中引用的列，不包含子查询中的列
 	}
 
 	BinaryComparisonPredicate struct {
@@ -48,10 +72,59 @@ type (
 		*Node
 
 		Predicate  IPredicate
+		Not        bool
 		Exprs      []IExpr
 		SelectStmt ISelectStmt
 	}
 
+	// SubqueryComparisonPredicate 与子查询的各行比较，如 a > all (select ...)
+	SubqueryComparisonPredicate struct {
+		*Node
+
+		Predicate          IPredicate
+		ComparisonOperator string
+		Quantifier         string // ALL、ANY或SOME
+		SelectStmt         ISelectStmt
+	}
+
+	// IsNullPredicate 如 a is not null
+	IsNullPredicate struct {
+		*Node
+
+		Predicate IPredicate
+		Not       bool
+	}
+
+	// BetweenPredicate 如 a not between 1 and 2
+	BetweenPredicate struct {
+		*Node
+
+		Predicate IPredicate
+		Not       bool
+		Low       IPredicate
+		High      IPredicate
+	}
+
+	// LikePredicate 如 a not like 'x%' escape '!'
+	LikePredicate struct {
+		*Node
+
+		Predicate IPredicate
+		Not       bool
+		Pattern   IPredicate
+		Escape    string // escape后的字符串字面量，包含引号，没有escape时为空
+	}
+
+	// RegexpPredicate 如 a not regexp '^x'
+	RegexpPredicate struct {
+		*Node
+
+		Predicate IPredicate
+		Not       bool
+		Operator  string // REGEXP或RLIKE
+		Pattern   IPredicate
+	}
+
 	ExprAtomPredicate struct {
 		Predicate
 
@@ -61,6 +134,18 @@ type (
 
 func (*Predicate) isPredicate() {}
 
+func (*InPredicate) isPredicate() {}
+
+func (*SubqueryComparisonPredicate) isPredicate() {}
+
+func (*IsNullPredicate) isPredicate() {}
+
+func (*BetweenPredicate) isPredicate() {}
+
+func (*LikePredicate) isPredicate() {}
+
+func (*RegexpPredicate) isPredicate() {}
+
 type (
 	IExprAtom interface {
 		INode
@@ -70,6 +155,9 @@ type (
 
 	ExprAtom struct {
 		*Node
+
+		Subqueries []ISelectStmt // 未解析为具体类型的表达式中包含的子查询
+		Columns    []*ColumnName // 未解析为具体类型的表达式中引用的列，不包含子查询中的列
 	}
 
 	ExprAtomFunctionCall struct {
@@ -81,6 +169,51 @@ type (
 
 		Constant *Constant
 	}
+
+	ExprAtomColumnName struct {
+		ExprAtom
+
+		ColumnName *ColumnName
+	}
+
+	// ExprAtomSubquery 标量子查询，如 (select max(id) from t)
+	ExprAtomSubquery struct {
+		ExprAtom
+
+		SelectStmt ISelectStmt
+	}
+
+	// ExprAtomExists exists子查询
+	ExprAtomExists struct {
+		ExprAtom
+
+		SelectStmt ISelectStmt
+	}
+
+	// ExprAtomUnary 一元运算，如 -a、~a、!a
+	ExprAtomUnary struct {
+		ExprAtom
+
+		Operator string // -、+、~、!或NOT
+		Operand  IExprAtom
+	}
+
+	// ExprAtomNested 括号中的表达式，多个表达式时为行构造器，如 (a + 1)、(a, b)、row(a, b)
+	ExprAtomNested struct {
+		ExprAtom
+
+		Row   bool // 以row关键字构造
+		Exprs []IExpr
+	}
+
+	// ExprAtomArithmetic 算术及位运算，如 a + 1、a div 2、a << 1、a & b
+	ExprAtomArithmetic struct {
+		ExprAtom
+
+		Left     IExprAtom
+		Operator string // 关键字(DIV、MOD)大写
+		Right    IExprAtom
+	}
 )
 
 func (*ExprAtom) isExprAtom() {}
@@ -123,6 +256,21 @@ type (
 		TableName *TableName // 表名
 		Alias     string     // 别名
 	}
+
+	// SubqueryTableItem 派生表，如 (select * from t) t1
+	SubqueryTableItem struct {
+		TableSourceItem
+
+		SelectStmt ISelectStmt
+		Alias      string
+	}
+
+	// TableSourcesItem 圆括号中的表，如 (t1 join t2 on t1.id = t2.id)
+	TableSourcesItem struct {
+		TableSourceItem
+
+		TableSources *TableSources
+	}
 )
 
 func (*TableSource) isTableSource() {}
@@ -132,6 +280,7 @@ type (
 		*Node
 
 		Value string
+		Arg   any // 作为预编译语句的参数时的值，见mysql.Parameterize；NULL、布尔值、十六进制等无法参数化的字面量为nil
 	}
 
 	FullId struct {
@@ -0,0 +1,113 @@
+package sqlstmt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/may-fly/go-sqlparser/base"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// ErrNotCountable 语句无法改写为count查询，如非查询语句、select ... into等
+var ErrNotCountable = errors.New("sqlstmt: statement cannot be rewritten to a count query")
+
+// CountQuery 将查询改写为统计其结果行数的count查询，mysql与pgsql均适用
+// 无distinct、group by、having、limit且查询列均为列名时直接将查询列替换为COUNT(*)，否则包装为派生表 SELECT COUNT(*) FROM (...) AS t
+// order by不影响行数，除fetch first ... with ties外均会去除；for update等加锁的查询统计行数时同样会加锁，返回ErrNotCountable
+func CountQuery(stmt Stmt) (string, error) {
+	ss, ok := stmt.(ISelectStmt)
+	if !ok {
+		return "", fmt.Errorf("%w: %T", ErrNotCountable, stmt)
+	}
+	if e := ss.GetSideEffects(); e.IntoFile || e.IntoTable || e.IntoVariables {
+		return "", fmt.Errorf("%w: select ... into", ErrNotCountable)
+	}
+	if ss.GetSideEffects().Locking {
+		return "", fmt.Errorf("%w: locking select", ErrNotCountable)
+	}
+
+	var node *Node
+	// 可去除的order by
+	var orderBys [][]*OrderByExpr
+	var qs *QuerySpecification
+	switch s := ss.(type) {
+	case *SimpleSelectStmt:
+		node, qs = s.Node, s.QuerySpecification
+		if qs != nil && !qs.Limit.isWithTies() {
+			orderBys = append(orderBys, qs.OrderBy)
+		}
+	case *UnionSelectStmt:
+		node = s.Node
+		if !s.Limit.isWithTies() {
+			orderBys = append(orderBys, s.OrderBy)
+		}
+		// mysql末尾的查询未加括号时，其后的order by被解析至该查询中
+		if n := len(s.UnionStmts); n > 0 {
+			if last := s.UnionStmts[n-1].QuerySpecification; last != nil && !last.Limit.isWithTies() {
+				orderBys = append(orderBys, last.OrderBy)
+			}
+		}
+	case *ParenthesisSelect:
+		node = s.Node
+	}
+	ctx, ok := node.GetRuleContext().(antlr.ParserRuleContext)
+	if !ok {
+		return "", fmt.Errorf("%w: %T", ErrNotCountable, stmt)
+	}
+
+	rewriter := base.NewRewriter(node.GetParser())
+	for _, orderBy := range orderBys {
+		if obc := orderByClause(orderBy); obc != nil {
+			rewriter.Delete(obc)
+		}
+	}
+	if qs != nil && qs.isCountable() {
+		sec := ruleContextOf(qs.SelectElements)
+		if sec == nil {
+			return "", fmt.Errorf("%w: %T", ErrNotCountable, stmt)
+		}
+		rewriter.Replace(sec, "COUNT(*)")
+		return rewriter.Text(ctx), nil
+	}
+	return "SELECT COUNT(*) FROM (" + rewriter.Text(ctx) + ") AS t", nil
+}
+
+func (l *Limit) isWithTies() bool {
+	return l != nil && l.WithTies
+}
+
+// isCountable 是否可直接将查询列替换为COUNT(*)而不改变行数
+func (qs *QuerySpecification) isCountable() bool {
+	if qs.Distinct || len(qs.GroupBy) > 0 || qs.Having != nil || qs.Limit != nil || qs.SelectElements == nil {
+		return false
+	}
+	// 函数、表达式可能为聚合函数或窗口函数
+	for _, element := range qs.SelectElements.Elements {
+		switch e := element.(type) {
+		case *SelectStarElement, *SelectColumnElement:
+		case *SelectExpressionElement:
+			// pgsql中的列解析为表达式
+			expr, ok := e.Expr.(*Expr)
+			if !ok || len(expr.Subqueries) > 0 || len(expr.Columns) != 1 || expr.GetText() != expr.Columns[0].GetText() {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// orderByClause order by各项所在的order by子句
+func orderByClause(orderBy []*OrderByExpr) antlr.ParserRuleContext {
+	if len(orderBy) == 0 || orderBy[0] == nil {
+		return nil
+	}
+	ctx, _ := orderBy[0].GetRuleContext().(antlr.ParserRuleContext)
+	for ctx != nil && !strings.EqualFold(ctx.GetStart().GetText(), "order") {
+		ctx, _ = ctx.GetParent().(antlr.ParserRuleContext)
+	}
+	return ctx
+}
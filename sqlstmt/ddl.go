@@ -15,14 +15,29 @@ type (
 
 	CreateTable struct {
 		DdlStmt
+
+		TableName  *TableName
+		Like       *TableName  // create table ... like
+		SelectStmt ISelectStmt // create table ... as select
 	}
 
 	CreateIndex struct {
 		DdlStmt
+
+		TableName *TableName
+	}
+
+	CreateView struct {
+		DdlStmt
+
+		TableName  *TableName
+		SelectStmt ISelectStmt
 	}
 
 	AlterTable struct {
 		DdlStmt
+
+		TableName *TableName
 	}
 
 	AlterDatabase struct {
@@ -35,19 +50,41 @@ type (
 
 	DropIndex struct {
 		DdlStmt
+
+		TableName *TableName
 	}
 
 	DropTable struct {
 		DdlStmt
+
+		TableNames []*TableName
 	}
 
 	DropView struct {
 		DdlStmt
+
+		TableNames []*TableName
+	}
+
+	RenameTable struct {
+		DdlStmt
+
+		OldTableNames []*TableName
+		NewTableNames []*TableName
+	}
+
+	TruncateTable struct {
+		DdlStmt
+
+		TableNames []*TableName
 	}
 )
 
 func (d *DdlStmt) isDdl() {}
 
+func (*DdlStmt) Kind() StmtKind { return KindDDL }
+
 func IsDDL(node INode) bool {
-	return true
+	stmt, ok := node.(Stmt)
+	return ok && stmt.Kind() == KindDDL
 }
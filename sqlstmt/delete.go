@@ -8,9 +8,14 @@ type (
 	DeleteStmt struct {
 		*Node
 
+		With         *WithClause
+		Targets      []*TableName // mysql多表删除时要删除数据的表，为空时删除TableSources中的表
 		TableSources *TableSources
+		Using        *TableSources // pgsql的delete ... using中的表
 		Where        IExpr
 	}
 )
 
 func (*DeleteStmt) isDelete() {}
+
+func (*DeleteStmt) Kind() StmtKind { return KindDelete }
@@ -0,0 +1,125 @@
+package sqlstmt
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// Detach 将节点及其子孙节点转换为不引用antlr的parser及语法树的节点，转换后节点仅保留其文本对应的token及位置，
+// 可缓存或在多个goroutine中共享，parser、token流及语法树随之可被回收
+// 转换后GetText、Pos、End及Restore不变，GetParser、GetRuleContext返回nil，因此依赖语法树的InjectPredicate、Paginate等不再可用
+// 同一token流的节点共享一份token的文本，需在SetOrigin之后调用
+func Detach(node INode) {
+	sources := make(map[antlr.TokenStream]*source)
+	Inspect(node, func(n INode) bool {
+		rn, ok := n.(interface{ getNode() *Node })
+		if !ok {
+			return true
+		}
+		if node := rn.getNode(); node != nil && node.parser != nil {
+			node.detach(sources)
+		}
+		return true
+	})
+}
+
+// source 被解析的sql的token，Detach后代替token流
+type source struct {
+	tokens []sourceToken
+}
+
+type sourceToken struct {
+	text   string
+	hidden bool // 空白、注释等不在默认通道的token
+}
+
+func (s *source) token(i int) (text string, hidden bool) {
+	if i < 0 || i >= len(s.tokens) {
+		return "", true
+	}
+	return s.tokens[i].text, s.tokens[i].hidden
+}
+
+// tokenSource 节点所在的token序列，为antlr的token流或Detach后的source
+type tokenSource interface {
+	// token 第i个token的文本，EOF为空字符串
+	token(i int) (text string, hidden bool)
+}
+
+type antlrTokens struct {
+	stream antlr.TokenStream
+}
+
+func (t antlrTokens) token(i int) (string, bool) {
+	token := t.stream.Get(i)
+	if token.GetTokenType() == antlr.TokenEOF {
+		return "", true
+	}
+	return token.GetText(), token.GetChannel() != antlr.TokenDefaultChannel
+}
+
+// tokenRange 节点所在的token序列及其中的范围，stop小于start时为空的语法规则，位于start之前
+func (n *Node) tokenRange() (tokens tokenSource, start, stop int, ok bool) {
+	if n == nil {
+		return nil, 0, 0, false
+	}
+	if n.source != nil {
+		return n.source, n.start, n.stop, true
+	}
+	ctx := ruleContextOf(n)
+	if ctx == nil || ctx.GetStart() == nil {
+		return nil, 0, 0, false
+	}
+	start = ctx.GetStart().GetTokenIndex()
+	stop = start - 1
+	if token := ctx.GetStop(); token != nil && token.GetTokenIndex() >= start {
+		stop = token.GetTokenIndex()
+	}
+	return antlrTokens{n.parser.GetTokenStream()}, start, stop, true
+}
+
+// tokenText 范围内token的文本，hidden为false时不包含空白及注释
+func tokenText(tokens tokenSource, start, stop int, hidden bool) string {
+	var sb strings.Builder
+	for i := start; i <= stop; i++ {
+		if text, h := tokens.token(i); hidden || !h {
+			sb.WriteString(text)
+		}
+	}
+	return sb.String()
+}
+
+func (n *Node) detach(sources map[antlr.TokenStream]*source) {
+	tokens, start, stop, ok := n.tokenRange()
+	if !ok {
+		n.parser, n.ruleContext, n.input = nil, nil, nil
+		return
+	}
+	stream := tokens.(antlrTokens).stream
+	src := sources[stream]
+	if src == nil {
+		if n.input != nil {
+			// 同一次解析得到的各语句分别Detach时共享
+			src = n.input.source(stream)
+		} else {
+			src = newSource(stream)
+		}
+		sources[stream] = src
+	}
+	n.pos, n.end = n.Pos(), n.End()
+	n.source, n.start, n.stop = src, start, stop
+	n.parser, n.ruleContext, n.input = nil, nil, nil
+}
+
+func newSource(stream antlr.TokenStream) *source {
+	if s, ok := stream.(interface{ Fill() }); ok {
+		s.Fill()
+	}
+	tokens := antlrTokens{stream}
+	src := &source{tokens: make([]sourceToken, stream.Size())}
+	for i := range src.tokens {
+		src.tokens[i].text, src.tokens[i].hidden = tokens.token(i)
+	}
+	return src
+}
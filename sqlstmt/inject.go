@@ -0,0 +1,306 @@
+package sqlstmt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/may-fly/go-sqlparser/base"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// ErrNotInjectable 无法注入条件，如外连接使用using或natural、full join等条件无法正确限制的语法，
+// 以及table t、lateral、handler、函数表等无法确定条件位置或其中的表的语法
+var ErrNotInjectable = errors.New("sqlstmt: predicate cannot be injected")
+
+// InjectPredicate 为语句中引用的每张表注入过滤条件，返回注入后的sql，mysql与pgsql均适用
+// predicate返回表对应的条件，如 alias.tenant_id = 42，alias为空时为未指定别名，返回空字符串时不注入；条件中包含or时需自行加括号
+// 条件以and连接至表所在的select、update、delete的where中，原where以括号包裹；
+// left join右侧的表、right join左侧的表的条件连接至该join的on中，以免外连接退化为内连接；
+// 子查询、派生表、with及union各分支中的表同样注入，with中定义的公共表表达式不会作为表注入
+func InjectPredicate(stmt Stmt, predicate func(table *TableName, alias string) string) (string, error) {
+	ctx := ruleContextOf(stmt)
+	if ctx == nil {
+		return "", fmt.Errorf("%w: %T", ErrNotInjectable, stmt)
+	}
+	i := &predicateInjector{predicate: predicate, rewriter: base.NewRewriter(stmt.(ruleNode).GetParser())}
+	i.stmt(stmt)
+	if i.err != nil {
+		return "", i.err
+	}
+	return i.rewriter.Text(ctx), nil
+}
+
+// ruleNode 可获取对应语法树节点的语句节点，即内嵌*Node的节点
+type ruleNode interface {
+	GetParser() antlr.Parser
+	GetRuleContext() antlr.RuleContext
+}
+
+// ruleContextOf 节点对应的语法树节点，无法获取时返回nil
+func ruleContextOf(node INode) antlr.ParserRuleContext {
+	rn, ok := node.(ruleNode)
+	if !ok || rn.GetParser() == nil {
+		return nil
+	}
+	ctx, _ := rn.GetRuleContext().(antlr.ParserRuleContext)
+	return ctx
+}
+
+type predicateInjector struct {
+	predicate func(table *TableName, alias string) string
+	rewriter  *base.Rewriter
+	ctes      cteScope
+	err       error
+}
+
+func (i *predicateInjector) fail(format string, args ...any) {
+	if i.err == nil {
+		i.err = fmt.Errorf("%w: "+format, append([]any{ErrNotInjectable}, args...)...)
+	}
+}
+
+func (i *predicateInjector) stmt(stmt Stmt) {
+	switch s := stmt.(type) {
+	case ISelectStmt:
+		i.selectStmt(s)
+	case *InsertStmt:
+		i.insert(s)
+	case *ReplaceStmt:
+		i.insert(&s.InsertStmt)
+	case *UpdateStmt:
+		i.update(s)
+	case *DeleteStmt:
+		i.delete(s)
+	case *ExplainStmt:
+		i.stmt(s.Stmt)
+	case *CallStmt:
+		i.exprs(s.Args)
+	case *SetStmt:
+		i.exprs(s.Values)
+	case *DoStmt:
+		i.exprs(s.Exprs)
+	case *HandlerStmt:
+		// handler read逐行读取表，没有可注入条件的where
+		i.fail("%s", s.GetText())
+	}
+}
+
+func (i *predicateInjector) exprs(exprs []IExpr) {
+	for _, expr := range exprs {
+		eachSubquery(expr, i.selectStmt)
+	}
+}
+
+func (i *predicateInjector) selectStmt(stmt ISelectStmt) {
+	switch s := stmt.(type) {
+	case *SimpleSelectStmt:
+		defer i.ctes.with(s.With, i.stmt)()
+		i.querySpecification(s.QuerySpecification)
+	case *UnionSelectStmt:
+		defer i.ctes.with(s.With, i.stmt)()
+		i.querySpecification(s.QuerySpecification)
+		i.queryExpr(s.QueryExpr)
+		for _, union := range s.UnionStmts {
+			i.querySpecification(union.QuerySpecification)
+			i.queryExpr(union.QueryExpr)
+		}
+	case *ParenthesisSelect:
+		defer i.ctes.with(s.With, i.stmt)()
+		i.queryExpr(s.QueryExpr)
+	case *ValuesStmt:
+		for _, row := range s.Rows {
+			i.exprs(row.Exprs)
+		}
+	case nil:
+	default:
+		// table t、lateral派生表等无法确定条件位置的查询
+		i.fail("%T %s", stmt, stmt.GetText())
+	}
+}
+
+func (i *predicateInjector) queryExpr(qe *QueryExpr) {
+	for ; qe != nil; qe = qe.QueryExpr {
+		i.querySpecification(qe.QuerySpecification)
+	}
+}
+
+func (i *predicateInjector) querySpecification(qs *QuerySpecification) {
+	if qs == nil {
+		return
+	}
+	eachSubquery(qs.SelectElements, i.selectStmt)
+	predicates := i.tableSources(qs.From)
+	eachSubquery(qs.Where, i.selectStmt)
+	for _, expr := range qs.GroupBy {
+		eachSubquery(expr, i.selectStmt)
+	}
+	eachSubquery(qs.Having, i.selectStmt)
+	for _, obe := range qs.OrderBy {
+		eachSubquery(obe.Expr, i.selectStmt)
+	}
+	// pgsql的table t没有where子句
+	if qs.SelectElements == nil && len(predicates) > 0 {
+		i.fail("%s", qs.GetText())
+		return
+	}
+	i.where(qs.Where, qs.From, predicates)
+}
+
+func (i *predicateInjector) insert(s *InsertStmt) {
+	defer i.ctes.with(s.With, i.stmt)()
+	for _, row := range s.Values {
+		i.exprs(row.Exprs)
+	}
+	i.selectStmt(s.SelectStmt)
+	for _, ue := range s.SetElements {
+		eachSubquery(ue.Value, i.selectStmt)
+	}
+	for _, ue := range s.DuplicatedElements {
+		eachSubquery(ue.Value, i.selectStmt)
+	}
+	eachSubquery(s.ConflictWhere, i.selectStmt)
+}
+
+func (i *predicateInjector) update(s *UpdateStmt) {
+	defer i.ctes.with(s.With, i.stmt)()
+	predicates := i.tableSources(s.TableSources)
+	for _, ue := range s.UpdatedElements {
+		eachSubquery(ue.Value, i.selectStmt)
+	}
+	predicates = append(predicates, i.tableSources(s.From)...)
+	eachSubquery(s.Where, i.selectStmt)
+
+	var anchor INode
+	if s.From != nil {
+		anchor = s.From
+	} else if n := len(s.UpdatedElements); n > 0 {
+		anchor = s.UpdatedElements[n-1]
+	}
+	i.where(s.Where, anchor, predicates)
+}
+
+func (i *predicateInjector) delete(s *DeleteStmt) {
+	defer i.ctes.with(s.With, i.stmt)()
+	predicates := i.tableSources(s.TableSources)
+	predicates = append(predicates, i.tableSources(s.Using)...)
+	eachSubquery(s.Where, i.selectStmt)
+
+	var anchor INode
+	if s.Using != nil {
+		anchor = s.Using
+	} else if s.TableSources != nil {
+		anchor = s.TableSources
+	}
+	i.where(s.Where, anchor, predicates)
+}
+
+// tableSources 注入join的on中的条件，返回需连接至where的条件
+func (i *predicateInjector) tableSources(tableSources *TableSources) []string {
+	if tableSources == nil {
+		return nil
+	}
+	var predicates []string
+	for _, ts := range tableSources.TableSources {
+		tsb, ok := ts.(*TableSourceBase)
+		if !ok {
+			continue
+		}
+		// pending 当前join左侧尚未注入的条件
+		pending := i.tableSourceItem(tsb.TableSourceItem)
+		for _, jp := range tsb.JoinParts {
+			joinPart := getJoinPart(jp)
+			if joinPart == nil {
+				continue
+			}
+			joined := i.tableSourceItem(joinPart.TableSourceItem)
+			eachSubquery(joinPart.On, i.selectStmt)
+
+			var outerType string
+			switch j := jp.(type) {
+			case *OuterJoin:
+				outerType = j.Type
+			case *NaturalJoin:
+				outerType = j.Type
+			}
+			switch outerType {
+			case "":
+				pending = append(pending, joined...)
+			case "LEFT":
+				i.on(joinPart, joined)
+			case "RIGHT":
+				i.on(joinPart, pending)
+				pending = joined
+			default:
+				// full join的两侧均保留未匹配的行，无法通过条件限制
+				if len(pending) > 0 || len(joined) > 0 {
+					i.fail("%s join %s", outerType, joinPart.GetText())
+				}
+			}
+		}
+		predicates = append(predicates, pending...)
+	}
+	return predicates
+}
+
+func (i *predicateInjector) tableSourceItem(tsi ITableSourceItem) []string {
+	switch item := tsi.(type) {
+	case *AtomTableItem:
+		tableName := item.TableName
+		if tableName == nil || tableName.Identifier == nil || (tableName.Owner == "" && i.ctes.contains(tableName.Identifier.Value)) {
+			return nil
+		}
+		if predicate := i.predicate(tableName, item.Alias); predicate != "" {
+			return []string{predicate}
+		}
+	case *SubqueryTableItem:
+		i.selectStmt(item.SelectStmt)
+	case *TableSourcesItem:
+		return i.tableSources(item.TableSources)
+	case nil:
+	default:
+		// 函数、json_table等，其参数中可能引用表
+		i.fail("%T %s", tsi, tsi.GetText())
+	}
+	return nil
+}
+
+// on 将条件连接至外连接的on中
+func (i *predicateInjector) on(joinPart *JoinPart, predicates []string) {
+	if len(predicates) == 0 {
+		return
+	}
+	ctx := ruleContextOf(joinPart.On)
+	if ctx == nil {
+		i.fail("outer join without on condition: %s", joinPart.GetText())
+		return
+	}
+	i.rewriter.InsertBefore(ctx, "(")
+	i.rewriter.InsertAfter(ctx, ") AND "+strings.Join(predicates, " AND "))
+}
+
+// where 将条件连接至where中，无where时添加至anchor之后
+func (i *predicateInjector) where(where IExpr, anchor INode, predicates []string) {
+	if len(predicates) == 0 {
+		return
+	}
+	condition := strings.Join(predicates, " AND ")
+	if where != nil {
+		ctx := ruleContextOf(where)
+		if ctx == nil {
+			i.fail("where %s", where.GetText())
+			return
+		}
+		i.rewriter.InsertBefore(ctx, "(")
+		i.rewriter.InsertAfter(ctx, ") AND "+condition)
+		return
+	}
+
+	ctx := ruleContextOf(anchor)
+	if ctx == nil {
+		i.fail("no position for where")
+		return
+	}
+	i.rewriter.InsertAfter(ctx, " WHERE "+condition)
+}
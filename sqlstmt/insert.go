@@ -8,8 +8,45 @@ type (
 	InsertStmt struct {
 		*Node
 
-		TableName *TableName
+		With       *WithClause
+		TableName  *TableName
+		Columns    []*ColumnName // 指定的列
+		Values     []*ValuesRow  // insert ... values中的行
+		SelectStmt ISelectStmt   // insert ... select中的查询
+		// mysql的insert ... set中的赋值
+		SetElements []*UpdatedElement
+		// mysql的on duplicate key update、pgsql的on conflict do update set中的赋值
+		DuplicatedElements []*UpdatedElement
+		ConflictWhere      IExpr // pgsql的on conflict do update中的where条件
+	}
+
+	// ValuesRow insert ... values中的一行
+	ValuesRow struct {
+		*Node
+
+		Exprs []IExpr // default为nil
 	}
 )
 
 func (*InsertStmt) isInsert() {}
+
+func (*InsertStmt) Kind() StmtKind { return KindInsert }
+
+// ReplaceStmt mysql的replace语句
+type ReplaceStmt struct {
+	InsertStmt
+}
+
+func (*ReplaceStmt) Kind() StmtKind { return KindReplace }
+
+// LoadDataStmt mysql的load data、load xml，将文件中的数据写入表
+type LoadDataStmt struct {
+	*Node
+
+	Xml         bool // load xml
+	Replace     bool // 与已有的行冲突时替换
+	TableName   *TableName
+	SetElements []*UpdatedElement // set中的赋值
+}
+
+func (*LoadDataStmt) Kind() StmtKind { return KindLoad }
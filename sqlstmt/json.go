@@ -0,0 +1,1274 @@
+package sqlstmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/may-fly/go-sqlparser/base"
+)
+
+// JSONVersion Marshal输出的json的版本，字段名、类型名或节点的编码方式不兼容地改变时递增，新增字段及类型不改变版本
+const JSONVersion = 1
+
+// Marshal 将节点编码为带版本的json：{"version":1,"sources":[...],"node":{...}}，持久化或跨进程传递节点时使用
+// 节点编码为json对象，"type"为节点的类型名，如"SimpleSelectStmt"、"BinaryComparisonPredicate"，其后为导出的字段，
+// 嵌入的结构体的字段(如SelectStmt的With)与节点的字段同级，键为字段名；接口类型的字段同样编码为带"type"的对象，nil为null；
+// 原sql只在"sources"中输出一次，各元素为{"text":原文,"pos":原文起始的Position}，通常只有一个，
+// 解析得到的节点另有"span":[start,end]，为节点在原文中的字节范围，原文不是第一个时另有"source"为其下标；
+// 节点的MarshalJSON输出的对象本身带有"sources"
+func Marshal(node INode) ([]byte, error) {
+	e := new(encoder)
+	if err := e.encodeNode(node); err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Version int             `json:"version"`
+		Sources []jsonSource    `json:"sources,omitempty"`
+		Node    json.RawMessage `json:"node"`
+	}{JSONVersion, e.sources, e.buf.Bytes()})
+}
+
+// Unmarshal 解码Marshal输出的json，版本不同或节点的类型未知时返回错误
+// 解码得到的节点与Detach后的节点相同，GetText、Pos、End及Restore与编码前一致，GetParser、GetRuleContext返回nil
+func Unmarshal(data []byte) (INode, error) {
+	var envelope struct {
+		Version *int            `json:"version"`
+		Sources []jsonSource    `json:"sources"`
+		Node    json.RawMessage `json:"node"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Version == nil || *envelope.Version != JSONVersion {
+		return nil, fmt.Errorf("sqlstmt: unsupported json version, want %d", JSONVersion)
+	}
+	d := &decoder{sources: envelope.Sources}
+	v, err := d.decodeNode(envelope.Node, inodeType)
+	if err != nil {
+		return nil, err
+	}
+	d.attach()
+	node, _ := v.Interface().(INode)
+	d.seal(node)
+	return node, nil
+}
+
+func (n *Node) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *Node) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *SqlStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *SqlStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *DmlStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *DmlStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *OtherReadStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *OtherReadStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *OtherStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *OtherStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *DclStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *DclStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *TclStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *TclStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ShowStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ShowStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ExplainStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ExplainStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *SetStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *SetStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *UseStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *UseStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *CallStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *CallStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *DoStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *DoStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *LoadDataStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *LoadDataStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *HandlerStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *HandlerStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *LockTablesStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *LockTablesStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *LockTable) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *LockTable) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *SelectStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *SelectStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *QuerySpecification) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *QuerySpecification) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *SimpleSelectStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *SimpleSelectStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *UnionSelectStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *UnionSelectStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ParenthesisSelect) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ParenthesisSelect) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *LateralSelectStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *LateralSelectStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *TableStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *TableStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ValuesStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ValuesStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *QueryExpr) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *QueryExpr) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *SelectElements) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *SelectElements) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *SelectStarElement) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *SelectStarElement) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *SelectColumnElement) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *SelectColumnElement) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *SelectFunctionElement) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *SelectFunctionElement) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *SelectExpressionElement) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *SelectExpressionElement) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *JoinPart) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *JoinPart) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *InnerJoin) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *InnerJoin) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *OuterJoin) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *OuterJoin) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *NaturalJoin) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *NaturalJoin) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *UnionStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *UnionStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *WithClause) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *WithClause) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *CommonTableExpr) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *CommonTableExpr) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *OrderByExpr) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *OrderByExpr) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *Limit) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *Limit) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *Expr) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *Expr) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *LogicalExpr) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *LogicalExpr) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *PredicateExpr) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *PredicateExpr) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *NotExpr) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *NotExpr) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *IsExpr) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *IsExpr) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *Predicate) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *Predicate) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *BinaryComparisonPredicate) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *BinaryComparisonPredicate) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *InPredicate) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *InPredicate) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *SubqueryComparisonPredicate) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *SubqueryComparisonPredicate) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *IsNullPredicate) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *IsNullPredicate) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *BetweenPredicate) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *BetweenPredicate) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *LikePredicate) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *LikePredicate) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *RegexpPredicate) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *RegexpPredicate) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ExprAtomPredicate) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ExprAtomPredicate) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ExprAtom) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ExprAtom) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ExprAtomFunctionCall) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ExprAtomFunctionCall) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ExprAtomConstant) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ExprAtomConstant) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ExprAtomColumnName) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ExprAtomColumnName) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ExprAtomSubquery) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ExprAtomSubquery) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ExprAtomExists) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ExprAtomExists) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ExprAtomUnary) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ExprAtomUnary) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ExprAtomNested) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ExprAtomNested) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ExprAtomArithmetic) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ExprAtomArithmetic) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *TableSource) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *TableSource) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *TableSources) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *TableSources) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *TableSourceBase) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *TableSourceBase) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *TableSourceItem) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *TableSourceItem) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *AtomTableItem) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *AtomTableItem) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *SubqueryTableItem) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *SubqueryTableItem) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *TableSourcesItem) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *TableSourcesItem) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *Constant) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *Constant) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *FullId) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *FullId) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ColumnName) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ColumnName) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *TableName) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *TableName) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *InsertStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *InsertStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ReplaceStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ReplaceStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *UpdateStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *UpdateStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *UpdatedElement) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *UpdatedElement) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *ValuesRow) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *ValuesRow) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *DeleteStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *DeleteStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *DdlStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *DdlStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *CreateDatabase) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *CreateDatabase) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *CreateTable) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *CreateTable) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *CreateIndex) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *CreateIndex) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *CreateView) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *CreateView) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *AlterTable) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *AlterTable) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *AlterDatabase) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *AlterDatabase) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *DropDatabase) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *DropDatabase) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *DropIndex) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *DropIndex) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *DropTable) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *DropTable) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *DropView) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *DropView) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *RenameTable) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *RenameTable) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *TruncateTable) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *TruncateTable) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (n *MergeStmt) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *MergeStmt) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+// nodeTypes 可编码为json的节点类型，键为"type"
+var nodeTypes = func() map[string]reflect.Type {
+	types := make(map[string]reflect.Type)
+	for _, node := range []INode{
+		(*Node)(nil), (*SqlStmt)(nil), (*DmlStmt)(nil), (*OtherReadStmt)(nil), (*OtherStmt)(nil), (*DclStmt)(nil),
+		(*TclStmt)(nil), (*ShowStmt)(nil), (*ExplainStmt)(nil), (*SetStmt)(nil), (*UseStmt)(nil), (*CallStmt)(nil),
+		(*SelectStmt)(nil), (*QuerySpecification)(nil), (*SimpleSelectStmt)(nil), (*UnionSelectStmt)(nil),
+		(*ParenthesisSelect)(nil), (*QueryExpr)(nil), (*SelectElements)(nil), (*SelectStarElement)(nil),
+		(*SelectColumnElement)(nil), (*SelectFunctionElement)(nil), (*SelectExpressionElement)(nil),
+		(*JoinPart)(nil), (*InnerJoin)(nil), (*OuterJoin)(nil), (*NaturalJoin)(nil), (*UnionStmt)(nil),
+		(*WithClause)(nil), (*CommonTableExpr)(nil), (*OrderByExpr)(nil), (*Limit)(nil), (*Expr)(nil),
+		(*LogicalExpr)(nil), (*PredicateExpr)(nil), (*NotExpr)(nil), (*IsExpr)(nil), (*Predicate)(nil),
+		(*BinaryComparisonPredicate)(nil), (*InPredicate)(nil), (*SubqueryComparisonPredicate)(nil),
+		(*IsNullPredicate)(nil), (*BetweenPredicate)(nil), (*LikePredicate)(nil), (*RegexpPredicate)(nil),
+		(*ExprAtomPredicate)(nil), (*ExprAtom)(nil), (*ExprAtomFunctionCall)(nil), (*ExprAtomConstant)(nil),
+		(*ExprAtomColumnName)(nil), (*ExprAtomSubquery)(nil), (*ExprAtomExists)(nil), (*ExprAtomUnary)(nil),
+		(*ExprAtomNested)(nil), (*ExprAtomArithmetic)(nil), (*TableSource)(nil), (*TableSources)(nil),
+		(*TableSourceBase)(nil), (*TableSourceItem)(nil), (*AtomTableItem)(nil), (*SubqueryTableItem)(nil),
+		(*TableSourcesItem)(nil), (*Constant)(nil), (*FullId)(nil), (*ColumnName)(nil), (*TableName)(nil),
+		(*InsertStmt)(nil), (*ReplaceStmt)(nil), (*ValuesRow)(nil), (*UpdateStmt)(nil), (*UpdatedElement)(nil),
+		(*DeleteStmt)(nil), (*DdlStmt)(nil), (*CreateDatabase)(nil), (*CreateTable)(nil), (*CreateIndex)(nil),
+		(*CreateView)(nil), (*AlterTable)(nil), (*AlterDatabase)(nil), (*DropDatabase)(nil), (*DropIndex)(nil),
+		(*DropTable)(nil), (*DropView)(nil), (*RenameTable)(nil), (*TruncateTable)(nil), (*MergeStmt)(nil),
+		(*DoStmt)(nil), (*LateralSelectStmt)(nil), (*TableStmt)(nil), (*ValuesStmt)(nil), (*LoadDataStmt)(nil),
+		(*HandlerStmt)(nil), (*LockTablesStmt)(nil), (*LockTable)(nil),
+	} {
+		t := reflect.TypeOf(node).Elem()
+		types[t.Name()] = t
+	}
+	return types
+}()
+
+var (
+	inodeType   = reflect.TypeOf((*INode)(nil)).Elem()
+	nodePtrType = reflect.TypeOf((*Node)(nil))
+	anyType     = reflect.TypeOf((*any)(nil)).Elem()
+)
+
+// isNodeType 字段是否为节点，即节点的指针或接口
+func isNodeType(t reflect.Type) bool {
+	return (t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface) && t.Implements(inodeType)
+}
+
+// eachField 遍历结构体导出的字段，嵌入的结构体的字段与其同级，跳过嵌入的*Node
+func eachField(v reflect.Value, f func(name string, field reflect.Value) bool) bool {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		switch {
+		case !sf.IsExported() || sf.Type == nodePtrType:
+		case sf.Anonymous && sf.Type.Kind() == reflect.Struct:
+			if !eachField(v.Field(i), f) {
+				return false
+			}
+		default:
+			if !f(sf.Name, v.Field(i)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// jsonSource 编码的节点所在的原文
+type jsonSource struct {
+	Text string   `json:"text"`
+	Pos  Position `json:"pos"`
+}
+
+type encoder struct {
+	buf     bytes.Buffer
+	sources []jsonSource
+}
+
+func marshalNode(node INode) ([]byte, error) {
+	e := new(encoder)
+	if err := e.encodeNode(node); err != nil {
+		return nil, err
+	}
+	data := e.buf.Bytes()
+	if len(e.sources) == 0 {
+		return data, nil
+	}
+	sources, err := json.Marshal(e.sources)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(`{"sources":`), sources...), append([]byte{','}, data[1:]...)...), nil
+}
+
+func (e *encoder) encodeNode(node INode) error {
+	buf := &e.buf
+	if isNil(node) {
+		buf.WriteString("null")
+		return nil
+	}
+	v := reflect.ValueOf(node)
+	t := v.Type().Elem()
+	if v.Kind() != reflect.Pointer || nodeTypes[t.Name()] != t {
+		return fmt.Errorf("sqlstmt: cannot marshal node type %s", v.Type())
+	}
+	// 先于子节点确定原文，子节点通常位于其中
+	source, start, end := -1, 0, 0
+	if node.Pos().IsValid() {
+		source, start, end = e.span(node)
+	}
+	buf.WriteString(`{"type":`)
+	buf.WriteString(strconv.Quote(t.Name()))
+	var err error
+	eachField(v.Elem(), func(name string, field reflect.Value) bool {
+		buf.WriteString(`,"` + name + `":`)
+		err = e.encodeValue(field)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if source >= 0 {
+		buf.WriteString(`,"span":[` + strconv.Itoa(start) + "," + strconv.Itoa(end) + "]")
+		if source > 0 {
+			buf.WriteString(`,"source":` + strconv.Itoa(source))
+		}
+		// 被修改的节点解码后同样按字段生成
+		if modified(node) {
+			buf.WriteString(`,"modified":true`)
+			if !keepsSource(node) {
+				buf.WriteString(`,"lossy":true`)
+			}
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// span 节点所在的原文的下标及节点在其中的字节范围，不在已有的原文中时以节点的原文作为新的原文
+func (e *encoder) span(node INode) (source, start, end int) {
+	text, pos := node.GetText(), node.Pos()
+	for i, s := range e.sources {
+		start := pos.Offset - s.Pos.Offset
+		if start >= 0 && start+len(text) <= len(s.Text) && s.Text[start:start+len(text)] == text {
+			return i, start, start + len(text)
+		}
+	}
+	e.sources = append(e.sources, jsonSource{Text: text, Pos: pos})
+	return len(e.sources) - 1, 0, len(text)
+}
+
+func (e *encoder) encodeValue(v reflect.Value) error {
+	buf := &e.buf
+	switch t := v.Type(); {
+	case isNodeType(t):
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return e.encodeNode(v.Interface().(INode))
+	case t.Kind() == reflect.Slice && isNodeType(t.Elem()):
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := e.encodeValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case t == anyType:
+		// 小数保留小数点，解码时与整数区分
+		if f, ok := v.Interface().(float64); ok {
+			if s := strconv.FormatFloat(f, 'g', -1, 64); !strings.ContainsAny(s, ".eEIN") {
+				buf.WriteString(s + ".0")
+				return nil
+			}
+		}
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+func unmarshalNode(data []byte, node INode) error {
+	d := new(decoder)
+	fields, name, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+	if data, ok := fields["sources"]; ok {
+		if err := json.Unmarshal(data, &d.sources); err != nil {
+			return err
+		}
+	}
+	v := reflect.ValueOf(node)
+	if t := v.Type().Elem(); name != t.Name() {
+		return fmt.Errorf("sqlstmt: cannot unmarshal node type %q into %s", name, t.Name())
+	}
+	if err := d.decodeInto(v, fields); err != nil {
+		return err
+	}
+	d.attach()
+	d.seal(node)
+	return nil
+}
+
+// seal 记录解码得到的字段，编码时已被修改的节点仍视为修改
+func (d *decoder) seal(node INode) {
+	Seal(node)
+	for n, lossy := range d.modified {
+		markModified(n, lossy)
+	}
+}
+
+// decodeObject 解码节点的json对象，返回字段及"type"
+func decodeObject(data []byte) (map[string]json.RawMessage, string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, "", err
+	}
+	var name string
+	if err := json.Unmarshal(fields["type"], &name); err != nil || name == "" {
+		return nil, "", fmt.Errorf("sqlstmt: node without type")
+	}
+	return fields, name, nil
+}
+
+type decoder struct {
+	sources  []jsonSource
+	nodes    []decodedNode
+	modified map[INode]bool // 编码时已被修改的节点，值为按字段生成时是否无法保留原sql中的各token
+}
+
+// decodedNode 带有原文范围的节点，解码完成后由attach设置token序列及位置
+type decodedNode struct {
+	node               *Node
+	source, start, end int
+}
+
+// decodeNode 解码类型为t(节点的指针或接口)的字段
+func (d *decoder) decodeNode(data json.RawMessage, t reflect.Type) (reflect.Value, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return reflect.Zero(t), nil
+	}
+	fields, name, err := decodeObject(data)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	nt := nodeTypes[name]
+	if nt == nil {
+		return reflect.Value{}, fmt.Errorf("sqlstmt: unknown node type %q", name)
+	}
+	if !reflect.PointerTo(nt).AssignableTo(t) {
+		return reflect.Value{}, fmt.Errorf("sqlstmt: node type %q is not %s", name, t)
+	}
+	v := reflect.New(nt)
+	if err := d.decodeInto(v, fields); err != nil {
+		return reflect.Value{}, err
+	}
+	return v, nil
+}
+
+func (d *decoder) decodeInto(v reflect.Value, fields map[string]json.RawMessage) error {
+	var err error
+	eachField(v.Elem(), func(name string, field reflect.Value) bool {
+		if data, ok := fields[name]; ok {
+			err = d.decodeValue(data, field)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if _, ok := fields["span"]; !ok {
+		return nil
+	}
+	if data, ok := fields["modified"]; ok && string(data) == "true" {
+		if d.modified == nil {
+			d.modified = map[INode]bool{}
+		}
+		d.modified[v.Interface().(INode)] = string(fields["lossy"]) == "true"
+	}
+	var n decodedNode
+	var span [2]int
+	if err := json.Unmarshal(fields["span"], &span); err != nil {
+		return err
+	}
+	if data, ok := fields["source"]; ok {
+		if err := json.Unmarshal(data, &n.source); err != nil {
+			return err
+		}
+	}
+	n.start, n.end = span[0], span[1]
+	if n.source < 0 || n.source >= len(d.sources) || n.start < 0 || n.start > n.end || n.end > len(d.sources[n.source].Text) {
+		return fmt.Errorf("sqlstmt: invalid span %v of source %d", span, n.source)
+	}
+	node, ok := v.Interface().(*Node)
+	if !ok {
+		node = new(Node)
+		v.Elem().FieldByName("Node").Set(reflect.ValueOf(node))
+	}
+	n.node = node
+	d.nodes = append(d.nodes, n)
+	return nil
+}
+
+func (d *decoder) decodeValue(data json.RawMessage, v reflect.Value) error {
+	switch t := v.Type(); {
+	case isNodeType(t):
+		node, err := d.decodeNode(data, t)
+		if err != nil {
+			return err
+		}
+		v.Set(node)
+	case t.Kind() == reflect.Slice && isNodeType(t.Elem()):
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		if items == nil {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		s := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			node, err := d.decodeNode(item, t.Elem())
+			if err != nil {
+				return err
+			}
+			s.Index(i).Set(node)
+		}
+		v.Set(s)
+	case t == anyType:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var arg any
+		if err := dec.Decode(&arg); err != nil {
+			return err
+		}
+		if n, ok := arg.(json.Number); ok {
+			if arg, ok = base.NumberValue(string(n)); !ok {
+				return fmt.Errorf("sqlstmt: invalid number %s", n)
+			}
+		}
+		if arg != nil {
+			v.Set(reflect.ValueOf(arg))
+		}
+	default:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+	return nil
+}
+
+// attach 为解码得到的节点设置token序列及位置，同一原文中的节点共享由原文切分出的token，使Restore可保留原sql中字段未表示的部分
+func (d *decoder) attach() {
+	groups := make([][]decodedNode, len(d.sources))
+	for _, n := range d.nodes {
+		groups[n.source] = append(groups[n.source], n)
+	}
+	for i, nodes := range groups {
+		if len(nodes) == 0 {
+			continue
+		}
+		text := d.sources[i].Text
+		offsets := []int{0, len(text)}
+		for _, n := range nodes {
+			offsets = append(offsets, n.start, n.end)
+		}
+		sort.Ints(offsets)
+		src := new(source)
+		index := make(map[int]int)
+		positions := make(map[int]Position)
+		pos := d.sources[i].Pos
+		for j, offset := range offsets {
+			if j > 0 {
+				pos = advance(pos, text[offsets[j-1]:offset])
+			}
+			index[offset], positions[offset] = len(src.tokens), pos
+			if j+1 < len(offsets) && offsets[j+1] > offset {
+				src.tokens = append(src.tokens, splitText(text[offset:offsets[j+1]])...)
+			}
+		}
+		for _, n := range nodes {
+			*n.node = Node{
+				source: src,
+				start:  index[n.start],
+				stop:   index[n.end] - 1,
+				pos:    positions[n.start],
+				end:    positions[n.end],
+			}
+		}
+	}
+}
+
+// advance 位于pos的text之后的位置
+func advance(pos Position, text string) Position {
+	pos.Offset += len(text)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		pos.Line += strings.Count(text, "\n")
+		pos.Column = utf8.RuneCountInString(text[i+1:])
+	} else {
+		pos.Column += utf8.RuneCountInString(text)
+	}
+	return pos
+}
+
+// splitText 将原文粗略地切分为token：空白、注释、引号包围的字符串或标识符、单词及单个符号
+func splitText(text string) []sourceToken {
+	var tokens []sourceToken
+	for i := 0; i < len(text); {
+		j, hidden := i+1, false
+		switch c := text[i]; {
+		case isSpace(c):
+			for j < len(text) && isSpace(text[j]) {
+				j++
+			}
+			hidden = true
+		case strings.HasPrefix(text[i:], "/*"):
+			if k := strings.Index(text[i+2:], "*/"); k >= 0 {
+				j = i + 2 + k + 2
+			} else {
+				j = len(text)
+			}
+			hidden = true
+		case strings.HasPrefix(text[i:], "--"):
+			if k := strings.IndexByte(text[i:], '\n'); k >= 0 {
+				j = i + k
+			} else {
+				j = len(text)
+			}
+			hidden = true
+		case c == '\'' || c == '"' || c == '`':
+			if k := strings.IndexByte(text[i+1:], c); k >= 0 {
+				j = i + 1 + k + 1
+			} else {
+				j = len(text)
+			}
+		case isWord(c):
+			for j < len(text) && isWord(text[j]) {
+				j++
+			}
+		}
+		tokens = append(tokens, sourceToken{text: text[i:j], hidden: hidden})
+		i = j
+	}
+	return tokens
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isWord(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,82 @@
+package sqlstmt
+
+import "strconv"
+
+// StmtKind 语句类型
+type StmtKind int
+
+const (
+	KindOther StmtKind = iota
+	KindSelect
+	KindInsert
+	KindReplace
+	KindUpdate
+	KindDelete
+	KindMerge
+	KindDDL
+	KindDCL
+	KindTCL
+	KindShow
+	KindExplain
+	KindSet
+	KindUse
+	KindCall
+	KindLoad    // mysql的load data、load xml，将文件中的数据写入表
+	KindHandler // mysql的handler，按索引逐行读取表
+)
+
+var stmtKindNames = [...]string{
+	KindOther:   "Other",
+	KindSelect:  "Select",
+	KindInsert:  "Insert",
+	KindReplace: "Replace",
+	KindUpdate:  "Update",
+	KindDelete:  "Delete",
+	KindMerge:   "Merge",
+	KindDDL:     "DDL",
+	KindDCL:     "DCL",
+	KindTCL:     "TCL",
+	KindShow:    "Show",
+	KindExplain: "Explain",
+	KindSet:     "Set",
+	KindUse:     "Use",
+	KindCall:    "Call",
+	KindLoad:    "Load",
+	KindHandler: "Handler",
+}
+
+func (k StmtKind) String() string {
+	if k >= 0 && int(k) < len(stmtKindNames) {
+		return stmtKindNames[k]
+	}
+	return "StmtKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// IsReadOnly 语句是否只读，只读角色仅可执行只读语句
+// 只读语句为无副作用的select(见SelectSideEffects)、show、use、handler、非analyze的explain(analyze时取决于被分析的语句)及OtherReadStmt，
+// set、call等可能修改数据或全局状态的语句均不是只读
+func IsReadOnly(stmt Stmt) bool {
+	if stmt == nil {
+		return false
+	}
+
+	switch stmt.Kind() {
+	case KindSelect:
+		if selectStmt, ok := stmt.(ISelectStmt); ok {
+			return selectStmt.GetSideEffects().IsReadOnly()
+		}
+		return true
+	case KindShow, KindUse, KindHandler:
+		return true
+	case KindExplain:
+		explain, ok := stmt.(*ExplainStmt)
+		if !ok {
+			return false
+		}
+		return !explain.Analyze || IsReadOnly(explain.Stmt)
+	case KindOther:
+		_, ok := stmt.(*OtherReadStmt)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,8 @@
+package sqlstmt
+
+// MergeStmt pgsql的merge语句
+type MergeStmt struct {
+	*Node
+}
+
+func (*MergeStmt) Kind() StmtKind { return KindMerge }
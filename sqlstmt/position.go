@@ -0,0 +1,153 @@
+package sqlstmt
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// Position 节点在原sql中的位置，Line从1开始，Column为该行中之前的字符数，与base.SyntaxError的行列相同，Offset为字节偏移
+// 浏览器中的编辑器(如Monaco、CodeMirror)使用UTF-16的偏移，见base.UTF16Offset、base.UTF16Column
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// IsValid 手动构造的节点的位置无效
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	return strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+}
+
+// Origin 被解析的sql在原sql中的起始位置，取值与base.ParseErrorListener的BaseLine、BaseColumn、BaseOffset相同
+type Origin struct {
+	Line   int // 第一行之前的行数
+	Column int // 第一行中之前的字符数
+	Offset int // 之前的字节数
+}
+
+// SetOrigin 设置节点及其子孙节点的Origin，由原sql切割出的语句解析后调用，使节点的位置为在原sql中的位置
+func SetOrigin(node INode, origin Origin) {
+	setInput(&input{origin: origin}, node)
+}
+
+// SetParsed 由同一sql解析得到语句后调用：设置各节点的Origin(见SetOrigin)并记录字段(见Seal)，
+// 各节点共享由字符序号计算字节偏移的表，计算位置时不再遍历之前的sql
+func SetParsed(stmts []Stmt, origin Origin) {
+	in := &input{origin: origin}
+	for _, stmt := range stmts {
+		setInput(in, stmt)
+		Seal(stmt)
+	}
+}
+
+func setInput(in *input, node INode) {
+	Inspect(node, func(n INode) bool {
+		if rn, ok := n.(interface{ getNode() *Node }); ok && rn.getNode() != nil {
+			rn.getNode().input = in
+		}
+		return true
+	})
+}
+
+// input 被解析的sql，同一次解析得到的节点共享
+type input struct {
+	origin  Origin
+	once    sync.Once
+	offsets []int // 各字符的字节偏移，最后为sql的字节数
+
+	sourceOnce sync.Once
+	src        *source // Detach后代替token流
+}
+
+// source Detach时token流的文本，首次调用时复制
+func (in *input) source(stream antlr.TokenStream) *source {
+	in.sourceOnce.Do(func() {
+		in.src = newSource(stream)
+	})
+	return in.src
+}
+
+// offset 被解析的sql中第runeIndex个字符的字节偏移，首次调用时计算各字符的偏移
+func (in *input) offset(stream antlr.CharStream, runeIndex int) int {
+	in.once.Do(func() {
+		text := stream.GetTextFromInterval(antlr.NewInterval(0, stream.Size()-1))
+		in.offsets = make([]int, 0, stream.Size()+1)
+		for i := range text {
+			in.offsets = append(in.offsets, i)
+		}
+		in.offsets = append(in.offsets, len(text))
+	})
+	return in.offsets[min(runeIndex, len(in.offsets)-1)]
+}
+
+func (n *Node) getNode() *Node {
+	return n
+}
+
+func (n *Node) Pos() Position {
+	if n != nil && n.source != nil {
+		return n.pos
+	}
+	ctx := ruleContextOf(n)
+	if ctx == nil || ctx.GetStart() == nil {
+		return Position{}
+	}
+	start := ctx.GetStart()
+	return n.position(start.GetInputStream(), start.GetLine(), start.GetColumn(), start.GetStart())
+}
+
+func (n *Node) End() Position {
+	if n != nil && n.source != nil {
+		return n.end
+	}
+	ctx := ruleContextOf(n)
+	if ctx == nil || ctx.GetStart() == nil {
+		return Position{}
+	}
+	stop := ctx.GetStop()
+	if stop == nil || stop.GetTokenIndex() < ctx.GetStart().GetTokenIndex() {
+		// 空的语法规则
+		return n.Pos()
+	}
+	input := stop.GetInputStream()
+	text := input.GetTextFromInterval(antlr.NewInterval(stop.GetStart(), stop.GetStop()))
+	line, column := stop.GetLine(), stop.GetColumn()
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		line += strings.Count(text, "\n")
+		column = utf8.RuneCountInString(text[i+1:])
+	} else {
+		column += utf8.RuneCountInString(text)
+	}
+	return n.position(input, line, column, stop.GetStop()+1)
+}
+
+// position 由被解析的sql中的行列及字符序号计算在原sql中的位置
+func (n *Node) position(stream antlr.CharStream, line, column, runeIndex int) Position {
+	pos := Position{Line: line, Column: column}
+	in := n.input
+	if in == nil {
+		// 未经SetParsed、SetOrigin的节点，不缓存偏移
+		in = &input{}
+	}
+	if runeIndex > 0 {
+		pos.Offset = in.offset(stream, runeIndex)
+	}
+	o := in.origin
+	if line == 1 {
+		pos.Column += o.Column
+	}
+	pos.Line += o.Line
+	pos.Offset += o.Offset
+	return pos
+}
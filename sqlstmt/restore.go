@@ -0,0 +1,1300 @@
+package sqlstmt
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Dialect 生成sql时使用的数据库方言，取值与sqlparser.DbDialect相同
+type Dialect string
+
+const (
+	DialectMysql Dialect = "mysql"
+	DialectPgsql Dialect = "pgsql"
+)
+
+// ErrNotRestorable 无法由节点生成sql，如手动构造的未解析为具体类型的表达式、缺少必需字段的节点
+var ErrNotRestorable = errors.New("sqlstmt: node cannot be restored")
+
+// SQL 由节点生成sql，见INode.Restore
+func SQL(node INode, dialect Dialect) (string, error) {
+	var sb strings.Builder
+	if err := node.Restore(&sb, dialect); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// Restore 未单独处理的节点以原sql生成，见INode.Restore
+func (n *Node) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ExplainStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *SimpleSelectStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *UnionSelectStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ParenthesisSelect) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *LateralSelectStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *TableStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ValuesStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *QueryExpr) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *UnionStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *QuerySpecification) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *SelectElements) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *SelectStarElement) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *SelectColumnElement) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *SelectFunctionElement) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *SelectExpressionElement) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *OrderByExpr) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *Limit) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *WithClause) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *CommonTableExpr) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *Expr) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *LogicalExpr) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *PredicateExpr) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *NotExpr) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *IsExpr) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *Predicate) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *BinaryComparisonPredicate) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *InPredicate) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *SubqueryComparisonPredicate) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *IsNullPredicate) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *BetweenPredicate) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *LikePredicate) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *RegexpPredicate) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ExprAtomPredicate) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ExprAtom) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ExprAtomConstant) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ExprAtomColumnName) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ExprAtomSubquery) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ExprAtomExists) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ExprAtomUnary) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ExprAtomNested) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ExprAtomArithmetic) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *TableSources) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *TableSourceBase) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *AtomTableItem) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *SubqueryTableItem) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *TableSourcesItem) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *JoinPart) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *InnerJoin) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *OuterJoin) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *NaturalJoin) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *Constant) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *FullId) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ColumnName) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *TableName) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *InsertStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ReplaceStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *UpdateStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *UpdatedElement) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *ValuesRow) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *LoadDataStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *HandlerStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *LockTablesStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *LockTable) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *DoStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *CallStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *SetStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *DeleteStmt) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *CreateTable) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *CreateIndex) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *CreateView) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *AlterTable) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *DropIndex) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *DropTable) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *DropView) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *RenameTable) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+func (n *TruncateTable) Restore(w *strings.Builder, dialect Dialect) error {
+	return restore(w, dialect, n)
+}
+
+// restore 各节点Restore的实现，节点按类型分派，同一次生成中各节点的位置及生成方式只计算一次
+func restore(w *strings.Builder, dialect Dialect, node INode) error {
+	r := &restorer{w: w, dialect: dialect, spans: map[INode]span{}, splices: map[INode]bool{}, escapes: map[INode][]INode{}}
+	if isNil(node) {
+		return fmt.Errorf("%w: nil node", ErrNotRestorable)
+	}
+	// 子节点不在该节点范围内时(如pgsql中query的order by、limit)无法以原sql生成，尽量按字段生成
+	if r.canSplice(node) && len(r.escaped(node)) > 0 {
+		var sb strings.Builder
+		gr := &restorer{w: &sb, dialect: dialect, spans: r.spans, splices: r.splices, escapes: r.escapes}
+		if gr.lossless(node) && gr.generate(node) && gr.err == nil {
+			w.WriteString(sb.String())
+			return nil
+		}
+	}
+	r.restore(node)
+	return r.err
+}
+
+// span 节点在原sql中的token范围，stop小于start时为空的语法规则，位于start之前
+type span struct {
+	node        INode
+	tokens      tokenSource
+	start, stop int
+	ok          bool
+}
+
+func (s span) contains(o span) bool {
+	return o.start >= s.start && o.stop <= s.stop
+}
+
+type restorer struct {
+	w       *strings.Builder
+	dialect Dialect
+	err     error
+
+	spans   map[INode]span
+	splices map[INode]bool
+	escapes map[INode][]INode
+
+	original bool // 子节点输出原sql的token，见lossless
+}
+
+func (r *restorer) write(ss ...string) {
+	for _, s := range ss {
+		r.w.WriteString(s)
+	}
+}
+
+func (r *restorer) fail(node INode) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: %T", ErrNotRestorable, node)
+	}
+}
+
+// restore 以原sql生成节点，无法以原sql生成时按字段生成
+func (r *restorer) restore(node INode) {
+	if r.err != nil {
+		return
+	}
+	if isNil(node) {
+		r.err = fmt.Errorf("%w: missing %T", ErrNotRestorable, node)
+		return
+	}
+	if r.original {
+		if s := r.span(node); s.ok && len(r.escaped(node)) == 0 {
+			r.write(" ", sourceText(s), " ")
+		} else if !r.generate(sealed(node)) {
+			r.fail(node)
+		}
+		return
+	}
+	if r.canSplice(node) {
+		r.splice(node)
+		return
+	}
+	if !r.lossless(node) || !r.generate(node) {
+		r.fail(node)
+	}
+}
+
+// lossless 解析得到的节点按字段生成时是否保留原sql中的各token：以Seal时的字段生成，其中的子节点输出原sql，
+// 原sql的token均依次出现时为true，字段未表示的语法(如returning、for update)丢失时为false
+func (r *restorer) lossless(node INode) bool {
+	s := r.span(node)
+	if r.original || !s.ok || r.preferFields(node) {
+		return true
+	}
+	if seal := sealOf(node); seal != nil && seal.dirty {
+		// 编码时已检查
+		return !seal.lossy
+	}
+	var sb strings.Builder
+	sr := &restorer{w: &sb, dialect: r.dialect, spans: r.spans, splices: r.splices, escapes: r.escapes, original: true}
+	if !sr.generate(sealed(node)) || sr.err != nil {
+		return true
+	}
+	generated := sqlWords(sb.String())
+	for _, word := range sqlWords(sourceText(s)) {
+		for len(generated) > 0 && generated[0] != word {
+			generated = generated[1:]
+		}
+		if len(generated) == 0 {
+			return false
+		}
+		generated = generated[1:]
+	}
+	return true
+}
+
+// keepsSource 被修改的节点在任一方言中按字段生成时是否保留原sql中的各token，编码时记录
+func keepsSource(node INode) bool {
+	for _, dialect := range []Dialect{DialectMysql, DialectPgsql} {
+		r := &restorer{dialect: dialect, spans: map[INode]span{}, splices: map[INode]bool{}, escapes: map[INode][]INode{}}
+		if r.lossless(node) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceText 节点范围内的非空白token，以空格分隔
+func sourceText(s span) string {
+	var sb strings.Builder
+	for i := s.start; i <= s.stop; i++ {
+		if text, hidden := s.tokens.token(i); !hidden {
+			sb.WriteString(text)
+			sb.WriteByte(' ')
+		}
+	}
+	return sb.String()
+}
+
+// sqlWords 按字母数字及其他字符拆分sql并转为小写，用于比较生成的sql与原sql
+func sqlWords(sql string) []string {
+	var words []string
+	start := -1
+	for i, c := range sql {
+		word := c == '_' || c == '$' || unicode.IsLetter(c) || unicode.IsDigit(c)
+		if start >= 0 && !word {
+			words = append(words, strings.ToLower(sql[start:i]))
+			start = -1
+		}
+		switch {
+		case word && start < 0:
+			start = i
+		case !word && !unicode.IsSpace(c):
+			words = append(words, string(c))
+		}
+	}
+	if start >= 0 {
+		words = append(words, strings.ToLower(sql[start:]))
+	}
+	return words
+}
+
+func (r *restorer) span(node INode) span {
+	if s, ok := r.spans[node]; ok {
+		return s
+	}
+	s := span{node: node}
+	if rn, ok := node.(interface{ getNode() *Node }); ok {
+		s.tokens, s.start, s.stop, s.ok = rn.getNode().tokenRange()
+	}
+	r.spans[node] = s
+	return s
+}
+
+// canSplice 是否以原sql生成：节点由解析得到且各子节点均可在原sql中定位；
+// 范围内按字段生成的子节点不能有位于其范围外的子节点，否则其范围外的原sql与按字段生成的部分重复
+func (r *restorer) canSplice(node INode) bool {
+	if splice, ok := r.splices[node]; ok {
+		return splice
+	}
+	splice := r.spliceable(node)
+	r.splices[node] = splice
+	return splice
+}
+
+func (r *restorer) spliceable(node INode) bool {
+	s := r.span(node)
+	if !s.ok || modified(node) || r.preferFields(node) {
+		return false
+	}
+	for _, child := range children(node) {
+		cs := r.span(child)
+		if !cs.ok || cs.tokens != s.tokens {
+			return false
+		}
+		if s.contains(cs) && !r.canSplice(child) && len(r.escaped(child)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// escaped 位于节点范围外的子孙节点，由包含其位置的祖先节点以原sql生成时生成
+func (r *restorer) escaped(node INode) []INode {
+	if nodes, ok := r.escapes[node]; ok {
+		return nodes
+	}
+	var nodes []INode
+	s := r.span(node)
+	for _, child := range children(node) {
+		cs := r.span(child)
+		if !cs.ok {
+			continue
+		}
+		if !s.contains(cs) {
+			nodes = append(nodes, child)
+		}
+		for _, e := range r.escaped(child) {
+			if !s.contains(r.span(e)) {
+				nodes = append(nodes, e)
+			}
+		}
+	}
+	r.escapes[node] = nodes
+	return nodes
+}
+
+// splice 输出原sql中节点范围内的token(包括空白及注释)，子节点及其范围外的子孙节点在其位置生成
+func (r *restorer) splice(node INode) {
+	s := r.span(node)
+	var points []span
+	for _, child := range children(node) {
+		cs := r.span(child)
+		if !s.contains(cs) {
+			continue
+		}
+		points = append(points, cs)
+		if r.canSplice(child) {
+			for _, e := range r.escaped(child) {
+				if es := r.span(e); s.contains(es) {
+					points = append(points, es)
+				}
+			}
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		if points[i].start != points[j].start {
+			return points[i].start < points[j].start
+		}
+		return points[i].stop > points[j].stop
+	})
+
+	next := s.start
+	for _, p := range points {
+		// 与前一个子节点重叠
+		if p.start < next {
+			continue
+		}
+		r.write(tokenText(s.tokens, next, p.start-1, true))
+		r.restore(p.node)
+		next = max(p.start, p.stop+1)
+	}
+	r.write(tokenText(s.tokens, next, s.stop, true))
+}
+
+// preferFields 由解析得到的表名、列名、常量及limit在字段可完整表示原sql时按字段生成，使修改字段后生成的sql随之改变
+func (r *restorer) preferFields(node INode) bool {
+	switch n := node.(type) {
+	case *TableName, *ColumnName, *FullId, *SelectStarElement:
+		return r.tokensMatch(node, isNameToken)
+	case *Constant:
+		s := r.span(n)
+		return n.Value != tokenText(s.tokens, s.start, s.stop, false)
+	case *Limit:
+		hasCount := false
+		return r.tokensMatch(node, func(text string) bool {
+			switch strings.ToUpper(text) {
+			case "LIMIT", "FETCH":
+				hasCount = true
+				return true
+			case "OFFSET", "ALL", "FIRST", "NEXT", "ROW", "ROWS", "ONLY", "WITH", "TIES", ",":
+				return true
+			}
+			_, err := strconv.ParseUint(text, 10, 64)
+			return err == nil
+		}) && hasCount
+	}
+	return false
+}
+
+// tokensMatch 节点范围内的非空白token是否均满足match
+func (r *restorer) tokensMatch(node INode, match func(text string) bool) bool {
+	s := r.span(node)
+	for i := s.start; i <= s.stop; i++ {
+		if text, hidden := s.tokens.token(i); !hidden && !match(text) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNameToken 名称中的标识符、点及*，下标、函数调用等无法以字段表示
+func isNameToken(text string) bool {
+	if text == "." || text == "*" {
+		return true
+	}
+	c, _ := firstRune(strings.TrimPrefix(text, "."))
+	return c == '_' || c == '`' || c == '"' || c == '\'' || c == '$' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func firstRune(s string) (rune, bool) {
+	for _, c := range s {
+		return c, true
+	}
+	return 0, false
+}
+
+// generate 按字段生成节点，不支持的节点返回false且不输出
+func (r *restorer) generate(node INode) bool {
+	switch n := node.(type) {
+	case *TableName:
+		r.name(n, n.Owner, n.Identifier)
+	case *ColumnName:
+		r.name(n, n.Owner, n.Identifier)
+		for _, attr := range n.NestedObjectAttrs {
+			r.write(".", attr)
+		}
+	case *FullId:
+		r.write(strings.Join(n.Uids, "."))
+	case *Constant:
+		r.write(n.Value)
+	case *Limit:
+		r.limit(n)
+
+	case *SimpleSelectStmt:
+		r.with(n.With)
+		r.restore(n.QuerySpecification)
+	case *ParenthesisSelect:
+		r.with(n.With)
+		r.restore(n.QueryExpr)
+	case *UnionSelectStmt:
+		if n.QuerySpecification == nil && n.QueryExpr == nil {
+			return false
+		}
+		r.with(n.With)
+		r.queryBranch(n.QuerySpecification, n.QueryExpr)
+		for _, us := range n.UnionStmts {
+			r.write(" ")
+			r.restore(us)
+		}
+		r.orderBy(n.OrderBy)
+		if n.Limit != nil {
+			r.write(" ")
+			r.restore(n.Limit)
+		}
+	case *LateralSelectStmt:
+		r.restore(n.QuerySpecification)
+		for _, lateral := range n.Laterals {
+			r.write(", LATERAL ")
+			r.restore(lateral)
+		}
+	case *TableStmt:
+		r.write("TABLE ")
+		r.restore(n.TableName)
+		r.orderBy(n.OrderBy)
+		if n.Limit != nil {
+			r.write(" ")
+			r.restore(n.Limit)
+		}
+	case *ValuesStmt:
+		if len(n.Rows) == 0 {
+			return false
+		}
+		r.write("VALUES ")
+		join(r, ", ", n.Rows)
+	case *UnionStmt:
+		if n.QuerySpecification == nil && n.QueryExpr == nil {
+			return false
+		}
+		unionType := strings.ToUpper(n.UnionType)
+		if unionType == "" {
+			unionType = "UNION"
+		}
+		r.write(unionType, " ")
+		r.queryBranch(n.QuerySpecification, n.QueryExpr)
+	case *QueryExpr:
+		if n.QuerySpecification == nil && n.QueryExpr == nil {
+			return false
+		}
+		r.write("(")
+		r.queryBranch(n.QuerySpecification, n.QueryExpr)
+		r.write(")")
+	case *QuerySpecification:
+		r.write("SELECT ")
+		if n.Distinct {
+			r.write("DISTINCT ")
+		}
+		r.restore(n.SelectElements)
+		if n.From != nil {
+			r.write(" FROM ")
+			r.restore(n.From)
+		}
+		r.where(n.Where)
+		if len(n.GroupBy) > 0 {
+			r.write(" GROUP BY ")
+			join(r, ", ", n.GroupBy)
+		}
+		if !isNil(n.Having) {
+			r.write(" HAVING ")
+			r.restore(n.Having)
+		}
+		r.orderBy(n.OrderBy)
+		if n.Limit != nil {
+			r.write(" ")
+			r.restore(n.Limit)
+		}
+	case *SelectElements:
+		if n.Star != "" {
+			r.write("*")
+			if len(n.Elements) > 0 {
+				r.write(", ")
+			}
+		}
+		join(r, ", ", n.Elements)
+	case *SelectStarElement:
+		if n.FullId != "" {
+			r.write(n.FullId, ".")
+		}
+		r.write("*")
+	case *SelectColumnElement:
+		r.restore(n.FullColumnName)
+		r.alias(n.Alias)
+	case *SelectExpressionElement:
+		r.restore(n.Expr)
+		r.alias(n.Alias)
+	case *OrderByExpr:
+		r.restore(n.Expr)
+		if n.Desc {
+			r.write(" DESC")
+		}
+	case *WithClause:
+		r.write("WITH ")
+		if n.Recursive {
+			r.write("RECURSIVE ")
+		}
+		join(r, ", ", n.CTEs)
+	case *CommonTableExpr:
+		r.write(n.Name)
+		if len(n.Columns) > 0 {
+			r.write(" (", strings.Join(n.Columns, ", "), ")")
+		}
+		r.write(" AS (")
+		r.restore(n.Stmt)
+		r.write(")")
+
+	case *LogicalExpr:
+		for i, expr := range n.Exprs {
+			if i > 0 {
+				r.write(" ", strings.ToUpper(n.Operator), " ")
+			}
+			// 不同运算符的条件以括号保持优先级
+			if le, ok := expr.(*LogicalExpr); ok && !strings.EqualFold(le.Operator, n.Operator) {
+				r.write("(")
+				r.restore(expr)
+				r.write(")")
+				continue
+			}
+			r.restore(expr)
+		}
+	case *PredicateExpr:
+		r.restore(n.Predicate)
+	case *NotExpr:
+		if _, ok := n.Operand.(*LogicalExpr); ok {
+			r.write("NOT (")
+			r.restore(n.Operand)
+			r.write(")")
+			break
+		}
+		r.write("NOT ")
+		r.restore(n.Operand)
+	case *IsExpr:
+		r.restore(n.Predicate)
+		r.write(" IS ", notKeyword(n.Not), n.TestValue)
+	case *BinaryComparisonPredicate:
+		r.restore(n.Left)
+		r.write(" ", n.ComparisonOperator, " ")
+		r.restore(n.Right)
+	case *InPredicate:
+		if isNil(n.SelectStmt) && len(n.Exprs) == 0 {
+			return false
+		}
+		r.restore(n.Predicate)
+		r.write(" ", notKeyword(n.Not), "IN (")
+		if !isNil(n.SelectStmt) {
+			r.restore(n.SelectStmt)
+		} else {
+			join(r, ", ", n.Exprs)
+		}
+		r.write(")")
+	case *SubqueryComparisonPredicate:
+		r.restore(n.Predicate)
+		r.write(" ", n.ComparisonOperator, " ", n.Quantifier, " (")
+		r.restore(n.SelectStmt)
+		r.write(")")
+	case *IsNullPredicate:
+		r.restore(n.Predicate)
+		r.write(" IS ", notKeyword(n.Not), "NULL")
+	case *BetweenPredicate:
+		r.restore(n.Predicate)
+		r.write(" ", notKeyword(n.Not), "BETWEEN ")
+		r.restore(n.Low)
+		r.write(" AND ")
+		r.restore(n.High)
+	case *LikePredicate:
+		r.restore(n.Predicate)
+		r.write(" ", notKeyword(n.Not), "LIKE ")
+		r.restore(n.Pattern)
+		if n.Escape != "" {
+			r.write(" ESCAPE ", n.Escape)
+		}
+	case *RegexpPredicate:
+		r.restore(n.Predicate)
+		r.write(" ", notKeyword(n.Not), n.Operator, " ")
+		r.restore(n.Pattern)
+	case *ExprAtomPredicate:
+		r.restore(n.ExprAtom)
+	case *ExprAtomConstant:
+		r.restore(n.Constant)
+	case *ExprAtomColumnName:
+		r.restore(n.ColumnName)
+	case *ExprAtomSubquery:
+		r.subquery(n.SelectStmt)
+	case *ExprAtomExists:
+		r.write("EXISTS ")
+		r.subquery(n.SelectStmt)
+	case *ExprAtomUnary:
+		r.write(n.Operator)
+		// 避免 - -1 生成为注释 --1
+		switch n.Operand.(type) {
+		case *ExprAtomUnary, *ExprAtomConstant:
+			r.write(" ")
+		default:
+			if strings.EqualFold(n.Operator, "not") {
+				r.write(" ")
+			}
+		}
+		r.restore(n.Operand)
+	case *ExprAtomNested:
+		if len(n.Exprs) == 0 {
+			return false
+		}
+		if n.Row {
+			r.write("ROW")
+		}
+		r.write("(")
+		join(r, ", ", n.Exprs)
+		r.write(")")
+	case *ExprAtomArithmetic:
+		r.restore(n.Left)
+		r.write(" ", n.Operator, " ")
+		r.restore(n.Right)
+
+	case *TableSources:
+		join(r, ", ", n.TableSources)
+	case *TableSourceBase:
+		r.restore(n.TableSourceItem)
+		for _, jp := range n.JoinParts {
+			r.write(" ")
+			r.restore(jp)
+		}
+	case *AtomTableItem:
+		r.restore(n.TableName)
+		r.alias(n.Alias)
+	case *SubqueryTableItem:
+		r.subquery(n.SelectStmt)
+		r.alias(n.Alias)
+	case *TableSourcesItem:
+		r.write("(")
+		r.restore(n.TableSources)
+		r.write(")")
+	case *JoinPart:
+		r.join("JOIN ", n)
+	case *InnerJoin:
+		if isNil(n.On) && len(n.Using) == 0 {
+			r.join("CROSS JOIN ", &n.JoinPart)
+		} else {
+			r.join("JOIN ", &n.JoinPart)
+		}
+	case *OuterJoin:
+		r.join(strings.ToUpper(n.Type)+" JOIN ", &n.JoinPart)
+	case *NaturalJoin:
+		if n.Type != "" {
+			r.join("NATURAL "+strings.ToUpper(n.Type)+" JOIN ", &n.JoinPart)
+		} else {
+			r.join("NATURAL JOIN ", &n.JoinPart)
+		}
+
+	case *InsertStmt:
+		return r.insert("INSERT", n)
+	case *ReplaceStmt:
+		return r.insert("REPLACE", &n.InsertStmt)
+	case *UpdateStmt:
+		r.with(n.With)
+		r.write("UPDATE ")
+		r.restore(n.TableSources)
+		r.write(" SET ")
+		join(r, ", ", n.UpdatedElements)
+		if n.From != nil {
+			r.write(" FROM ")
+			r.restore(n.From)
+		}
+		r.where(n.Where)
+	case *UpdatedElement:
+		r.restore(n.ColumnName)
+		r.write(" = ")
+		r.exprOrDefault(n.Value)
+	case *ValuesRow:
+		r.write("(")
+		for i, expr := range n.Exprs {
+			if i > 0 {
+				r.write(", ")
+			}
+			r.exprOrDefault(expr)
+		}
+		r.write(")")
+	case *LockTablesStmt:
+		if len(n.Tables) == 0 {
+			return false
+		}
+		r.write("LOCK TABLES ")
+		join(r, ", ", n.Tables)
+	case *LockTable:
+		r.restore(n.TableName)
+		r.alias(n.Alias)
+		if n.Write {
+			r.write(" WRITE")
+		} else {
+			r.write(" READ")
+		}
+	case *DoStmt:
+		if len(n.Exprs) == 0 {
+			return false
+		}
+		r.write("DO ")
+		join(r, ", ", n.Exprs)
+	case *DeleteStmt:
+		r.with(n.With)
+		r.write("DELETE ")
+		if len(n.Targets) > 0 {
+			join(r, ", ", n.Targets)
+			r.write(" ")
+		}
+		r.write("FROM ")
+		r.restore(n.TableSources)
+		if n.Using != nil {
+			r.write(" USING ")
+			r.restore(n.Using)
+		}
+		r.where(n.Where)
+	case *ExplainStmt:
+		r.write("EXPLAIN ")
+		if n.Analyze {
+			r.write("ANALYZE ")
+		}
+		r.restore(n.Stmt)
+
+	case *CreateTable:
+		switch {
+		case n.Like != nil && r.dialect == DialectPgsql:
+			r.write("CREATE TABLE ")
+			r.restore(n.TableName)
+			r.write(" (LIKE ")
+			r.restore(n.Like)
+			r.write(")")
+		case n.Like != nil:
+			r.write("CREATE TABLE ")
+			r.restore(n.TableName)
+			r.write(" LIKE ")
+			r.restore(n.Like)
+		case !isNil(n.SelectStmt):
+			r.write("CREATE TABLE ")
+			r.restore(n.TableName)
+			r.write(" AS ")
+			r.restore(n.SelectStmt)
+		default:
+			return false
+		}
+	case *CreateView:
+		r.write("CREATE VIEW ")
+		r.restore(n.TableName)
+		r.write(" AS ")
+		r.restore(n.SelectStmt)
+	case *DropTable:
+		r.write("DROP TABLE ")
+		join(r, ", ", n.TableNames)
+	case *DropView:
+		r.write("DROP VIEW ")
+		join(r, ", ", n.TableNames)
+	case *TruncateTable:
+		r.write("TRUNCATE TABLE ")
+		join(r, ", ", n.TableNames)
+	case *RenameTable:
+		if len(n.OldTableNames) == 0 || len(n.OldTableNames) != len(n.NewTableNames) {
+			return false
+		}
+		r.renameTable(n)
+	default:
+		return false
+	}
+	return true
+}
+
+// name [owner.]identifier，owner为原sql中的文本
+func (r *restorer) name(node INode, owner string, identifier *IdentifierValue) {
+	if owner != "" {
+		r.write(owner, ".")
+	}
+	r.identifier(node, identifier)
+}
+
+// identifier 指定了引号时使用该引号，未指定且包含特殊字符或为方言的保留字时使用方言的引号，
+// 保留字在node的原sql中未加引号出现时(语法允许其作为标识符)保持原样
+func (r *restorer) identifier(node INode, iv *IdentifierValue) {
+	if iv == nil {
+		r.err = fmt.Errorf("%w: missing identifier", ErrNotRestorable)
+		return
+	}
+	if qc := iv.QuoteChar; qc != nil && qc.StartDelimiter != "" {
+		r.write(qc.Wrap(iv.Value))
+		return
+	}
+	if isPlainIdentifier(iv.Value) && (!isReservedKeyword(r.dialect, iv.Value) || r.bareInSource(node, iv.Value)) {
+		r.write(iv.Value)
+		return
+	}
+	quote := `"`
+	if r.dialect == DialectMysql {
+		quote = "`"
+	}
+	r.write(quote, strings.ReplaceAll(iv.Value, quote, quote+quote), quote)
+}
+
+// bareInSource 原sql中node范围内是否有文本为value的token
+func (r *restorer) bareInSource(node INode, value string) bool {
+	s := r.span(node)
+	for i := s.start; s.ok && i <= s.stop; i++ {
+		if text, hidden := s.tokens.token(i); !hidden && text == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isPlainIdentifier(s string) bool {
+	for i, c := range s {
+		if c != '_' && !unicode.IsLetter(c) && (i == 0 || c != '$' && !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// limit 一般为limit n offset m，原sql为mysql的limit m, n或pgsql的fetch first时保持该形式，pgsql的with ties为fetch first
+// 不限制行数时pgsql为limit all或仅offset，mysql没有对应的语法，行数为最大值
+func (r *restorer) limit(l *Limit) {
+	if l.Offset < 0 || !l.Unlimited && l.RowCount < 0 {
+		r.err = fmt.Errorf("%w: negative limit %d offset %d", ErrNotRestorable, l.RowCount, l.Offset)
+		return
+	}
+	if l.Unlimited {
+		switch {
+		case r.dialect == DialectMysql:
+			r.write("LIMIT ", strconv.Itoa(l.Offset), ", 18446744073709551615")
+		case l.Offset > 0:
+			r.write("OFFSET ", strconv.Itoa(l.Offset))
+		default:
+			r.write("LIMIT ALL")
+		}
+		return
+	}
+	var comma, fetch bool
+	if r.span(l).ok {
+		r.tokensMatch(l, func(text string) bool {
+			comma = comma || text == ","
+			fetch = fetch || strings.EqualFold(text, "FETCH")
+			return true
+		})
+	}
+	switch {
+	case comma:
+		r.write("LIMIT ", strconv.Itoa(l.Offset), ", ", strconv.Itoa(l.RowCount))
+	case r.dialect == DialectPgsql && (l.WithTies || fetch):
+		if l.Offset > 0 {
+			r.write("OFFSET ", strconv.Itoa(l.Offset), " ROWS ")
+		}
+		r.write("FETCH FIRST ", strconv.Itoa(l.RowCount), " ROWS ")
+		if l.WithTies {
+			r.write("WITH TIES")
+		} else {
+			r.write("ONLY")
+		}
+	default:
+		r.write("LIMIT ", strconv.Itoa(l.RowCount))
+		if l.Offset > 0 {
+			r.write(" OFFSET ", strconv.Itoa(l.Offset))
+		}
+	}
+}
+
+func (r *restorer) with(with *WithClause) {
+	if with != nil {
+		r.restore(with)
+		r.write(" ")
+	}
+}
+
+// subquery 圆括号中的查询，mysql中解析得到的圆括号查询已包含圆括号
+func (r *restorer) subquery(stmt ISelectStmt) {
+	if ps, ok := stmt.(*ParenthesisSelect); ok && ps.With == nil {
+		r.restore(ps)
+		return
+	}
+	r.write("(")
+	r.restore(stmt)
+	r.write(")")
+}
+
+func (r *restorer) where(where IExpr) {
+	if !isNil(where) {
+		r.write(" WHERE ")
+		r.restore(where)
+	}
+}
+
+func (r *restorer) orderBy(orderBy []*OrderByExpr) {
+	if len(orderBy) > 0 {
+		r.write(" ORDER BY ")
+		join(r, ", ", orderBy)
+	}
+}
+
+func (r *restorer) alias(alias string) {
+	if alias != "" {
+		r.write(" AS ", alias)
+	}
+}
+
+func (r *restorer) queryBranch(qs *QuerySpecification, qe *QueryExpr) {
+	if qs != nil {
+		r.restore(qs)
+	} else {
+		r.restore(qe)
+	}
+}
+
+func (r *restorer) join(keyword string, jp *JoinPart) {
+	r.write(keyword)
+	r.restore(jp.TableSourceItem)
+	if !isNil(jp.On) {
+		r.write(" ON ")
+		r.restore(jp.On)
+	}
+	if len(jp.Using) > 0 {
+		r.write(" USING (", strings.Join(jp.Using, ", "), ")")
+	}
+}
+
+// insert 仅支持insert ... select，values未解析为字段
+// insert pgsql的on conflict需指定冲突的列或约束，未以字段表示，无法生成
+func (r *restorer) insert(keyword string, n *InsertStmt) bool {
+	if isNil(n.SelectStmt) && len(n.Values) == 0 && len(n.SetElements) == 0 {
+		return false
+	}
+	if r.dialect == DialectPgsql && (len(n.DuplicatedElements) > 0 || !isNil(n.ConflictWhere)) {
+		return false
+	}
+	r.with(n.With)
+	r.write(keyword, " INTO ")
+	r.restore(n.TableName)
+	if len(n.Columns) > 0 {
+		r.write(" (")
+		join(r, ", ", n.Columns)
+		r.write(")")
+	}
+	switch {
+	case len(n.SetElements) > 0:
+		r.write(" SET ")
+		join(r, ", ", n.SetElements)
+	case len(n.Values) > 0:
+		r.write(" VALUES ")
+		join(r, ", ", n.Values)
+	default:
+		r.write(" ")
+		r.restore(n.SelectStmt)
+	}
+	if len(n.DuplicatedElements) > 0 {
+		r.write(" ON DUPLICATE KEY UPDATE ")
+		join(r, ", ", n.DuplicatedElements)
+	}
+	return true
+}
+
+// exprOrDefault insert的values及赋值中为nil的表达式为default
+func (r *restorer) exprOrDefault(expr IExpr) {
+	if isNil(expr) {
+		r.write("DEFAULT")
+		return
+	}
+	r.restore(expr)
+}
+
+// renameTable pgsql每条alter table只能重命名一张表，新表名不能指定schema
+func (r *restorer) renameTable(n *RenameTable) {
+	if r.dialect != DialectPgsql {
+		r.write("RENAME TABLE ")
+		for i, old := range n.OldTableNames {
+			if i > 0 {
+				r.write(", ")
+			}
+			r.restore(old)
+			r.write(" TO ")
+			r.restore(n.NewTableNames[i])
+		}
+		return
+	}
+	for i, old := range n.OldTableNames {
+		if i > 0 {
+			r.write("; ")
+		}
+		r.write("ALTER TABLE ")
+		r.restore(old)
+		r.write(" RENAME TO ")
+		if tn := n.NewTableNames[i]; tn != nil {
+			r.identifier(tn, tn.Identifier)
+		} else {
+			r.restore(tn)
+		}
+	}
+}
+
+func join[T INode](r *restorer, sep string, nodes []T) {
+	for i, node := range nodes {
+		if i > 0 {
+			r.write(sep)
+		}
+		r.restore(node)
+	}
+}
+
+// notKeyword 取反的谓词中NOT及其后的空格
+func notKeyword(negated bool) string {
+	if negated {
+		return "NOT "
+	}
+	return ""
+}
+
+// isNil 接口为nil或包含nil指针
+func isNil(node INode) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
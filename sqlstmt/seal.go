@@ -0,0 +1,158 @@
+package sqlstmt
+
+import "reflect"
+
+// seal Seal时节点的字段
+type seal struct {
+	node   INode
+	fields reflect.Value // 节点的副本，子节点与原节点相同
+	dirty  bool          // 解码得到的节点在编码时已被修改
+	lossy  bool          // 解码得到的节点在编码时按字段生成无法保留原sql中的各token
+}
+
+// Seal 记录节点及其子孙节点当前的字段，之后字段被修改的节点由Restore按字段生成，而不是输出原sql；
+// 解析及Unmarshal得到的节点已记录，无需调用
+func Seal(node INode) {
+	Inspect(node, func(n INode) bool {
+		nd := nodeOf(n)
+		if nd == nil {
+			return true
+		}
+		// 多个节点可共享同一个Node，如单表update中的TableSources与AtomTableItem
+		s := seal{node: n, fields: cloneValue(reflect.ValueOf(n))}
+		for i := range nd.seals {
+			if nd.seals[i].node == n {
+				nd.seals[i] = s
+				return true
+			}
+		}
+		nd.seals = append(nd.seals, s)
+		return true
+	})
+}
+
+func nodeOf(node INode) *Node {
+	if rn, ok := node.(interface{ getNode() *Node }); ok {
+		return rn.getNode()
+	}
+	return nil
+}
+
+func sealOf(node INode) *seal {
+	if nd := nodeOf(node); nd != nil {
+		for i := range nd.seals {
+			if nd.seals[i].node == node {
+				return &nd.seals[i]
+			}
+		}
+	}
+	return nil
+}
+
+// modified 节点的字段在Seal后是否被修改，未Seal的节点视为未修改
+func modified(node INode) bool {
+	s := sealOf(node)
+	return s != nil && (s.dirty || !equalValue(s.fields, reflect.ValueOf(node)))
+}
+
+// sealed Seal时的节点，未Seal的节点返回其自身
+func sealed(node INode) INode {
+	if s := sealOf(node); s != nil && !s.dirty {
+		return s.fields.Interface().(INode)
+	}
+	return node
+}
+
+// markModified 将已Seal的节点标记为修改
+func markModified(node INode, lossy bool) {
+	if s := sealOf(node); s != nil {
+		s.dirty, s.lossy = true, lossy
+	}
+}
+
+// cloneValue 复制节点的字段，子节点不复制
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneField(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				c.Field(i).Set(cloneField(v.Field(i)))
+			}
+		}
+		return c
+	}
+	return v
+}
+
+func cloneField(v reflect.Value) reflect.Value {
+	if isNodeType(v.Type()) {
+		return v
+	}
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		c := reflect.New(v.Type()).Elem()
+		c.Set(cloneValue(v.Elem()))
+		return c
+	}
+	return cloneValue(v)
+}
+
+// equalValue 比较节点的字段，子节点按指针比较，修改子节点的字段不影响该节点
+func equalValue(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return equalValue(a.Elem(), b.Elem())
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalField(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if a.Type().Field(i).IsExported() && !equalField(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return a.Elem().Type() == b.Elem().Type() && equalValue(a.Elem(), b.Elem())
+	case reflect.Map, reflect.Func, reflect.Chan:
+		return true
+	}
+	return a.Equal(b)
+}
+
+func equalField(a, b reflect.Value) bool {
+	if isNodeType(a.Type()) {
+		return a.Equal(b)
+	}
+	return equalValue(a, b)
+}
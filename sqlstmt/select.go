@@ -5,18 +5,37 @@ type (
 		INode
 
 		isSelect()
+
+		// GetSideEffects 查询除读取数据外的副作用
+		GetSideEffects() SelectSideEffects
 	}
 
 	SelectStmt struct {
 		*Node
+
+		With        *WithClause
+		SideEffects SelectSideEffects
+	}
+
+	// SelectSideEffects 查询除读取数据外的副作用，包含子查询、with中的语句
+	SelectSideEffects struct {
+		IntoFile      bool // mysql的select ... into outfile/dumpfile，写服务器文件
+		IntoTable     bool // pgsql的select ... into new_table，创建表并写入数据
+		IntoVariables bool // select ... into @var，仅修改会话变量
+		Locking       bool // for update、for share、lock in share mode，对读取的行加锁
+		ModifyingCTE  bool // pgsql的with x as (delete ... returning ...) select，with中包含insert、update、delete
 	}
 
 	QuerySpecification struct {
 		*Node
 
+		Distinct       bool // select distinct，mysql的distinctrow、pgsql的distinct on同样为true
 		SelectElements *SelectElements
 		From           *TableSources
 		Where          IExpr
+		GroupBy        []IExpr
+		Having         IExpr
+		OrderBy        []*OrderByExpr
 		Limit          *Limit
 	}
 
@@ -33,6 +52,7 @@ type (
 		QuerySpecification *QuerySpecification
 		QueryExpr          *QueryExpr
 		UnionStmts         []*UnionStmt
+		OrderBy            []*OrderByExpr
 		Limit              *Limit
 	}
 
@@ -49,10 +69,54 @@ type (
 		QuerySpecification *QuerySpecification
 		QueryExpr          *QueryExpr
 	}
+
+	// TableStmt mysql的table语句，如 table t order by a limit 10，等同于select * from t
+	TableStmt struct {
+		SelectStmt
+
+		TableName *TableName
+		OrderBy   []*OrderByExpr
+		Limit     *Limit
+	}
+
+	// ValuesStmt mysql的values语句，如 values (1, 2), (3, 4)
+	ValuesStmt struct {
+		SelectStmt
+
+		Rows []*ValuesRow
+	}
+
+	// LateralSelectStmt mysql中from后以逗号连接lateral派生表的查询，如 select * from t1, lateral (select * from t2 where t2.id = t1.id) as dt
+	LateralSelectStmt struct {
+		SelectStmt
+
+		QuerySpecification *QuerySpecification
+		Laterals           []*SubqueryTableItem // 各lateral派生表
+	}
 )
 
 func (*SelectStmt) isSelect() {}
 
+func (*SelectStmt) Kind() StmtKind { return KindSelect }
+
+func (s *SelectStmt) GetSideEffects() SelectSideEffects { return s.SideEffects }
+
+// IsReadOnly 是否仅读取数据，修改会话变量不视为写入
+func (e SelectSideEffects) IsReadOnly() bool {
+	return !e.IntoFile && !e.IntoTable && !e.Locking && !e.ModifyingCTE
+}
+
+// Merge 合并两个查询的副作用，如查询与其中的子查询
+func (e SelectSideEffects) Merge(other SelectSideEffects) SelectSideEffects {
+	return SelectSideEffects{
+		IntoFile:      e.IntoFile || other.IntoFile,
+		IntoTable:     e.IntoTable || other.IntoTable,
+		IntoVariables: e.IntoVariables || other.IntoVariables,
+		Locking:       e.Locking || other.Locking,
+		ModifyingCTE:  e.ModifyingCTE || other.ModifyingCTE,
+	}
+}
+
 // var _ (ISelectStmt) = (*SimpleSelectStmt)(nil)
 // var _ (ISelectStmt) = (*SelectStmt)(nil)
 // var _ (ISelectStmt) = (*ParenthesisSelect)(nil)
@@ -85,6 +149,15 @@ type (
 	SelectFunctionElement struct {
 		*Node
 
+		Alias      string
+		Subqueries []ISelectStmt // 函数参数中包含的子查询
+		Columns    []*ColumnName // 函数参数中引用的列，不包含子查询中的列
+	}
+
+	SelectExpressionElement struct {
+		*Node
+
+		Expr  IExpr
 		Alias string
 	}
 )
@@ -93,11 +166,20 @@ type From struct {
 	TableSource *ITableSource
 }
 
+type OrderByExpr struct {
+	*Node
+
+	Expr IExpr
+	Desc bool
+}
+
 type Limit struct {
 	*Node
 
-	RowCount int
-	Offset   int
+	RowCount  int
+	Offset    int
+	WithTies  bool // pgsql的fetch first ... with ties，行数取决于order by
+	Unlimited bool // 不限制行数，如pgsql的limit all或仅有offset，此时RowCount无意义
 }
 
 type (
@@ -109,6 +191,8 @@ type (
 		*Node
 
 		TableSourceItem ITableSourceItem
+		On              IExpr    // on条件
+		Using           []string // using中的列
 	}
 
 	InnerJoin struct {
@@ -117,10 +201,14 @@ type (
 
 	OuterJoin struct {
 		JoinPart
+
+		Type string // LEFT、RIGHT、FULL
 	}
 
 	NaturalJoin struct {
 		JoinPart
+
+		Type string // LEFT、RIGHT、FULL，内连接时为空
 	}
 )
 
@@ -133,3 +221,22 @@ type (
 		QueryExpr          *QueryExpr
 	}
 )
+
+type (
+	// WithClause with子句
+	WithClause struct {
+		*Node
+
+		Recursive bool
+		CTEs      []*CommonTableExpr
+	}
+
+	// CommonTableExpr 公共表表达式，如 with t1 (id) as (select id from t)
+	CommonTableExpr struct {
+		*Node
+
+		Name    string
+		Columns []string
+		Stmt    Stmt
+	}
+)
@@ -1,7 +1,7 @@
 package sqlstmt
 
 import (
-	"reflect"
+	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
 )
@@ -9,32 +9,71 @@ import (
 type INode interface {
 	GetText() string
 
-	// GetStartIndex() int
-	// GetStopIndex() int
+	// Restore 由节点生成sql写入w。解析得到的节点输出原sql(包括注释及字段未表示的语法，如hint、for update)，其中的子节点按同样的方式生成；
+	// 表名、列名、常量及仅包含数字的limit按字段生成；字段被修改的节点(见Seal)、手动构造的节点及包含手动构造的子节点的节点按字段生成，
+	// 此时运算符的优先级等不影响语义的语法不保留，原sql中字段未表示的语法(如returning)无法保留或无法生成时返回ErrNotRestorable
+	Restore(w *strings.Builder, dialect Dialect) error
+
+	// Pos 节点第一个字符的位置，End 节点最后一个字符之后的位置，均为在原sql中的位置，手动构造的节点为无效的位置
+	Pos() Position
+	End() Position
+	// GetStartIndex 节点第一个字节在原sql中的偏移，GetStopIndex 最后一个字节的偏移，手动构造的节点为-1
+	GetStartIndex() int
+	GetStopIndex() int
 }
 
 type Node struct {
-	// startIndex int
-	// stopIndex  int
-
 	parser      antlr.Parser
 	ruleContext antlr.RuleContext
+	input       *input // 被解析的sql及其在原sql中的位置，nil时原sql即被解析的sql
+
+	// Detach后节点的token范围及位置
+	source      *source
+	start, stop int
+	pos, end    Position
+
+	seals []seal // Seal时内嵌该Node的各节点的字段
 }
 
 func (n *Node) GetText() string {
+	if n != nil && n.source != nil {
+		return tokenText(n.source, n.start, n.stop, true)
+	}
 	if n == nil || n.ruleContext == nil || n.parser == nil {
 		return ""
 	}
 	return n.parser.GetTokenStream().GetTextFromRuleContext(n.ruleContext)
 }
 
-// func (n *Node) GetStartIndex() int {
-// 	return n.startIndex
-// }
+// GetParser 解析该节点时使用的parser，可通过其token流改写sql
+func (n *Node) GetParser() antlr.Parser {
+	if n == nil {
+		return nil
+	}
+	return n.parser
+}
 
-// func (n *Node) GetStopIndex() int {
-// 	return n.stopIndex
-// }
+// GetRuleContext 节点对应的语法树节点
+func (n *Node) GetRuleContext() antlr.RuleContext {
+	if n == nil {
+		return nil
+	}
+	return n.ruleContext
+}
+
+func (n *Node) GetStartIndex() int {
+	if pos := n.Pos(); pos.IsValid() {
+		return pos.Offset
+	}
+	return -1
+}
+
+func (n *Node) GetStopIndex() int {
+	if end := n.End(); end.IsValid() {
+		return end.Offset - 1
+	}
+	return -1
+}
 
 func NewNode(parser antlr.Parser, ruleContext antlr.RuleContext) *Node {
 	return &Node{
@@ -43,33 +82,131 @@ func NewNode(parser antlr.Parser, ruleContext antlr.RuleContext) *Node {
 	}
 }
 
-// func NewNode(startIndex, stopIndex int) *Node {
-// 	return &Node{
-// 		startIndex: startIndex,
-// 		stopIndex:  stopIndex,
-// 	}
-// }
-
-// func GetText(sqlstmts string, node INode) string {
-// 	return sqlstmts[node.GetStartIndex() : node.GetStopIndex()+1]
-// }
-
 type Stmt interface {
 	INode
+
+	// Kind 语句类型
+	Kind() StmtKind
 }
 
 type SqlStmt struct {
 	*Node
 }
 
+func (*SqlStmt) Kind() StmtKind { return KindOther }
+
 type DmlStmt struct {
 	SqlStmt
 }
 
+// OtherReadStmt 其他只读语句，如help
 type OtherReadStmt struct {
 	*Node
 }
 
+func (*OtherReadStmt) Kind() StmtKind { return KindOther }
+
+// OtherStmt 未单独处理的语句
+type OtherStmt struct {
+	*Node
+}
+
+func (*OtherStmt) Kind() StmtKind { return KindOther }
+
+// DclStmt 权限控制语句，如grant、revoke、create user
+type DclStmt struct {
+	*Node
+}
+
+func (*DclStmt) Kind() StmtKind { return KindDCL }
+
+// TclStmt 事务控制语句，如begin、commit、rollback、savepoint、lock tables
+type TclStmt struct {
+	*Node
+}
+
+func (*TclStmt) Kind() StmtKind { return KindTCL }
+
+// LockTablesStmt mysql的lock tables
+type LockTablesStmt struct {
+	*Node
+
+	Tables []*LockTable
+}
+
+func (*LockTablesStmt) Kind() StmtKind { return KindTCL }
+
+// LockTable lock tables中的表，如 t1 as a read、t2 low_priority write
+type LockTable struct {
+	*Node
+
+	TableName *TableName
+	Alias     string
+	Write     bool // write锁，为false时为read锁
+}
+
+// HandlerStmt mysql的handler open、read、close，TableName为open中的表名，read、close中的表名或open时指定的别名
+type HandlerStmt struct {
+	*Node
+
+	TableName *TableName
+	Alias     string // handler ... open as中的别名
+	Where     IExpr  // handler ... read中的where条件
+}
+
+func (*HandlerStmt) Kind() StmtKind { return KindHandler }
+
+// ShowStmt show语句及mysql的describe table
+type ShowStmt struct {
+	*Node
+}
+
+func (*ShowStmt) Kind() StmtKind { return KindShow }
+
+// ExplainStmt 执行计划语句
+type ExplainStmt struct {
+	*Node
+
+	Analyze bool // explain analyze会实际执行语句
+	Stmt    Stmt // 被分析的语句，可能为nil
+}
+
+func (*ExplainStmt) Kind() StmtKind { return KindExplain }
+
+// SetStmt 设置变量等会话状态的语句
+type SetStmt struct {
+	*Node
+
+	Values []IExpr // mysql中赋给变量的表达式
+}
+
+func (*SetStmt) Kind() StmtKind { return KindSet }
+
+// UseStmt 切换数据库
+type UseStmt struct {
+	*Node
+}
+
+func (*UseStmt) Kind() StmtKind { return KindUse }
+
+// CallStmt 调用存储过程
+type CallStmt struct {
+	*Node
+
+	Args []IExpr // 参数
+}
+
+func (*CallStmt) Kind() StmtKind { return KindCall }
+
+// DoStmt mysql的do语句，计算表达式并丢弃结果
+type DoStmt struct {
+	*Node
+
+	Exprs []IExpr
+}
+
+func (*DoStmt) Kind() StmtKind { return KindOther }
+
 func IsSelectStmt(stmt Stmt) bool {
-	return reflect.TypeOf(stmt).AssignableTo(reflect.TypeOf(&SelectStmt{}))
+	return stmt != nil && stmt.Kind() == KindSelect
 }
@@ -0,0 +1,397 @@
+package sqlstmt
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TableAccess 表的访问方式
+type TableAccess int
+
+const (
+	AccessRead   TableAccess = iota // 读取数据
+	AccessWrite                     // 写入数据，如insert、update、delete的目标表
+	AccessCreate                    // 创建表或视图
+	AccessDrop                      // 删除表或视图，truncate同样需要drop权限
+	AccessAlter                     // 修改表结构，如alter table、create index、rename table的原表
+)
+
+var tableAccessNames = [...]string{
+	AccessRead:   "Read",
+	AccessWrite:  "Write",
+	AccessCreate: "Create",
+	AccessDrop:   "Drop",
+	AccessAlter:  "Alter",
+}
+
+func (a TableAccess) String() string {
+	if a >= 0 && int(a) < len(tableAccessNames) {
+		return tableAccessNames[a]
+	}
+	return "TableAccess(" + strconv.Itoa(int(a)) + ")"
+}
+
+// TableRef 语句中引用的表
+type TableRef struct {
+	TableName *TableName
+	Alias     string
+	Access    TableAccess
+}
+
+// ReferencedTables 返回语句中引用的全部表，包含join、子查询、派生表、with及union中的表
+// 同一张表被多次引用时返回多个TableRef，with中定义的公共表表达式不会作为表返回
+func ReferencedTables(stmt Stmt) []*TableRef {
+	c := new(tableCollector)
+	c.stmt(stmt)
+	return c.refs
+}
+
+type tableCollector struct {
+	refs []*TableRef
+	ctes cteScope
+}
+
+func (c *tableCollector) add(tableName *TableName, alias string, access TableAccess) {
+	if tableName == nil || tableName.Identifier == nil {
+		return
+	}
+	if access == AccessRead && tableName.Owner == "" && c.ctes.contains(tableName.Identifier.Value) {
+		return
+	}
+	c.refs = append(c.refs, &TableRef{TableName: tableName, Alias: alias, Access: access})
+}
+
+// cteScope 当前作用域内with定义的名称
+type cteScope []string
+
+func (s cteScope) contains(name string) bool {
+	for _, cte := range s {
+		if strings.EqualFold(cte, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// with 以stmt遍历with中的语句并将公共表表达式加入作用域，返回恢复作用域的函数
+func (s *cteScope) with(with *WithClause, stmt func(Stmt)) func() {
+	n := len(*s)
+	if with != nil {
+		for _, cte := range with.CTEs {
+			if with.Recursive {
+				*s = append(*s, cte.Name)
+			}
+			stmt(cte.Stmt)
+			if !with.Recursive {
+				*s = append(*s, cte.Name)
+			}
+		}
+	}
+	return func() { *s = (*s)[:n] }
+}
+
+func (c *tableCollector) stmt(stmt Stmt) {
+	switch s := stmt.(type) {
+	case ISelectStmt:
+		c.selectStmt(s)
+	case *InsertStmt:
+		c.insert(s)
+	case *ReplaceStmt:
+		c.insert(&s.InsertStmt)
+	case *UpdateStmt:
+		c.update(s)
+	case *DeleteStmt:
+		c.delete(s)
+	case *ExplainStmt:
+		c.stmt(s.Stmt)
+	case *LoadDataStmt:
+		c.add(s.TableName, "", AccessWrite)
+		c.updatedElements(s.SetElements)
+	case *HandlerStmt:
+		// read、close中的表名可能是open时指定的别名
+		c.add(s.TableName, s.Alias, AccessRead)
+		c.expr(s.Where)
+	case *LockTablesStmt:
+		for _, lt := range s.Tables {
+			if lt.Write {
+				c.add(lt.TableName, lt.Alias, AccessWrite)
+			} else {
+				c.add(lt.TableName, lt.Alias, AccessRead)
+			}
+		}
+	case *CallStmt:
+		c.exprs(s.Args)
+	case *SetStmt:
+		c.exprs(s.Values)
+	case *DoStmt:
+		c.exprs(s.Exprs)
+	case *CreateTable:
+		c.add(s.TableName, "", AccessCreate)
+		c.add(s.Like, "", AccessRead)
+		c.selectStmt(s.SelectStmt)
+	case *CreateView:
+		c.add(s.TableName, "", AccessCreate)
+		c.selectStmt(s.SelectStmt)
+	case *AlterTable:
+		c.add(s.TableName, "", AccessAlter)
+	case *CreateIndex:
+		c.add(s.TableName, "", AccessAlter)
+	case *DropIndex:
+		c.add(s.TableName, "", AccessAlter)
+	case *DropTable:
+		c.tableNames(s.TableNames, AccessDrop)
+	case *DropView:
+		c.tableNames(s.TableNames, AccessDrop)
+	case *TruncateTable:
+		c.tableNames(s.TableNames, AccessDrop)
+	case *RenameTable:
+		for i, tableName := range s.OldTableNames {
+			c.add(tableName, "", AccessAlter)
+			if i < len(s.NewTableNames) {
+				c.add(s.NewTableNames[i], "", AccessCreate)
+			}
+		}
+	}
+}
+
+func (c *tableCollector) tableNames(tableNames []*TableName, access TableAccess) {
+	for _, tableName := range tableNames {
+		c.add(tableName, "", access)
+	}
+}
+
+func (c *tableCollector) selectStmt(stmt ISelectStmt) {
+	switch s := stmt.(type) {
+	case *SimpleSelectStmt:
+		defer c.ctes.with(s.With, c.stmt)()
+		c.querySpecification(s.QuerySpecification)
+	case *UnionSelectStmt:
+		defer c.ctes.with(s.With, c.stmt)()
+		c.querySpecification(s.QuerySpecification)
+		c.queryExpr(s.QueryExpr)
+		for _, union := range s.UnionStmts {
+			c.querySpecification(union.QuerySpecification)
+			c.queryExpr(union.QueryExpr)
+		}
+		c.orderBy(s.OrderBy)
+	case *ParenthesisSelect:
+		defer c.ctes.with(s.With, c.stmt)()
+		c.queryExpr(s.QueryExpr)
+	case *LateralSelectStmt:
+		c.querySpecification(s.QuerySpecification)
+		for _, lateral := range s.Laterals {
+			c.selectStmt(lateral.SelectStmt)
+		}
+	case *TableStmt:
+		c.add(s.TableName, "", AccessRead)
+	case *ValuesStmt:
+		for _, row := range s.Rows {
+			c.exprs(row.Exprs)
+		}
+	case *SelectStmt:
+		defer c.ctes.with(s.With, c.stmt)()
+	}
+}
+
+func (c *tableCollector) queryExpr(qe *QueryExpr) {
+	for ; qe != nil; qe = qe.QueryExpr {
+		c.querySpecification(qe.QuerySpecification)
+	}
+}
+
+func (c *tableCollector) querySpecification(qs *QuerySpecification) {
+	if qs == nil {
+		return
+	}
+	eachSubquery(qs.SelectElements, c.selectStmt)
+	c.tableSources(qs.From, AccessRead)
+	c.expr(qs.Where)
+	for _, expr := range qs.GroupBy {
+		c.expr(expr)
+	}
+	c.expr(qs.Having)
+	c.orderBy(qs.OrderBy)
+}
+
+func (c *tableCollector) orderBy(orderBy []*OrderByExpr) {
+	for _, obe := range orderBy {
+		c.expr(obe.Expr)
+	}
+}
+
+func (c *tableCollector) insert(s *InsertStmt) {
+	defer c.ctes.with(s.With, c.stmt)()
+	c.add(s.TableName, "", AccessWrite)
+	for _, row := range s.Values {
+		c.exprs(row.Exprs)
+	}
+	c.selectStmt(s.SelectStmt)
+	c.updatedElements(s.SetElements)
+	c.updatedElements(s.DuplicatedElements)
+	c.expr(s.ConflictWhere)
+}
+
+func (c *tableCollector) updatedElements(ues []*UpdatedElement) {
+	for _, ue := range ues {
+		c.expr(ue.Value)
+	}
+}
+
+func (c *tableCollector) exprs(exprs []IExpr) {
+	for _, expr := range exprs {
+		c.expr(expr)
+	}
+}
+
+func (c *tableCollector) update(s *UpdateStmt) {
+	defer c.ctes.with(s.With, c.stmt)()
+
+	// 多表更新时仅set中的列所属的表为写入，列未指定表时无法区分，视为全部写入
+	atoms := atomTableItems(s.TableSources)
+	written := make(map[*AtomTableItem]bool)
+	for _, atom := range atoms {
+		written[atom] = len(atoms) == 1
+	}
+	for _, ue := range s.UpdatedElements {
+		if ue.ColumnName == nil {
+			continue
+		}
+		for _, atom := range atoms {
+			written[atom] = written[atom] || ue.ColumnName.Owner == "" || atom.isReferencedBy(ue.ColumnName.Owner)
+		}
+	}
+	c.tableSourcesWith(s.TableSources, func(atom *AtomTableItem) TableAccess {
+		if written[atom] {
+			return AccessWrite
+		}
+		return AccessRead
+	})
+
+	c.updatedElements(s.UpdatedElements)
+	c.tableSources(s.From, AccessRead)
+	c.expr(s.Where)
+}
+
+func (c *tableCollector) delete(s *DeleteStmt) {
+	defer c.ctes.with(s.With, c.stmt)()
+
+	// 多表删除时仅Targets中的表为写入，Targets中为表名或别名
+	c.tableSourcesWith(s.TableSources, func(atom *AtomTableItem) TableAccess {
+		if len(s.Targets) == 0 {
+			return AccessWrite
+		}
+		for _, target := range s.Targets {
+			if target.Identifier != nil && atom.isReferencedBy(target.Identifier.Value) {
+				return AccessWrite
+			}
+		}
+		return AccessRead
+	})
+	c.tableSources(s.Using, AccessRead)
+	c.expr(s.Where)
+}
+
+// isReferencedBy 表是否可通过name引用，有别名时只能使用别名
+func (atom *AtomTableItem) isReferencedBy(name string) bool {
+	if atom.Alias != "" {
+		return strings.EqualFold(NewIdentifierValue(atom.Alias).Value, NewIdentifierValue(name).Value)
+	}
+	return atom.TableName != nil && atom.TableName.Identifier != nil &&
+		strings.EqualFold(atom.TableName.Identifier.Value, NewIdentifierValue(name).Value)
+}
+
+// atomTableItems 返回tableSources中直接引用的表，不包含子查询中的表
+func atomTableItems(tableSources *TableSources) []*AtomTableItem {
+	var atoms []*AtomTableItem
+	var item func(ITableSourceItem)
+	var sources func(*TableSources)
+	item = func(tsi ITableSourceItem) {
+		switch i := tsi.(type) {
+		case *AtomTableItem:
+			atoms = append(atoms, i)
+		case *TableSourcesItem:
+			sources(i.TableSources)
+		}
+	}
+	sources = func(tss *TableSources) {
+		if tss == nil {
+			return
+		}
+		for _, ts := range tss.TableSources {
+			if tsb, ok := ts.(*TableSourceBase); ok {
+				item(tsb.TableSourceItem)
+				for _, jp := range tsb.JoinParts {
+					if joinPart := getJoinPart(jp); joinPart != nil {
+						item(joinPart.TableSourceItem)
+					}
+				}
+			}
+		}
+	}
+	sources(tableSources)
+	return atoms
+}
+
+func (c *tableCollector) tableSources(tableSources *TableSources, access TableAccess) {
+	c.tableSourcesWith(tableSources, func(*AtomTableItem) TableAccess { return access })
+}
+
+// tableSourcesWith 收集tableSources中的表，直接引用的表的访问方式由access决定，子查询中的表均为读取
+func (c *tableCollector) tableSourcesWith(tableSources *TableSources, access func(*AtomTableItem) TableAccess) {
+	if tableSources == nil {
+		return
+	}
+	for _, ts := range tableSources.TableSources {
+		tsb, ok := ts.(*TableSourceBase)
+		if !ok {
+			continue
+		}
+		c.tableSourceItem(tsb.TableSourceItem, access)
+		for _, jp := range tsb.JoinParts {
+			if joinPart := getJoinPart(jp); joinPart != nil {
+				c.tableSourceItem(joinPart.TableSourceItem, access)
+				c.expr(joinPart.On)
+			}
+		}
+	}
+}
+
+func (c *tableCollector) tableSourceItem(tsi ITableSourceItem, access func(*AtomTableItem) TableAccess) {
+	switch i := tsi.(type) {
+	case *AtomTableItem:
+		c.add(i.TableName, i.Alias, access(i))
+	case *SubqueryTableItem:
+		c.selectStmt(i.SelectStmt)
+	case *TableSourcesItem:
+		c.tableSourcesWith(i.TableSources, access)
+	}
+}
+
+func getJoinPart(jp IJoinPart) *JoinPart {
+	switch j := jp.(type) {
+	case *JoinPart:
+		return j
+	case *InnerJoin:
+		return &j.JoinPart
+	case *OuterJoin:
+		return &j.JoinPart
+	case *NaturalJoin:
+		return &j.JoinPart
+	}
+	return nil
+}
+
+func (c *tableCollector) expr(expr IExpr) {
+	eachSubquery(expr, c.selectStmt)
+}
+
+// eachSubquery 遍历节点中的子查询，子查询中嵌套的子查询不会遍历
+func eachSubquery(node INode, fn func(ISelectStmt)) {
+	Inspect(node, func(n INode) bool {
+		if stmt, ok := n.(ISelectStmt); ok {
+			fn(stmt)
+			return false
+		}
+		return true
+	})
+}
@@ -10,14 +10,18 @@ type (
 	UpdateStmt struct {
 		*Node
 
+		With            *WithClause
 		TableSources    *TableSources
 		UpdatedElements []*UpdatedElement
+		From            *TableSources // pgsql的update ... from中的表
 		Where           IExpr
 	}
 )
 
 func (*UpdateStmt) isUpdate() {}
 
+func (*UpdateStmt) Kind() StmtKind { return KindUpdate }
+
 type UpdatedElement struct {
 	*Node
 
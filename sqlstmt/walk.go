@@ -0,0 +1,298 @@
+package sqlstmt
+
+import "sort"
+
+// Visitor Walk访问节点时调用Visit，返回的w不为nil时以w访问该节点的各子节点，之后调用w.Visit(nil)
+type Visitor interface {
+	Visit(node INode) (w Visitor)
+}
+
+// Walk 与go/ast.Walk相同，以深度优先的顺序遍历节点，子节点按在sql中出现的顺序访问，为nil的字段不访问
+// 子节点包括表达式、谓词、表、join、union的各分支、with中的语句、update的赋值等全部具体类型的字段，未解析为具体类型的节点中仅包含其中的子查询及列
+func Walk(v Visitor, node INode) {
+	if isNil(node) {
+		return
+	}
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	for _, child := range children(node) {
+		Walk(v, child)
+	}
+	v.Visit(nil)
+}
+
+type inspector func(INode) bool
+
+func (f inspector) Visit(node INode) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect 与go/ast.Inspect相同，f返回true时继续访问node的子节点，之后调用f(nil)
+func Inspect(node INode, f func(INode) bool) {
+	Walk(inspector(f), node)
+}
+
+// InspectStack 与Inspect相同，但不调用f(nil)；stack为node的各祖先节点，根节点在前，父节点为最后一个，f返回后不可保留stack
+func InspectStack(node INode, f func(node INode, stack []INode) bool) {
+	var stack []INode
+	Inspect(node, func(n INode) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		if !f(n, stack) {
+			return false
+		}
+		stack = append(stack, n)
+		return true
+	})
+}
+
+// children 节点的直接子节点，按在sql中出现的顺序，不包含nil
+func children(node INode) []INode {
+	var nodes []INode
+	switch n := node.(type) {
+	case *ExplainStmt:
+		nodes = appendNodes(nodes, n.Stmt)
+	case *SimpleSelectStmt:
+		nodes = appendNodes(nodes, n.With)
+		nodes = appendNodes(nodes, n.QuerySpecification)
+	case *UnionSelectStmt:
+		nodes = appendNodes(nodes, n.With)
+		nodes = appendNodes(nodes, n.QuerySpecification)
+		nodes = appendNodes(nodes, n.QueryExpr)
+		nodes = appendNodes(nodes, n.UnionStmts...)
+		nodes = appendNodes(nodes, n.OrderBy...)
+		nodes = appendNodes(nodes, n.Limit)
+	case *ParenthesisSelect:
+		nodes = appendNodes(nodes, n.With)
+		nodes = appendNodes(nodes, n.QueryExpr)
+	case *LateralSelectStmt:
+		nodes = appendNodes(nodes, n.With)
+		nodes = appendNodes(nodes, n.QuerySpecification)
+		nodes = appendNodes(nodes, n.Laterals...)
+	case *TableStmt:
+		nodes = appendNodes(nodes, n.With)
+		nodes = appendNodes(nodes, n.TableName)
+		nodes = appendNodes(nodes, n.OrderBy...)
+		nodes = appendNodes(nodes, n.Limit)
+	case *ValuesStmt:
+		nodes = appendNodes(nodes, n.With)
+		nodes = appendNodes(nodes, n.Rows...)
+	case *QueryExpr:
+		nodes = appendNodes(nodes, n.QuerySpecification)
+		nodes = appendNodes(nodes, n.QueryExpr)
+	case *UnionStmt:
+		nodes = appendNodes(nodes, n.QuerySpecification)
+		nodes = appendNodes(nodes, n.QueryExpr)
+	case *QuerySpecification:
+		nodes = appendNodes(nodes, n.SelectElements)
+		nodes = appendNodes(nodes, n.From)
+		nodes = appendNodes(nodes, n.Where)
+		nodes = appendNodes(nodes, n.GroupBy...)
+		nodes = appendNodes(nodes, n.Having)
+		nodes = appendNodes(nodes, n.OrderBy...)
+		nodes = appendNodes(nodes, n.Limit)
+	case *SelectElements:
+		nodes = appendNodes(nodes, n.Elements...)
+	case *SelectColumnElement:
+		nodes = appendNodes(nodes, n.FullColumnName)
+	case *SelectFunctionElement:
+		nodes = sourceOrder(appendNodes(appendNodes(nodes, n.Subqueries...), n.Columns...))
+	case *SelectExpressionElement:
+		nodes = appendNodes(nodes, n.Expr)
+	case *OrderByExpr:
+		nodes = appendNodes(nodes, n.Expr)
+	case *WithClause:
+		nodes = appendNodes(nodes, n.CTEs...)
+	case *CommonTableExpr:
+		nodes = appendNodes(nodes, n.Stmt)
+
+	case *Expr:
+		nodes = sourceOrder(appendNodes(appendNodes(nodes, n.Subqueries...), n.Columns...))
+	case *LogicalExpr:
+		nodes = appendNodes(nodes, n.Exprs...)
+	case *PredicateExpr:
+		nodes = appendNodes(nodes, n.Predicate)
+	case *NotExpr:
+		nodes = appendNodes(nodes, n.Operand)
+	case *IsExpr:
+		nodes = appendNodes(nodes, n.Predicate)
+	case *Predicate:
+		nodes = sourceOrder(appendNodes(appendNodes(nodes, n.Subqueries...), n.Columns...))
+	case *BinaryComparisonPredicate:
+		nodes = appendNodes(nodes, n.Left)
+		nodes = appendNodes(nodes, n.Right)
+	case *InPredicate:
+		nodes = appendNodes(nodes, n.Predicate)
+		nodes = appendNodes(nodes, n.Exprs...)
+		nodes = appendNodes(nodes, n.SelectStmt)
+	case *SubqueryComparisonPredicate:
+		nodes = appendNodes(nodes, n.Predicate)
+		nodes = appendNodes(nodes, n.SelectStmt)
+	case *IsNullPredicate:
+		nodes = appendNodes(nodes, n.Predicate)
+	case *BetweenPredicate:
+		nodes = appendNodes(nodes, n.Predicate)
+		nodes = appendNodes(nodes, n.Low)
+		nodes = appendNodes(nodes, n.High)
+	case *LikePredicate:
+		nodes = appendNodes(nodes, n.Predicate)
+		nodes = appendNodes(nodes, n.Pattern)
+	case *RegexpPredicate:
+		nodes = appendNodes(nodes, n.Predicate)
+		nodes = appendNodes(nodes, n.Pattern)
+	case *ExprAtomPredicate:
+		nodes = appendNodes(nodes, n.ExprAtom)
+	case *ExprAtom:
+		nodes = sourceOrder(appendNodes(appendNodes(nodes, n.Subqueries...), n.Columns...))
+	case *ExprAtomConstant:
+		nodes = appendNodes(nodes, n.Constant)
+	case *ExprAtomColumnName:
+		nodes = appendNodes(nodes, n.ColumnName)
+	case *ExprAtomSubquery:
+		nodes = appendNodes(nodes, n.SelectStmt)
+	case *ExprAtomExists:
+		nodes = appendNodes(nodes, n.SelectStmt)
+	case *ExprAtomUnary:
+		nodes = appendNodes(nodes, n.Operand)
+	case *ExprAtomNested:
+		nodes = appendNodes(nodes, n.Exprs...)
+	case *ExprAtomArithmetic:
+		nodes = appendNodes(nodes, n.Left)
+		nodes = appendNodes(nodes, n.Right)
+
+	case *TableSources:
+		nodes = appendNodes(nodes, n.TableSources...)
+	case *TableSourceBase:
+		nodes = appendNodes(nodes, n.TableSourceItem)
+		nodes = appendNodes(nodes, n.JoinParts...)
+	case *AtomTableItem:
+		nodes = appendNodes(nodes, n.TableName)
+	case *SubqueryTableItem:
+		nodes = appendNodes(nodes, n.SelectStmt)
+	case *TableSourcesItem:
+		nodes = appendNodes(nodes, n.TableSources)
+	case *JoinPart:
+		nodes = joinChildren(nodes, n)
+	case *InnerJoin:
+		nodes = joinChildren(nodes, &n.JoinPart)
+	case *OuterJoin:
+		nodes = joinChildren(nodes, &n.JoinPart)
+	case *NaturalJoin:
+		nodes = joinChildren(nodes, &n.JoinPart)
+
+	case *InsertStmt:
+		nodes = insertChildren(nodes, n)
+	case *ReplaceStmt:
+		nodes = insertChildren(nodes, &n.InsertStmt)
+	case *UpdateStmt:
+		nodes = appendNodes(nodes, n.With)
+		nodes = appendNodes(nodes, n.TableSources)
+		nodes = appendNodes(nodes, n.UpdatedElements...)
+		nodes = appendNodes(nodes, n.From)
+		nodes = appendNodes(nodes, n.Where)
+	case *UpdatedElement:
+		nodes = appendNodes(nodes, n.ColumnName)
+		nodes = appendNodes(nodes, n.Value)
+	case *DeleteStmt:
+		nodes = appendNodes(nodes, n.With)
+		nodes = appendNodes(nodes, n.Targets...)
+		nodes = appendNodes(nodes, n.TableSources)
+		nodes = appendNodes(nodes, n.Using)
+		nodes = appendNodes(nodes, n.Where)
+	case *ValuesRow:
+		nodes = appendNodes(nodes, n.Exprs...)
+	case *CallStmt:
+		nodes = appendNodes(nodes, n.Args...)
+	case *SetStmt:
+		nodes = appendNodes(nodes, n.Values...)
+	case *DoStmt:
+		nodes = appendNodes(nodes, n.Exprs...)
+	case *LoadDataStmt:
+		nodes = appendNodes(nodes, n.TableName)
+		nodes = appendNodes(nodes, n.SetElements...)
+	case *HandlerStmt:
+		nodes = appendNodes(nodes, n.TableName)
+		nodes = appendNodes(nodes, n.Where)
+	case *LockTablesStmt:
+		nodes = appendNodes(nodes, n.Tables...)
+	case *LockTable:
+		nodes = appendNodes(nodes, n.TableName)
+
+	case *CreateTable:
+		nodes = appendNodes(nodes, n.TableName)
+		nodes = appendNodes(nodes, n.Like)
+		nodes = appendNodes(nodes, n.SelectStmt)
+	case *CreateIndex:
+		nodes = appendNodes(nodes, n.TableName)
+	case *CreateView:
+		nodes = appendNodes(nodes, n.TableName)
+		nodes = appendNodes(nodes, n.SelectStmt)
+	case *AlterTable:
+		nodes = appendNodes(nodes, n.TableName)
+	case *DropIndex:
+		nodes = appendNodes(nodes, n.TableName)
+	case *DropTable:
+		nodes = appendNodes(nodes, n.TableNames...)
+	case *DropView:
+		nodes = appendNodes(nodes, n.TableNames...)
+	case *TruncateTable:
+		nodes = appendNodes(nodes, n.TableNames...)
+	case *RenameTable:
+		for i, old := range n.OldTableNames {
+			nodes = appendNodes(nodes, old)
+			if i < len(n.NewTableNames) {
+				nodes = appendNodes(nodes, n.NewTableNames[i])
+			}
+		}
+	}
+	return nodes
+}
+
+func joinChildren(nodes []INode, jp *JoinPart) []INode {
+	nodes = appendNodes(nodes, jp.TableSourceItem)
+	return appendNodes(nodes, jp.On)
+}
+
+func insertChildren(nodes []INode, is *InsertStmt) []INode {
+	nodes = appendNodes(nodes, is.With)
+	nodes = appendNodes(nodes, is.TableName)
+	nodes = appendNodes(nodes, is.Columns...)
+	nodes = appendNodes(nodes, is.Values...)
+	nodes = appendNodes(nodes, is.SelectStmt)
+	nodes = appendNodes(nodes, is.SetElements...)
+	nodes = appendNodes(nodes, is.DuplicatedElements...)
+	return appendNodes(nodes, is.ConflictWhere)
+}
+
+func appendNodes[T INode](nodes []INode, ts ...T) []INode {
+	for _, t := range ts {
+		if !isNil(t) {
+			nodes = append(nodes, t)
+		}
+	}
+	return nodes
+}
+
+// sourceOrder 按在sql中的位置排序，存在无法定位的节点时保持原顺序
+func sourceOrder(nodes []INode) []INode {
+	starts := make(map[INode]int, len(nodes))
+	for _, node := range nodes {
+		rn, ok := node.(interface{ getNode() *Node })
+		if !ok {
+			return nodes
+		}
+		_, start, _, ok := rn.getNode().tokenRange()
+		if !ok {
+			return nodes
+		}
+		starts[node] = start
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return starts[nodes[i]] < starts[nodes[j]] })
+	return nodes
+}